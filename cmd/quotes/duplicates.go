@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage/quotes/file"
+)
+
+// runDuplicates handles "quotes duplicates [flags]".
+func runDuplicates(args []string) {
+	fs := flag.NewFlagSet("duplicates", flag.ExitOnError)
+	storageType := fs.String("storage", "file", "storage type: file or sqlite")
+	dataDir := fs.String("data-dir", "data", "directory for the file storage")
+	dsn := fs.String("dsn", "file:quotes.db", "database for the sqlite storage")
+	merge := fs.Bool("merge", false, "merge each cluster into its oldest quote")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: quotes duplicates [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	repos, err := storageConfig{
+		typ:     *storageType,
+		dataDir: *dataDir,
+		dsn:     *dsn,
+		file:    file.Options{},
+	}.open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repos.closer.Close()
+
+	authorService := services.NewAuthorService(repos.authors, repos.quotes)
+	quoteService := services.NewQuoteService(repos.quotes, authorService, services.QuoteOptions{})
+
+	find := quoteService.FindDuplicates
+	if *merge {
+		find = func() ([]models.DuplicateCluster, error) {
+			return quoteService.MergeDuplicates("duplicates")
+		}
+	}
+	clusters, err := find()
+	// A failed merge still reports the clusters merged before it.
+	if err != nil && clusters == nil {
+		log.Fatal(err)
+	}
+
+	duplicates := 0
+	for _, cluster := range clusters {
+		fmt.Printf("%d\t%s\t%q\n", cluster.Canonical.ID, cluster.Canonical.Author, cluster.Canonical.Text)
+		for _, dup := range cluster.Duplicates {
+			fmt.Printf("  %d\t%.2f\t%q\n", dup.Quote.ID, dup.Similarity, dup.Quote.Text)
+		}
+		duplicates += len(cluster.Duplicates)
+	}
+	if *merge {
+		fmt.Printf("merged %d duplicates into %d quotes\n", duplicates, len(clusters))
+	} else {
+		fmt.Printf("found %d duplicates of %d quotes\n", duplicates, len(clusters))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
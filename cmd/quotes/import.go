@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"quotes/internal/importer"
+	"quotes/internal/services"
+	"quotes/internal/storage/quotes/file"
+)
+
+// runImport handles "quotes import [flags] file...".
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	storageType := fs.String("storage", "file", "storage type: file or sqlite")
+	dataDir := fs.String("data-dir", "data", "directory for the file storage")
+	dsn := fs.String("dsn", "file:quotes.db", "database for the sqlite storage")
+	format := fs.String("format", "", "format of the files: fortune or text; by default guessed from the file extension")
+	dryRun := fs.Bool("dry-run", false, "only report what would be imported")
+	defaultAuthor := fs.String("default-author", "", "author of the quotes without an attribution; such quotes are skipped if empty")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: quotes import [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *format != "" && *format != string(importer.FormatFortune) && *format != string(importer.FormatText) {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	repos, err := storageConfig{
+		typ:     *storageType,
+		dataDir: *dataDir,
+		dsn:     *dsn,
+		file:    file.Options{},
+	}.open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repos.closer.Close()
+
+	authorService := services.NewAuthorService(repos.authors, repos.quotes)
+	quoteService := services.NewQuoteService(repos.quotes, authorService, services.QuoteOptions{})
+	imp := importer.New(quoteService, importer.Options{
+		DryRun:        *dryRun,
+		DefaultAuthor: *defaultAuthor,
+		ChangedBy:     "import",
+	})
+
+	for _, path := range fs.Args() {
+		fileFormat := importer.Format(*format)
+		if fileFormat == "" {
+			fileFormat = importer.FormatOf(path)
+		}
+		if err := importFile(imp, path, fileFormat); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	summary := imp.Summary()
+	for _, failure := range summary.Failures {
+		fmt.Fprintln(os.Stderr, failure)
+	}
+	created := "created"
+	if *dryRun {
+		created = "would create"
+	}
+	fmt.Printf("parsed %d quotes: %s %d, duplicates %d, without author %d, failed %d\n",
+		summary.Parsed, created, summary.Created, summary.Duplicates, summary.Unattributed, len(summary.Failures))
+}
+
+func importFile(imp *importer.Importer, path string, format importer.Format) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := importer.Parse(format, f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return imp.Import(path, entries)
+}
@@ -1,28 +1,91 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"quotes/internal/handlers"
 	"quotes/internal/services"
-	"quotes/internal/storage/quotes/memory"
+	"quotes/internal/storage/quotes/file"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	repository := memory.NewQuoteStorage()
-	quoteService := services.NewQuoteService(repository)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "duplicates" {
+		runDuplicates(os.Args[2:])
+		return
+	}
+
+	storageType := flag.String("storage", "memory", "storage type: memory, file or sqlite")
+	dataDir := flag.String("data-dir", "data", "directory for the file storage")
+	snapshotInterval := flag.Duration("snapshot-interval", 5*time.Minute, "how often the file storage compacts its log into a snapshot")
+	snapshotThreshold := flag.Int("snapshot-threshold", 10000, "number of log records after which the file storage takes a snapshot")
+	dsn := flag.String("dsn", "file:quotes.db", "database for the sqlite storage")
+	shuffleTTL := flag.Duration("shuffle-ttl", services.DefaultShuffleTTL, "how long an idle client keeps its order of random quotes")
+	dailySeed := flag.String("daily-seed", "", "seed choosing the quotes of the day, shared by all replicas")
+	dailyWindow := flag.Int("daily-window", services.DefaultDailyWindow, "number of days within which a quote of the day is not repeated")
+	trashRetention := flag.Duration("trash-retention", services.DefaultTrashRetention, "how long deleted quotes stay in the trash, 0 keeps them forever")
+	flag.Parse()
+
+	if *shuffleTTL <= 0 {
+		log.Fatalf("shuffle-ttl must be positive, got %v", *shuffleTTL)
+	}
+	if *dailyWindow <= 0 {
+		log.Fatalf("daily-window must be positive, got %d", *dailyWindow)
+	}
+	if *trashRetention < 0 {
+		log.Fatalf("trash-retention must not be negative, got %v", *trashRetention)
+	}
+
+	repos, err := storageConfig{
+		typ:     *storageType,
+		dataDir: *dataDir,
+		dsn:     *dsn,
+		file: file.Options{
+			SnapshotInterval:  *snapshotInterval,
+			SnapshotThreshold: *snapshotThreshold,
+		},
+	}.open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repos.closer.Close()
+
+	authorService := services.NewAuthorService(repos.authors, repos.quotes)
+	linked, err := authorService.LinkQuotes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if linked > 0 {
+		log.Printf("Linked %d quotes to their authors", linked)
+	}
+
+	quoteService := services.NewQuoteService(repos.quotes, authorService, services.QuoteOptions{
+		ShuffleTTL:  *shuffleTTL,
+		DailySeed:   *dailySeed,
+		DailyWindow: *dailyWindow,
+	})
+	if *trashRetention > 0 {
+		go purgeTrash(quoteService, *trashRetention)
+	}
 	quoteHandler := handlers.NewQuoteHandler(quoteService)
+	authorHandler := handlers.NewAuthorHandler(authorService)
 
 	r := mux.NewRouter()
-
-	r.HandleFunc("/quotes", quoteHandler.CreateQuote).Methods("POST")
-	r.HandleFunc("/quotes", quoteHandler.GetAllQuotes).Methods("GET")
-	r.HandleFunc("/quotes/random", quoteHandler.GetRandomQuote).Methods("GET")
-	r.HandleFunc("/quotes", quoteHandler.GetQuotesByAuthor).Methods("GET").Queries("author", "{author}")
-	r.HandleFunc("/quotes/{id:[0-9]+}", quoteHandler.DeleteQuote).Methods("DELETE")
+	quoteHandler.RegisterRoutes(r)
+	authorHandler.RegisterRoutes(r)
 
 	log.Println("Starting server on :8080")
 	if err := http.ListenAndServe(":8080", r); err != nil {
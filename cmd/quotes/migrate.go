@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"quotes/internal/storage/migrate"
+	"quotes/internal/storage/quotes/sqlite"
+)
+
+// runMigrate handles "quotes migrate up|down|status".
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dsn := fs.String("dsn", "file:quotes.db", "SQLite database to migrate")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: quotes migrate [-dsn=...] up|down|status")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db, err := sqlite.Open(*dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, sqlite.Migrations())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch fs.Arg(0) {
+	case "up":
+		if err := migrator.Up(); err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		if err := migrator.Down(); err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
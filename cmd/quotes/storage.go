@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"quotes/internal/services"
+	authorfile "quotes/internal/storage/authors/file"
+	authormemory "quotes/internal/storage/authors/memory"
+	authorsqlite "quotes/internal/storage/authors/sqlite"
+	"quotes/internal/storage/filelock"
+	"quotes/internal/storage/migrate"
+	"quotes/internal/storage/quotes/file"
+	"quotes/internal/storage/quotes/memory"
+	"quotes/internal/storage/quotes/sqlite"
+)
+
+// storageConfig selects the storage shared by the server and the subcommands
+// working with quotes.
+type storageConfig struct {
+	typ     string
+	dataDir string
+	dsn     string
+	file    file.Options
+}
+
+// repositories are the repositories of an opened storage. The closer
+// releases them.
+type repositories struct {
+	quotes  services.QuoteRepository
+	authors services.AuthorRepository
+	closer  io.Closer
+}
+
+// open opens the quote and author repositories.
+func (c storageConfig) open() (repositories, error) {
+	switch c.typ {
+	case "memory":
+		return repositories{
+			quotes:  memory.NewQuoteStorage(),
+			authors: authormemory.NewAuthorStorage(),
+			closer:  nopCloser{},
+		}, nil
+	case "file":
+		quotes, err := file.NewQuoteStorage(c.dataDir, c.file)
+		if errors.Is(err, filelock.ErrLocked) {
+			return repositories{}, fmt.Errorf("%w; stop the server using %s or work with it over HTTP", err, c.dataDir)
+		}
+		if err != nil {
+			return repositories{}, err
+		}
+		authors, err := authorfile.NewAuthorStorage(c.dataDir)
+		if err != nil {
+			quotes.Close()
+			return repositories{}, err
+		}
+		return repositories{
+			quotes:  quotes,
+			authors: authors,
+			closer:  closers{quotes, authors},
+		}, nil
+	case "sqlite":
+		db, err := sqlite.Open(c.dsn)
+		if err != nil {
+			return repositories{}, err
+		}
+		migrator, err := migrate.New(db, sqlite.Migrations())
+		if err != nil {
+			db.Close()
+			return repositories{}, err
+		}
+		pending, err := migrator.Pending()
+		if err != nil {
+			db.Close()
+			return repositories{}, err
+		}
+		if pending > 0 {
+			db.Close()
+			return repositories{}, fmt.Errorf("database has %d pending migrations, run \"quotes migrate -dsn=%s up\"", pending, c.dsn)
+		}
+		return repositories{
+			quotes:  sqlite.NewQuoteStorage(db),
+			authors: authorsqlite.NewAuthorStorage(db),
+			closer:  db,
+		}, nil
+	default:
+		return repositories{}, fmt.Errorf("unknown storage type %q", c.typ)
+	}
+}
+
+// nopCloser is the closer of the memory storage, which holds no resources.
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// closers closes all of its closers, returning the first error.
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var first error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"quotes/internal/services"
+)
+
+// maxPurgeInterval bounds how long a quote may outstay the trash retention.
+const maxPurgeInterval = time.Hour
+
+// purgeTrash removes the quotes that have been in the trash for longer than
+// the retention, checking once in a while for as long as the server runs.
+func purgeTrash(service *services.QuoteService, retention time.Duration) {
+	ticker := time.NewTicker(min(retention, maxPurgeInterval))
+	defer ticker.Stop()
+
+	for {
+		purged, err := service.PurgeTrash(retention)
+		if err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d quotes from the trash", purged)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeAuthor returns the form author names are compared in: NFC, case
+// folded, with runs of whitespace collapsed to a single space and trimmed.
+func NormalizeAuthor(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	// A Caser keeps state, so it cannot be shared between goroutines.
+	return norm.NFC.String(cases.Fold().String(norm.NFC.String(name)))
+}
+
+// SameAuthor reports whether the names refer to the same author.
+func SameAuthor(a, b string) bool {
+	return a == b || NormalizeAuthor(a) == NormalizeAuthor(b)
+}
+
+// FuzzyAuthorMatch reports whether the normalized author name is within the
+// edit distance allowed for the normalized query: one typo for every four
+// letters, at least one and at most three.
+func FuzzyAuthorMatch(query, author string) bool {
+	limit := min(max(len([]rune(query))/4, 1), 3)
+	return editDistance(query, author, limit) <= limit
+}
+
+// SuggestAuthors picks up to limit author names that look like the query, the
+// closest first.
+func SuggestAuthors(query string, names []string, limit int) []string {
+	query = NormalizeAuthor(query)
+	if query == "" {
+		return nil
+	}
+	maxDistance := max(len([]rune(query))/3, 2)
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for _, name := range names {
+		key := NormalizeAuthor(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		distance := editDistance(query, key, maxDistance)
+		// A part of the name, like the surname alone, is a good suggestion too.
+		if distance > maxDistance && strings.Contains(key, query) {
+			distance = maxDistance
+		}
+		if distance <= maxDistance {
+			candidates = append(candidates, candidate{name: name, distance: distance})
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if c := cmp.Compare(a.distance, b.distance); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	suggestions := make([]string, 0, min(len(candidates), limit))
+	for _, c := range candidates[:min(len(candidates), limit)] {
+		suggestions = append(suggestions, c.name)
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between the strings in runes,
+// or limit+1 as soon as it is known to exceed limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		best := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			best = min(best, curr[j])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return min(prev[len(rb)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Author is a person quotes are attributed to. Quotes submitted under any of
+// the aliases are linked to the author.
+type Author struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Bio     string   `json:"bio,omitempty"`
+	// BirthYear and DeathYear are negative for years BC and zero if unknown.
+	BirthYear int       `json:"birth_year,omitempty"`
+	DeathYear int       `json:"death_year,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+// Clean collapses whitespace in the name and aliases and drops aliases that
+// duplicate the name or each other.
+func (a *Author) Clean() {
+	a.Name = strings.Join(strings.Fields(a.Name), " ")
+
+	seen := map[string]bool{NormalizeAuthor(a.Name): true}
+	aliases := make([]string, 0, len(a.Aliases))
+	for _, alias := range a.Aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		key := NormalizeAuthor(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+	a.Aliases = aliases
+}
+
+// Names returns the name followed by the aliases.
+func (a *Author) Names() []string {
+	return append([]string{a.Name}, a.Aliases...)
+}
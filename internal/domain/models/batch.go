@@ -0,0 +1,22 @@
+package models
+
+// BatchMode is how a batch of new quotes treats the invalid ones.
+type BatchMode string
+
+const (
+	// BatchAtomic creates the quotes only if every one of them is valid.
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort creates the valid quotes and skips the invalid ones.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+func (m BatchMode) Valid() bool {
+	return m == BatchAtomic || m == BatchBestEffort
+}
+
+// BatchResult is the outcome for one quote of a batch: the created quote or
+// the error that kept it from being created.
+type BatchResult struct {
+	Quote *Quote
+	Err   error
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NearDuplicateSimilarity is the share of a normalized text that must stay
+// unchanged in another one for them to be near duplicates.
+const NearDuplicateSimilarity = 0.9
+
+// minNearDuplicateLength is the length from which normalized texts can be
+// near duplicates. In shorter ones a single changed word makes another quote.
+const minNearDuplicateLength = 20
+
+// NormalizeText reduces the text of a quote to its words: case folded, in NFC,
+// with punctuation and symbols dropped. Texts that differ only in that
+// normalize to the same string.
+func NormalizeText(text string) string {
+	// A Caser keeps state, so it cannot be shared between goroutines.
+	folded := cases.Fold().String(norm.NFC.String(text))
+	words := strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+	return norm.NFC.String(strings.Join(words, " "))
+}
+
+// NearDuplicate reports whether normalized texts are near duplicates: their
+// edit distance is at most 1 - NearDuplicateSimilarity of the longer one's
+// length. Short texts are never near duplicates.
+func NearDuplicate(a, b string) bool {
+	la, lb := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	if min(la, lb) < minNearDuplicateLength {
+		return false
+	}
+	limit := int((1 - NearDuplicateSimilarity) * float64(max(la, lb)))
+	return editDistance(a, b, limit) <= limit
+}
+
+// FindDuplicate returns the quote among known whose text the quote repeats
+// exactly or nearly, preferring an exact duplicate to a near one, and whether
+// it is exact. The authors of the quotes are not compared.
+func FindDuplicate(quote Quote, known []Quote) (*Quote, bool) {
+	text := NormalizeText(quote.Text)
+	var near *Quote
+	for i := range known {
+		other := NormalizeText(known[i].Text)
+		if other == text {
+			return &known[i], true
+		}
+		if near == nil && NearDuplicate(other, text) {
+			near = &known[i]
+		}
+	}
+	return near, false
+}
+
+// Similarity returns 1 minus the edit distance between normalized texts
+// relative to the longer one's length: 1 for the same texts, 0 for completely
+// different ones.
+func Similarity(a, b string) float64 {
+	n := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b, n))/float64(n)
+}
+
+// DuplicateCluster is a group of quotes of one author whose texts repeat each
+// other exactly or nearly.
+type DuplicateCluster struct {
+	// Canonical is the oldest quote of the cluster, the one the others are
+	// merged into.
+	Canonical  Quote              `json:"canonical"`
+	Duplicates []DuplicateOfQuote `json:"duplicates"`
+}
+
+// DuplicateOfQuote is a quote of a cluster other than the canonical one.
+type DuplicateOfQuote struct {
+	Quote Quote `json:"quote"`
+	// Similarity is the Similarity of the quote's normalized text to the
+	// canonical one. A quote may join a cluster through another duplicate, so
+	// it can be below NearDuplicateSimilarity.
+	Similarity float64 `json:"similarity"`
+}
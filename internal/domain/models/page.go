@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// Page selects a part of a listing.
+type Page struct {
+	// Limit is the maximum number of quotes to return; zero means no limit.
+	Limit int
+	// Sort is the order of the listing, see OrderBy.
+	Sort []SortKey
+	// After continues a listing right after the position of the cursor.
+	After *Cursor
+}
+
+// Cursor is the position of a quote in a listing: the values of every
+// property the listing can be sorted by.
+type Cursor struct {
+	ID         int64     `json:"id"`
+	Author     string    `json:"author,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitzero"`
+	TextLength int       `json:"text_length,omitempty"`
+}
+
+// CursorOf returns the position of the quote.
+func CursorOf(quote Quote) Cursor {
+	return Cursor{
+		ID:         quote.ID,
+		Author:     quote.Author,
+		CreatedAt:  quote.CreatedAt,
+		TextLength: utf8.RuneCountInString(quote.Text),
+	}
+}
+
+// QuotePage is a part of a listing.
+type QuotePage struct {
+	Quotes []Quote
+	// Next is the cursor of the following page, nil if this page is the last one.
+	Next *Cursor
+	// Suggestions are author names similar to the author filter, offered
+	// when nothing matched it.
+	Suggestions []string
+}
+
+// NewQuotePage makes a page of the quotes, which may hold one quote more than
+// the limit to tell that there is a next page.
+func NewQuotePage(quotes []Quote, limit int) QuotePage {
+	if limit <= 0 || len(quotes) <= limit {
+		return QuotePage{Quotes: quotes}
+	}
+	quotes = quotes[:limit]
+	next := CursorOf(quotes[limit-1])
+	return QuotePage{Quotes: quotes, Next: &next}
+}
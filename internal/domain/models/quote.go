@@ -7,4 +7,32 @@ type Quote struct {
 	Author    string    `json:"author"`
 	Text      string    `json:"quote"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+	// Version starts at 1 and is incremented by every update.
+	Version int64 `json:"version"`
+	// AuthorID links the quote to its canonical author, whose name is then
+	// kept in Author.
+	AuthorID int64 `json:"author_id,omitempty"`
+	// Tags are normalized by NormalizeTags when the quote is stored.
+	Tags []string `json:"tags,omitempty"`
+	// Source is nil for unsourced quotes.
+	Source       *Source      `json:"source,omitempty"`
+	Verification Verification `json:"verification,omitempty"`
+	// Rating is from 1 to MaxRating, or zero if the quote is not rated.
+	Rating int `json:"rating,omitempty"`
+	// DeletedAt is set while the quote is in the trash.
+	DeletedAt time.Time `json:"deleted_at,omitzero"`
+}
+
+// ScoredQuote is a search result with its relevance score, higher is better.
+type ScoredQuote struct {
+	Quote
+	Score float64 `json:"score"`
+}
+
+// CreatedQuote tells when a quote was created. It is kept after the quote is
+// deleted or merged into another quote.
+type CreatedQuote struct {
+	ID        int64
+	CreatedAt time.Time
 }
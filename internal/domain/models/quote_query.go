@@ -0,0 +1,89 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// QuoteQuery is a set of filters for listing quotes. A quote has to pass all of
+// them; zero-valued filters are ignored.
+type QuoteQuery struct {
+	// Author matches the author name ignoring case, whitespace and Unicode
+	// normalization differences, see NormalizeAuthor.
+	Author string
+	// AuthorFuzzy also matches author names with a few typos.
+	AuthorFuzzy bool
+	// AuthorID matches quotes linked to the author.
+	AuthorID int64
+	// TextContains matches quotes whose text contains the substring, ignoring case.
+	TextContains string
+	// CreatedAfter matches quotes created at or after the time.
+	CreatedAfter time.Time
+	// CreatedBefore matches quotes created strictly before the time.
+	CreatedBefore time.Time
+	// Tags matches quotes that have every one of the tags, or at least one of
+	// them if AnyTag is set. Tags are compared as normalized by NormalizeTag.
+	Tags   []string
+	AnyTag bool
+	// MaxLength matches quotes whose text is at most this many characters long.
+	MaxLength int
+	// Verification matches quotes with the verification status. Quotes with
+	// no status are unverified.
+	Verification Verification
+}
+
+// IsZero reports whether the query has no filters.
+func (q QuoteQuery) IsZero() bool {
+	return q.Author == "" && q.AuthorID == 0 && q.TextContains == "" &&
+		q.CreatedAfter.IsZero() && q.CreatedBefore.IsZero() &&
+		len(q.Tags) == 0 && q.MaxLength == 0 && q.Verification == ""
+}
+
+func (q QuoteQuery) Match(quote Quote) bool {
+	if q.Author != "" {
+		if q.AuthorFuzzy {
+			if !FuzzyAuthorMatch(NormalizeAuthor(q.Author), NormalizeAuthor(quote.Author)) {
+				return false
+			}
+		} else if !SameAuthor(q.Author, quote.Author) {
+			return false
+		}
+	}
+	if q.AuthorID != 0 && quote.AuthorID != q.AuthorID {
+		return false
+	}
+	if q.TextContains != "" && !strings.Contains(strings.ToLower(quote.Text), strings.ToLower(q.TextContains)) {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && quote.CreatedAt.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !quote.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	if len(q.Tags) > 0 && !q.matchTags(quote.Tags) {
+		return false
+	}
+	if q.MaxLength > 0 && utf8.RuneCountInString(quote.Text) > q.MaxLength {
+		return false
+	}
+	if q.Verification != "" && quote.Verification.Or(VerificationUnverified) != q.Verification {
+		return false
+	}
+	return true
+}
+
+func (q QuoteQuery) matchTags(tags []string) bool {
+	for _, tag := range q.Tags {
+		has := slices.Contains(tags, NormalizeTag(tag))
+		if has && q.AnyTag {
+			return true
+		}
+		if !has && !q.AnyTag {
+			return false
+		}
+	}
+	return !q.AnyTag
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// RandomWeight is how a random pick favors some quotes over others.
+type RandomWeight string
+
+const (
+	// WeightUniform gives every quote the same chance.
+	WeightUniform RandomWeight = ""
+	// WeightRating makes a quote rated r as likely as r+1 unrated quotes.
+	WeightRating RandomWeight = "rating"
+	// WeightRecency halves the chance of a quote every RecencyHalfLife of its age.
+	WeightRecency RandomWeight = "recency"
+)
+
+// RecencyHalfLife is the age at which WeightRecency picks a quote half as
+// often as a brand new one.
+const RecencyHalfLife = 30 * 24 * time.Hour
+
+// MaxRating is the highest rating of a quote. Zero means unrated.
+const MaxRating = 5
+
+func (w RandomWeight) Valid() bool {
+	switch w {
+	case WeightUniform, WeightRating, WeightRecency:
+		return true
+	}
+	return false
+}
+
+// LogWeight returns the natural logarithm of the weight of the quote, up to a
+// constant shared by all quotes. Logarithms keep the weights of old quotes
+// from underflowing to zero.
+func (w RandomWeight) LogWeight(quote Quote) float64 {
+	switch w {
+	case WeightRating:
+		return math.Log(float64(quote.Rating + 1))
+	case WeightRecency:
+		return float64(quote.CreatedAt.UnixNano()) / float64(RecencyHalfLife) * math.Ln2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// RevisionAction is the kind of change a revision records.
+type RevisionAction string
+
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionDelete  RevisionAction = "delete"
+	RevisionRestore RevisionAction = "restore"
+	// RevisionMerge records a quote removed by merging it into another one.
+	RevisionMerge RevisionAction = "merge"
+	// RevisionRevert records an update back to the content of an earlier
+	// revision.
+	RevisionRevert RevisionAction = "revert"
+	// RevisionPurge records a deleted quote removed from the trash for good.
+	RevisionPurge RevisionAction = "purge"
+)
+
+// Revision is what a quote looked like after a change. The revisions of a
+// quote are numbered from 1 in the order of the changes and never change.
+type Revision struct {
+	QuoteID int64          `json:"quote_id"`
+	Number  int64          `json:"revision"`
+	Action  RevisionAction `json:"action"`
+	// ChangedBy names who made the change, if known.
+	ChangedBy string    `json:"changed_by,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+	Quote     Quote     `json:"quote"`
+	// RevertedTo is the number of the revision a revert went back to.
+	RevertedTo int64 `json:"reverted_to,omitempty"`
+	// MergedInto is the ID of the quote a merged quote was merged into.
+	MergedInto int64 `json:"merged_into,omitempty"`
+}
+
+// DiffOp tells what happened to a run of words between two texts.
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffChunk is a run of words, separated by single spaces, that was kept,
+// inserted or deleted.
+type DiffChunk struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff shows how a quote changed from one revision to another.
+type RevisionDiff struct {
+	QuoteID     int64       `json:"quote_id"`
+	From        int64       `json:"from"`
+	To          int64       `json:"to"`
+	Author      []DiffChunk `json:"author"`
+	Text        []DiffChunk `json:"quote"`
+	TagsAdded   []string    `json:"tags_added,omitempty"`
+	TagsRemoved []string    `json:"tags_removed,omitempty"`
+}
+
+// maxDiffCells bounds the memory taken by DiffWords, about 256 KB: the
+// product of the numbers of words the texts differ in, plus one each.
+const maxDiffCells = 1 << 15
+
+// DiffRevisions compares the author, text and tags of two revisions. It
+// returns false if the authors or texts differ in too many words to compare.
+func DiffRevisions(from, to Revision) (RevisionDiff, bool) {
+	author, ok := DiffWords(from.Quote.Author, to.Quote.Author)
+	if !ok {
+		return RevisionDiff{}, false
+	}
+	text, ok := DiffWords(from.Quote.Text, to.Quote.Text)
+	if !ok {
+		return RevisionDiff{}, false
+	}
+	return RevisionDiff{
+		QuoteID:     to.QuoteID,
+		From:        from.Number,
+		To:          to.Number,
+		Author:      author,
+		Text:        text,
+		TagsAdded:   missingTags(to.Quote.Tags, from.Quote.Tags),
+		TagsRemoved: missingTags(from.Quote.Tags, to.Quote.Tags),
+	}, true
+}
+
+// missingTags returns the tags that are not among others.
+func missingTags(tags, others []string) []string {
+	var missing []string
+	for _, tag := range tags {
+		if !slices.Contains(others, tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// DiffWords returns the word-level difference between two texts as the
+// shortest sequence of kept, deleted and inserted runs of words. Deletions
+// come before insertions where both are possible. It returns false if the
+// texts differ in too many words to compare.
+func DiffWords(from, to string) ([]DiffChunk, bool) {
+	a, b := strings.Fields(from), strings.Fields(to)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(middleA)+1)*(len(middleB)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	var diff wordDiff
+	diff.add(DiffEqual, a[:prefix]...)
+	diff.middle(middleA, middleB)
+	diff.add(DiffEqual, a[len(a)-suffix:]...)
+	return diff.chunks(), true
+}
+
+type wordDiff struct {
+	ops   []DiffOp
+	words []string
+}
+
+func (d *wordDiff) add(op DiffOp, words ...string) {
+	for _, word := range words {
+		d.ops = append(d.ops, op)
+		d.words = append(d.words, word)
+	}
+}
+
+// middle diffs the words between the common prefix and suffix through their
+// longest common subsequence.
+func (d *wordDiff) middle(a, b []string) {
+	if len(a) == 0 || len(b) == 0 {
+		d.add(DiffDelete, a...)
+		d.add(DiffInsert, b...)
+		return
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			d.add(DiffEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			d.add(DiffDelete, a[i])
+			i++
+		default:
+			d.add(DiffInsert, b[j])
+			j++
+		}
+	}
+	d.add(DiffDelete, a[i:]...)
+	d.add(DiffInsert, b[j:]...)
+}
+
+// chunks joins the runs of words with the same operation.
+func (d *wordDiff) chunks() []DiffChunk {
+	chunks := make([]DiffChunk, 0)
+	for i, op := range d.ops {
+		if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+			chunks[n-1].Text += " " + d.words[i]
+			continue
+		}
+		chunks = append(chunks, DiffChunk{Op: op, Text: d.words[i]})
+	}
+	return chunks
+}
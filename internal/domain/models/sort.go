@@ -0,0 +1,64 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// SortField is a quote property listings can be ordered by.
+type SortField string
+
+const (
+	SortByID         SortField = "id"
+	SortByAuthor     SortField = "author"
+	SortByCreatedAt  SortField = "created_at"
+	SortByTextLength SortField = "text_length"
+)
+
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByID, SortByAuthor, SortByCreatedAt, SortByTextLength:
+		return true
+	}
+	return false
+}
+
+type SortKey struct {
+	Field SortField
+	Desc  bool
+}
+
+// OrderBy completes the sort keys with the ascending ID unless the ID is
+// already among them, so that the order is total and stable. Without keys
+// quotes are ordered by ID.
+func OrderBy(keys []SortKey) []SortKey {
+	if slices.ContainsFunc(keys, func(k SortKey) bool { return k.Field == SortByID }) {
+		return keys
+	}
+	return append(slices.Clip(keys), SortKey{Field: SortByID})
+}
+
+// Compare orders two positions by the keys.
+func Compare(a, b Cursor, keys []SortKey) int {
+	for _, key := range keys {
+		var c int
+		switch key.Field {
+		case SortByID:
+			c = cmp.Compare(a.ID, b.ID)
+		case SortByAuthor:
+			c = strings.Compare(a.Author, b.Author)
+		case SortByCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case SortByTextLength:
+			c = cmp.Compare(a.TextLength, b.TextLength)
+		}
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package models
+
+// SourceKind is the kind of work a quote comes from.
+type SourceKind string
+
+const (
+	SourceBook      SourceKind = "book"
+	SourceArticle   SourceKind = "article"
+	SourceSpeech    SourceKind = "speech"
+	SourceInterview SourceKind = "interview"
+	SourceLetter    SourceKind = "letter"
+	SourceFilm      SourceKind = "film"
+	SourceWeb       SourceKind = "web"
+	SourceOther     SourceKind = "other"
+)
+
+func (k SourceKind) Valid() bool {
+	switch k {
+	case SourceBook, SourceArticle, SourceSpeech, SourceInterview, SourceLetter, SourceFilm, SourceWeb, SourceOther:
+		return true
+	}
+	return false
+}
+
+// Source tells where a quote was said or written.
+type Source struct {
+	Kind  SourceKind `json:"kind,omitempty"`
+	Title string     `json:"title,omitempty"`
+	// Page is free-form, e.g. "12" or "xiv-xv".
+	Page string `json:"page,omitempty"`
+	// Year is negative for years BC and zero if unknown.
+	Year int    `json:"year,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Verification tells whether the attribution of a quote has been checked.
+type Verification string
+
+const (
+	VerificationUnverified Verification = "unverified"
+	// VerificationVerified quotes have been checked against their source.
+	VerificationVerified Verification = "verified"
+	// VerificationDisputed quotes have a source whose attribution is doubted.
+	VerificationDisputed Verification = "disputed"
+	// VerificationApocryphal quotes are known to be misattributed or made up.
+	VerificationApocryphal Verification = "apocryphal"
+)
+
+func (v Verification) Valid() bool {
+	switch v {
+	case VerificationUnverified, VerificationVerified, VerificationDisputed, VerificationApocryphal:
+		return true
+	}
+	return false
+}
+
+// Or returns v, or def if v is empty.
+func (v Verification) Or(def Verification) Verification {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// TagCount is a tag with the number of quotes that have it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTag returns the form tags are stored in: NFC, lower case, with runs
+// of whitespace collapsed to a single space and trimmed.
+func NormalizeTag(tag string) string {
+	return norm.NFC.String(strings.ToLower(strings.Join(strings.Fields(tag), " ")))
+}
+
+// NormalizeTags normalizes the tags, drops empty ones and duplicates and sorts
+// the rest. It returns nil if no tags are left.
+func NormalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
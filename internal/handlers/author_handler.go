@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+type AuthorService interface {
+	CreateAuthor(author *models.Author) error
+	GetAllAuthors() ([]models.Author, error)
+	GetAuthorByID(id int64) (*models.Author, error)
+	UpdateAuthor(author *models.Author, changedBy string) error
+	DeleteAuthor(id int64) error
+	GetAuthorQuotes(id int64, page models.Page) (models.QuotePage, error)
+}
+
+type AuthorHandler struct {
+	service AuthorService
+}
+
+func NewAuthorHandler(service AuthorService) *AuthorHandler {
+	return &AuthorHandler{
+		service: service,
+	}
+}
+
+func (h *AuthorHandler) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.author.CreateAuthor"
+
+	var author models.Author
+	if err := json.NewDecoder(r.Body).Decode(&author); err != nil {
+		log.Printf("%s: failed to decode request body: %v", op, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CreateAuthor(&author); err != nil {
+		log.Printf("%s: failed to create author: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrEmptyAuthorName):
+			http.Error(w, "Author name cannot be empty", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrAuthorExists):
+			http.Error(w, "Author with this name already exists", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to create author", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(author); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuthorHandler) GetAllAuthors(w http.ResponseWriter, _ *http.Request) {
+	const op = "handlers.author.GetAllAuthors"
+
+	authors, err := h.service.GetAllAuthors()
+	if err != nil {
+		log.Printf("%s: failed to get authors: %v", op, err)
+		http.Error(w, "Failed to get authors", http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(authors); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuthorHandler) GetAuthorByID(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.author.GetAuthorByID"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid author ID: %v", op, err)
+		http.Error(w, "Invalid author ID", http.StatusBadRequest)
+		return
+	}
+
+	author, err := h.service.GetAuthorByID(id)
+	if err != nil {
+		log.Printf("%s: failed to get author: %v", op, err)
+		writeAuthorLookupError(w, err, "Failed to get author")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(author); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuthorHandler) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.author.UpdateAuthor"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid author ID: %v", op, err)
+		http.Error(w, "Invalid author ID", http.StatusBadRequest)
+		return
+	}
+
+	var author models.Author
+	if err := json.NewDecoder(r.Body).Decode(&author); err != nil {
+		log.Printf("%s: failed to decode request body: %v", op, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	author.ID = id
+
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateAuthor(&author, changedBy); err != nil {
+		log.Printf("%s: failed to update author: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrVersionMismatch):
+			http.Error(w, "Quotes of the author have been modified, try again", http.StatusConflict)
+		case errors.Is(err, storage.ErrEmptyAuthorName):
+			http.Error(w, "Author name cannot be empty", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrAuthorExists):
+			http.Error(w, "Author with this name already exists", http.StatusConflict)
+		default:
+			writeAuthorLookupError(w, err, "Failed to update author")
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(author); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuthorHandler) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.author.DeleteAuthor"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid author ID: %v", op, err)
+		http.Error(w, "Invalid author ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteAuthor(id); err != nil {
+		log.Printf("%s: failed to delete author: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrAuthorHasQuotes):
+			http.Error(w, "Author has quotes", http.StatusConflict)
+		default:
+			writeAuthorLookupError(w, err, "Failed to delete author")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthorHandler) GetAuthorQuotes(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.author.GetAuthorQuotes"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid author ID: %v", op, err)
+		http.Error(w, "Invalid author ID", http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		log.Printf("%s: invalid page: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.GetAuthorQuotes(id, page)
+	if err != nil {
+		log.Printf("%s: failed to get author quotes: %v", op, err)
+		writeAuthorLookupError(w, err, "Failed to get quotes")
+		return
+	}
+
+	list := quoteList{Quotes: result.Quotes}
+	if result.Next != nil {
+		list.NextCursor = encodeCursor(result.Next, r.URL.Query().Get("sort"))
+		setNextLink(w, r, list.NextCursor)
+	}
+	writeCached(w, r, op, list, "")
+}
+
+// writeAuthorLookupError responds to an error of an operation on the author
+// with the given ID.
+func writeAuthorLookupError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrAuthorNotFound):
+		http.Error(w, "Author not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrInvalidID):
+		http.Error(w, "Invalid author ID", http.StatusBadRequest)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
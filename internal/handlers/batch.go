@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+)
+
+const ndjsonMediaType = "application/x-ndjson"
+
+// maxBatchSize is the maximum number of quotes in a batch.
+const maxBatchSize = 10000
+
+// batchErrors are the errors a quote of a batch may fail with; any other error
+// is reported as an internal one.
+var batchErrors = []error{
+	storage.ErrEmptyAuthor,
+	storage.ErrEmptyText,
+	storage.ErrAuthorNotFound,
+	storage.ErrInvalidRating,
+	storage.ErrInvalidSource,
+	storage.ErrInvalidStatus,
+	storage.ErrSourceRequired,
+	storage.ErrDuplicateQuote,
+	storage.ErrBatchRejected,
+}
+
+type batchResult struct {
+	Index int    `json:"index"`
+	ID    int64  `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	// ExistingID is the quote a duplicate repeats.
+	ExistingID int64 `json:"existing_id,omitempty"`
+}
+
+type batchReport struct {
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Results []batchResult `json:"results"`
+}
+
+// decodeBatch reads the quotes of a batch: a JSON array or, for NDJSON, one
+// quote per non-empty line.
+func decodeBatch(body io.Reader, ndjson bool) ([]models.Quote, error) {
+	if !ndjson {
+		return decodeBatchArray(body)
+	}
+
+	quotes := make([]models.Quote, 0)
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if len(quotes) == maxBatchSize {
+			return nil, fmt.Errorf("batch has more than %d quotes", maxBatchSize)
+		}
+		var quote models.Quote
+		if err := json.Unmarshal(scanner.Bytes(), &quote); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		quotes = append(quotes, quote)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// decodeBatchArray reads the quotes of a JSON array one by one, so that a
+// batch over maxBatchSize is rejected before it is read to the end.
+func decodeBatchArray(body io.Reader) ([]models.Quote, error) {
+	dec := json.NewDecoder(body)
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("batch must be a JSON array")
+	}
+
+	quotes := make([]models.Quote, 0)
+	for dec.More() {
+		if len(quotes) == maxBatchSize {
+			return nil, fmt.Errorf("batch has more than %d quotes", maxBatchSize)
+		}
+		var quote models.Quote
+		if err := dec.Decode(&quote); err != nil {
+			return nil, fmt.Errorf("quote %d: %w", len(quotes), err)
+		}
+		quotes = append(quotes, quote)
+	}
+	// The closing bracket.
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// newBatchReport reports the outcome of every quote of a batch in order.
+func newBatchReport(results []models.BatchResult) batchReport {
+	report := batchReport{Results: make([]batchResult, len(results))}
+	for i, result := range results {
+		report.Results[i].Index = i
+		if result.Err == nil {
+			report.Results[i].ID = result.Quote.ID
+			report.Created++
+			continue
+		}
+		report.Failed++
+		var dup *storage.DuplicateError
+		if errors.As(result.Err, &dup) {
+			report.Results[i].ExistingID = dup.ID
+		}
+		report.Results[i].Error = "failed to create quote"
+		for _, err := range batchErrors {
+			if errors.Is(result.Err, err) {
+				report.Results[i].Error = err.Error()
+				break
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+)
+
+type duplicateResponse struct {
+	Error      string `json:"error"`
+	ExistingID int64  `json:"existing_id"`
+	Exact      bool   `json:"exact"`
+}
+
+// parseAllowDuplicate reads whether quotes repeating existing ones may be
+// created.
+func parseAllowDuplicate(values url.Values) (bool, error) {
+	value := values.Get("allow_duplicate")
+	if value == "" {
+		return false, nil
+	}
+	allow, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid allow_duplicate: expected true or false, got %q", value)
+	}
+	return allow, nil
+}
+
+// writeDuplicate answers with 409 Conflict pointing to the existing quote.
+func writeDuplicate(w http.ResponseWriter, dup *storage.DuplicateError) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/quotes/%d", dup.ID))
+	w.WriteHeader(http.StatusConflict)
+	return json.NewEncoder(w).Encode(duplicateResponse{
+		Error:      "Quote already exists",
+		ExistingID: dup.ID,
+		Exact:      dup.Exact,
+	})
+}
+
+type duplicateReport struct {
+	// Duplicates is the number of quotes to be merged into or merged into the
+	// canonical quotes of the clusters.
+	Duplicates int                       `json:"duplicates"`
+	Clusters   []models.DuplicateCluster `json:"clusters"`
+}
+
+func newDuplicateReport(clusters []models.DuplicateCluster) duplicateReport {
+	report := duplicateReport{Clusters: clusters}
+	for _, cluster := range clusters {
+		report.Duplicates += len(cluster.Duplicates)
+	}
+	return report
+}
+
+// FindDuplicates reports the clusters of quotes repeating each other.
+func (h *QuoteHandler) FindDuplicates(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.FindDuplicates"
+
+	clusters, err := h.service.FindDuplicates()
+	if err != nil {
+		log.Printf("%s: failed to find duplicates: %v", op, err)
+		http.Error(w, "Failed to find duplicates", http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(newDuplicateReport(clusters)); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// MergeDuplicates merges each cluster of quotes repeating each other into its
+// canonical quote and reports the merged clusters.
+func (h *QuoteHandler) MergeDuplicates(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.MergeDuplicates"
+
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clusters, err := h.service.MergeDuplicates(changedBy)
+	if err != nil {
+		log.Printf("%s: failed to merge duplicates after %d clusters: %v", op, len(clusters), err)
+		switch {
+		case errors.Is(err, storage.ErrVersionMismatch), errors.Is(err, storage.ErrQuoteNotFound):
+			http.Error(w, "Quotes have been modified while merging, try again", http.StatusConflict)
+		default:
+			http.Error(w, "Failed to merge duplicates", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(newDuplicateReport(clusters)); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// redirectMerged redirects to the quote a missing quote was merged into or
+// reports that the quote is not found.
+func (h *QuoteHandler) redirectMerged(w http.ResponseWriter, r *http.Request, op string, id int64) {
+	to, err := h.service.MergedInto(id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrQuoteNotFound) {
+			log.Printf("%s: failed to look up merged quote: %v", op, err)
+		}
+		http.Error(w, "Quote not found", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/quotes/%d", to), http.StatusMovedPermanently)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"quotes/internal/domain/models"
+)
+
+var (
+	errPreconditionRequired = errors.New("If-Match header is required")
+	errPreconditionFailed   = errors.New("If-Match does not match the quote")
+)
+
+// quoteETag is the strong entity tag of a single quote, derived from its version.
+func quoteETag(quote *models.Quote) string {
+	return `"` + strconv.FormatInt(quote.Version, 10) + `"`
+}
+
+// ifMatchVersion returns the quote version required by the If-Match header.
+// Zero means that any existing version matches ("*").
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errPreconditionRequired
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	// Weak tags never match with the strong comparison If-Match requires, and
+	// a list of tags cannot be checked atomically against a single version.
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return 0, errPreconditionFailed
+	}
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errPreconditionFailed
+	}
+	return version, nil
+}
+
+// noneMatch reports whether the If-None-Match header contains the entity tag,
+// using weak comparison.
+func noneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCached encodes v as the response with an ETag, replying 304 Not Modified
+// if the client already has it. An empty etag is derived from the encoded body.
+func writeCached(w http.ResponseWriter, r *http.Request, op string, v any, etag string) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	if etag == "" {
+		sum := sha256.Sum256(body.Bytes())
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	w.Header().Set("ETag", etag)
+
+	if noneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if _, err := w.Write(body.Bytes()); err != nil {
+		log.Printf("%s: failed to write response: %v", op, err)
+	}
+}
+
+func writePreconditionError(w http.ResponseWriter, op string, err error) {
+	log.Printf("%s: %v", op, err)
+	if errors.Is(err, errPreconditionRequired) {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+	http.Error(w, "Quote has been modified", http.StatusPreconditionFailed)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"quotes/internal/domain/models"
+)
+
+// exportFormat describes how quotes are exported in one of the formats of
+// GET /quotes/export.
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer) exportWriter
+}
+
+var exportFormats = map[string]exportFormat{
+	"ndjson": {ndjsonMediaType, "ndjson", newNDJSONExport},
+	"json":   {"application/json", "json", newJSONExport},
+	"csv":    {"text/csv; charset=utf-8", "csv", newCSVExport},
+}
+
+// exportWriter writes exported quotes one by one. Nothing is written before
+// the first quote or Close, so an export that fails right away can still be
+// answered with an error.
+type exportWriter interface {
+	Write(quote models.Quote) error
+	// Close writes what completes the export and flushes it.
+	Close() error
+}
+
+type ndjsonExport struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONExport(w io.Writer) exportWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonExport{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (e *ndjsonExport) Write(quote models.Quote) error {
+	return e.enc.Encode(quote)
+}
+
+func (e *ndjsonExport) Close() error {
+	return e.buf.Flush()
+}
+
+// jsonExport writes a single JSON array.
+type jsonExport struct {
+	buf   *bufio.Writer
+	count int
+}
+
+func newJSONExport(w io.Writer) exportWriter {
+	return &jsonExport{buf: bufio.NewWriter(w)}
+}
+
+func (e *jsonExport) Write(quote models.Quote) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "[\n"
+	}
+	e.count++
+	e.buf.WriteString(sep)
+	_, err = e.buf.Write(data)
+	return err
+}
+
+func (e *jsonExport) Close() error {
+	if e.count == 0 {
+		e.buf.WriteString("[")
+	}
+	e.buf.WriteString("\n]\n")
+	return e.buf.Flush()
+}
+
+var csvHeader = []string{
+	"id", "author", "author_id", "quote", "tags", "verification", "rating",
+	"source_kind", "source_title", "source_page", "source_year", "source_url",
+	"created_at", "updated_at", "version",
+}
+
+// csvExport writes a header row and a row per quote. Tags are joined by
+// semicolons; encoding/csv quotes the fields with commas, quotes and newlines.
+type csvExport struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVExport(w io.Writer) exportWriter {
+	return &csvExport{w: csv.NewWriter(w)}
+}
+
+func (e *csvExport) Write(quote models.Quote) error {
+	if !e.header {
+		e.header = true
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	var source models.Source
+	if quote.Source != nil {
+		source = *quote.Source
+	}
+	return e.w.Write([]string{
+		strconv.FormatInt(quote.ID, 10),
+		quote.Author,
+		formatOptionalInt(quote.AuthorID),
+		quote.Text,
+		strings.Join(quote.Tags, ";"),
+		string(quote.Verification),
+		formatOptionalInt(int64(quote.Rating)),
+		string(source.Kind),
+		source.Title,
+		source.Page,
+		formatOptionalInt(int64(source.Year)),
+		source.URL,
+		quote.CreatedAt.Format(time.RFC3339Nano),
+		formatOptionalTime(quote.UpdatedAt),
+		strconv.FormatInt(quote.Version, 10),
+	})
+}
+
+func (e *csvExport) Close() error {
+	if !e.header {
+		e.header = true
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatOptionalInt(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"quotes/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// changedByHeader names who makes a change, to be recorded in the
+	// history of the quote.
+	changedByHeader    = "X-User"
+	maxChangedByLength = 128
+)
+
+// parseChangedBy returns who makes the change the request asks for, or an
+// empty string if the request does not tell.
+func parseChangedBy(r *http.Request) (string, error) {
+	changedBy := strings.TrimSpace(r.Header.Get(changedByHeader))
+	if len(changedBy) > maxChangedByLength {
+		return "", fmt.Errorf("%s header must be at most %d bytes", changedByHeader, maxChangedByLength)
+	}
+	return changedBy, nil
+}
+
+// parseRevision parses the revision number in the path or a query parameter.
+// An empty value is zero.
+func parseRevision(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid revision %q", value)
+	}
+	return number, nil
+}
+
+func (h *QuoteHandler) GetQuoteHistory(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.GetQuoteHistory"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.service.QuoteHistory(id)
+	if err != nil {
+		log.Printf("%s: failed to get history: %v", op, err)
+		writeHistoryError(w, err, "Failed to get history")
+		return
+	}
+
+	writeCached(w, r, op, revisions, "")
+}
+
+func (h *QuoteHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.GetRevision"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+	number, err := parseRevision(vars["rev"])
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := h.service.GetRevision(id, number)
+	if err != nil {
+		log.Printf("%s: failed to get revision: %v", op, err)
+		writeHistoryError(w, err, "Failed to get revision")
+		return
+	}
+
+	writeCached(w, r, op, revision, "")
+}
+
+func (h *QuoteHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.DiffRevisions"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+	from, err := parseRevision(r.URL.Query().Get("from"))
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, "Invalid from revision", http.StatusBadRequest)
+		return
+	}
+	to, err := parseRevision(r.URL.Query().Get("to"))
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, "Invalid to revision", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.service.DiffRevisions(id, from, to)
+	if err != nil {
+		log.Printf("%s: failed to diff revisions: %v", op, err)
+		writeHistoryError(w, err, "Failed to diff revisions")
+		return
+	}
+
+	writeCached(w, r, op, diff, "")
+}
+
+func (h *QuoteHandler) RevertQuote(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.RevertQuote"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+	number, err := parseRevision(vars["rev"])
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writePreconditionError(w, op, err)
+		return
+	}
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.service.RevertQuote(id, number, version, changedBy)
+	if err != nil {
+		log.Printf("%s: failed to revert quote: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrVersionMismatch):
+			http.Error(w, "Quote has been modified", http.StatusPreconditionFailed)
+		default:
+			writeHistoryError(w, err, "Failed to revert quote")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", quoteETag(quote))
+	if err := json.NewEncoder(w).Encode(quote); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeHistoryError reports an error of looking up the revisions of a quote.
+func writeHistoryError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrQuoteNotFound):
+		http.Error(w, "Quote not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrRevisionNotFound):
+		http.Error(w, "Revision not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrInvalidID):
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+	case errors.Is(err, storage.ErrDiffTooLarge):
+		http.Error(w, "Revisions differ in too many words to compare", http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"quotes/internal/domain/models"
+)
+
+const mergePatchMediaType = "application/merge-patch+json"
+
+// mergePatch applies a JSON Merge Patch (RFC 7396) to a decoded JSON document.
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// applyMergePatch returns a copy of the quote with the patch applied to its
+// JSON representation.
+func applyMergePatch(quote *models.Quote, patch map[string]any) (*models.Quote, error) {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	data, err = json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return nil, err
+	}
+	var patched models.Quote
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return nil, err
+	}
+	return &patched, nil
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"quotes/internal/domain/models"
+)
+
+const (
+	defaultPageLimit   = 100
+	defaultSearchLimit = 20
+	maxPageLimit       = 1000
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorToken is the content of an opaque cursor. It remembers the order of
+// the listing, since a position is meaningless in any other order.
+type cursorToken struct {
+	Sort string `json:"sort,omitempty"`
+	models.Cursor
+}
+
+// quoteList is the response envelope of quote listings.
+type quoteList struct {
+	Quotes      []models.Quote `json:"quotes"`
+	NextCursor  string         `json:"next_cursor,omitempty"`
+	Suggestions []string       `json:"suggestions,omitempty"`
+}
+
+// parsePage reads the limit, sort and cursor parameters of a listing.
+func parsePage(values url.Values) (models.Page, error) {
+	page := models.Page{Limit: defaultPageLimit}
+
+	sort := values.Get("sort")
+	keys, err := parseSort(sort)
+	if err != nil {
+		return page, err
+	}
+	page.Sort = keys
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, fmt.Errorf("invalid limit: expected a number from 1 to %d, got %q", maxPageLimit, value)
+		}
+		page.Limit = limit
+	}
+
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := decodeCursor(value, sort)
+		if err != nil {
+			return page, err
+		}
+		page.After = cursor
+	}
+	return page, nil
+}
+
+// parseSort reads a comma-separated list of sort fields, each optionally
+// prefixed with "-" for descending order, e.g. "created_at,-author".
+func parseSort(value string) ([]models.SortKey, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var keys []models.SortKey
+	for _, field := range strings.Split(value, ",") {
+		key := models.SortKey{Field: models.SortField(strings.TrimPrefix(field, "-"))}
+		key.Desc = strings.HasPrefix(field, "-")
+		if !key.Field.Valid() {
+			return nil, fmt.Errorf("invalid sort: unknown field %q", key.Field)
+		}
+		if slices.ContainsFunc(keys, func(k models.SortKey) bool { return k.Field == key.Field }) {
+			return nil, fmt.Errorf("invalid sort: field %q is repeated", key.Field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// encodeCursor turns the cursor into an opaque URL-safe token.
+func encodeCursor(cursor *models.Cursor, sort string) string {
+	data, _ := json.Marshal(cursorToken{Sort: sort, Cursor: *cursor})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, sort string) (*models.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var token cursorToken
+	if err := json.Unmarshal(data, &token); err != nil || token.ID < 0 || token.Sort != sort {
+		return nil, errInvalidCursor
+	}
+	return &token.Cursor, nil
+}
+
+// setNextLink adds an RFC 8288 Link header pointing to the following page,
+// keeping the other parameters of the request.
+func setNextLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	values := r.URL.Query()
+	values.Set("cursor", cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+	w.Header().Set("Link", "<"+next.String()+`>; rel="next"`)
+}
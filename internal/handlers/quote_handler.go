@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"quotes/internal/domain/models"
 	"quotes/internal/storage"
@@ -14,11 +16,27 @@ import (
 )
 
 type QuoteService interface {
-	CreateQuote(quote *models.Quote) error
-	GetAllQuotes() ([]models.Quote, error)
-	GetRandomQuote() (*models.Quote, error)
-	GetQuotesByAuthor(author string) ([]models.Quote, error)
-	DeleteQuote(id int64) error
+	CreateQuote(quote *models.Quote, allowDuplicate bool, changedBy string) error
+	CreateQuotes(quotes []models.Quote, mode models.BatchMode, allowDuplicate bool, changedBy string) ([]models.BatchResult, error)
+	ListQuotes(query models.QuoteQuery, page models.Page) (models.QuotePage, error)
+	ExportQuotes(query models.QuoteQuery, fn func(quote models.Quote) error) error
+	SearchQuotes(query string, limit int) ([]models.ScoredQuote, error)
+	ListTags() ([]models.TagCount, error)
+	GetQuoteByID(id int64) (*models.Quote, error)
+	GetRandomQuote(query models.QuoteQuery, weight models.RandomWeight) (*models.Quote, error)
+	GetShuffledQuote(client string, query models.QuoteQuery) (*models.Quote, error)
+	GetDailyQuote(date time.Time) (*models.Quote, error)
+	UpdateQuote(quote *models.Quote, changedBy string) error
+	DeleteQuote(id int64, version int64, changedBy string) error
+	ListTrash() ([]models.Quote, error)
+	RestoreQuote(id int64, changedBy string) (*models.Quote, error)
+	FindDuplicates() ([]models.DuplicateCluster, error)
+	MergeDuplicates(changedBy string) ([]models.DuplicateCluster, error)
+	MergedInto(id int64) (int64, error)
+	QuoteHistory(id int64) ([]models.Revision, error)
+	GetRevision(id int64, number int64) (*models.Revision, error)
+	RevertQuote(id int64, number int64, version int64, changedBy string) (*models.Quote, error)
+	DiffRevisions(id int64, from int64, to int64) (*models.RevisionDiff, error)
 }
 
 type QuoteHandler struct {
@@ -34,6 +52,19 @@ func NewQuoteHandler(service QuoteService) *QuoteHandler {
 func (h *QuoteHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.quote.CreateQuote"
 
+	allowDuplicate, err := parseAllowDuplicate(r.URL.Query())
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var quote models.Quote
 	if err := json.NewDecoder(r.Body).Decode(&quote); err != nil {
 		log.Printf("%s: failed to decode request body: %v", op, err)
@@ -41,19 +72,35 @@ func (h *QuoteHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.CreateQuote(&quote); err != nil {
+	if err := h.service.CreateQuote(&quote, allowDuplicate, changedBy); err != nil {
 		log.Printf("%s: failed to create quote: %v", op, err)
+		var dup *storage.DuplicateError
 		switch {
+		case errors.As(err, &dup):
+			if err := writeDuplicate(w, dup); err != nil {
+				log.Printf("%s: failed to encode response: %v", op, err)
+			}
 		case errors.Is(err, storage.ErrEmptyAuthor):
 			http.Error(w, "Author cannot be empty", http.StatusBadRequest)
 		case errors.Is(err, storage.ErrEmptyText):
 			http.Error(w, "Quote text cannot be empty", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrAuthorNotFound):
+			http.Error(w, "Author not found", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrInvalidRating):
+			http.Error(w, "Rating must be from 0 to 5", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrInvalidSource):
+			http.Error(w, "Invalid quote source", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrInvalidStatus):
+			http.Error(w, "Invalid verification status", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrSourceRequired):
+			http.Error(w, "Verified quote must have a source", http.StatusBadRequest)
 		default:
 			http.Error(w, "Failed to create quote", http.StatusInternalServerError)
 		}
 		return
 	}
 
+	w.Header().Set("ETag", quoteETag(&quote))
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(quote); err != nil {
 		log.Printf("%s: failed to encode response: %v", op, err)
@@ -62,27 +109,255 @@ func (h *QuoteHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *QuoteHandler) GetAllQuotes(w http.ResponseWriter, _ *http.Request) {
-	const op = "handlers.quote.GetAllQuotes"
+func (h *QuoteHandler) CreateQuotes(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.CreateQuotes"
 
-	quotes, err := h.service.GetAllQuotes()
+	mode := models.BatchMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = models.BatchAtomic
+	}
+	if !mode.Valid() {
+		log.Printf("%s: invalid mode %q", op, mode)
+		http.Error(w, "Invalid mode: expected atomic or best_effort", http.StatusBadRequest)
+		return
+	}
+	allowDuplicate, err := parseAllowDuplicate(r.URL.Query())
 	if err != nil {
-		log.Printf("%s: failed to get quotes: %v", op, err)
-		http.Error(w, "Failed to get quotes", http.StatusInternalServerError)
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(quotes); err != nil {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	quotes, err := decodeBatch(r.Body, mediaType == ndjsonMediaType)
+	if err != nil {
+		log.Printf("%s: failed to decode request body: %v", op, err)
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.CreateQuotes(quotes, mode, allowDuplicate, changedBy)
+	if err != nil {
+		log.Printf("%s: failed to create quotes: %v", op, err)
+		http.Error(w, "Failed to create quotes", http.StatusInternalServerError)
+		return
+	}
+
+	report := newBatchReport(results)
+	switch {
+	case report.Failed == 0:
+		w.WriteHeader(http.StatusCreated)
+	case report.Created == 0:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
 		log.Printf("%s: failed to encode response: %v", op, err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func (h *QuoteHandler) GetRandomQuote(w http.ResponseWriter, _ *http.Request) {
+func (h *QuoteHandler) ListQuotes(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.ListQuotes"
+
+	query, err := parseQuoteQuery(r.URL.Query())
+	if err != nil {
+		log.Printf("%s: invalid query: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		log.Printf("%s: invalid page: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.ListQuotes(query, page)
+	if err != nil {
+		log.Printf("%s: failed to get quotes: %v", op, err)
+		http.Error(w, "Failed to get quotes", http.StatusInternalServerError)
+		return
+	}
+
+	list := quoteList{Quotes: result.Quotes, Suggestions: result.Suggestions}
+	if result.Next != nil {
+		list.NextCursor = encodeCursor(result.Next, r.URL.Query().Get("sort"))
+		setNextLink(w, r, list.NextCursor)
+	}
+	writeCached(w, r, op, list, "")
+}
+
+func (h *QuoteHandler) ExportQuotes(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.ExportQuotes"
+
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "ndjson"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		log.Printf("%s: invalid format %q", op, name)
+		http.Error(w, "Invalid format: expected ndjson, csv or json", http.StatusBadRequest)
+		return
+	}
+
+	query, err := parseQuoteQuery(r.URL.Query())
+	if err != nil {
+		log.Printf("%s: invalid query: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := "quotes-" + time.Now().UTC().Format(time.DateOnly) + "." + format.extension
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	export := format.newWriter(w)
+	exported := 0
+	err = h.service.ExportQuotes(query, func(quote models.Quote) error {
+		exported++
+		return export.Write(quote)
+	})
+	if err == nil {
+		err = export.Close()
+	}
+	if err != nil {
+		log.Printf("%s: failed to export quotes: %v", op, err)
+		// Once quotes are written the status is sent, and the cut off
+		// output is all that tells the client the export failed.
+		if exported == 0 {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Failed to export quotes", http.StatusInternalServerError)
+		}
+	}
+}
+
+func (h *QuoteHandler) SearchQuotes(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.SearchQuotes"
+
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageLimit {
+			log.Printf("%s: invalid limit %q", op, value)
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	quotes, err := h.service.SearchQuotes(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		log.Printf("%s: failed to search quotes: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrEmptySearchQuery):
+			http.Error(w, "Search query cannot be empty", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to search quotes", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeCached(w, r, op, quotes, "")
+}
+
+func (h *QuoteHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.ListTags"
+
+	tags, err := h.service.ListTags()
+	if err != nil {
+		log.Printf("%s: failed to get tags: %v", op, err)
+		http.Error(w, "Failed to get tags", http.StatusInternalServerError)
+		return
+	}
+
+	writeCached(w, r, op, tags, "")
+}
+
+func (h *QuoteHandler) GetQuoteByID(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.GetQuoteByID"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.service.GetQuoteByID(id)
+	if err != nil {
+		log.Printf("%s: failed to get quote: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrQuoteNotFound):
+			h.redirectMerged(w, r, op, id)
+		case errors.Is(err, storage.ErrInvalidID):
+			http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to get quote", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeCached(w, r, op, quote, quoteETag(quote))
+}
+
+const (
+	// clientTokenHeader identifies the client, such as a display screen, that
+	// asks for random quotes without repeats. The client query parameter can
+	// be used instead.
+	clientTokenHeader    = "X-Client-Token"
+	maxClientTokenLength = 128
+)
+
+func (h *QuoteHandler) GetRandomQuote(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.quote.GetRandomQuote"
 
-	quote, err := h.service.GetRandomQuote()
+	query, err := parseQuoteQuery(r.URL.Query())
+	if err != nil {
+		log.Printf("%s: invalid query: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	weight := models.RandomWeight(r.URL.Query().Get("weight"))
+	if !weight.Valid() {
+		log.Printf("%s: invalid weight %q", op, weight)
+		http.Error(w, "Invalid weight: expected rating or recency", http.StatusBadRequest)
+		return
+	}
+
+	// A client token switches to the shuffle bag, which does not repeat
+	// quotes and so cannot favor any of them.
+	client := r.Header.Get(clientTokenHeader)
+	if client == "" {
+		client = r.URL.Query().Get("client")
+	}
+	if len(client) > maxClientTokenLength {
+		log.Printf("%s: client token of %d bytes", op, len(client))
+		http.Error(w, "Client token is too long", http.StatusBadRequest)
+		return
+	}
+	if client != "" && weight != models.WeightUniform {
+		log.Printf("%s: weight %q with client token", op, weight)
+		http.Error(w, "Weight cannot be combined with a client token", http.StatusBadRequest)
+		return
+	}
+
+	var quote *models.Quote
+	if client != "" {
+		quote, err = h.service.GetShuffledQuote(client, query)
+	} else {
+		quote, err = h.service.GetRandomQuote(query, weight)
+	}
 	if err != nil {
 		log.Printf("%s: failed to get random quote: %v", op, err)
 		switch {
@@ -101,29 +376,181 @@ func (h *QuoteHandler) GetRandomQuote(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-func (h *QuoteHandler) GetQuotesByAuthor(w http.ResponseWriter, r *http.Request) {
-	const op = "handlers.quote.GetQuotesByAuthor"
+func (h *QuoteHandler) GetDailyQuote(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.GetDailyQuote"
+
+	loc := time.UTC
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			log.Printf("%s: invalid time zone: %v", op, err)
+			http.Error(w, "Invalid tz: expected an IANA time zone such as Europe/Moscow", http.StatusBadRequest)
+			return
+		}
+	}
+
+	date := time.Now().In(loc)
+	if value := r.URL.Query().Get("date"); value != "" {
+		var err error
+		if date, err = time.ParseInLocation(time.DateOnly, value, loc); err != nil {
+			log.Printf("%s: invalid date: %v", op, err)
+			http.Error(w, "Invalid date: expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	quote, err := h.service.GetDailyQuote(date)
+	if err != nil {
+		log.Printf("%s: failed to get daily quote: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrNoQuotesAvailable):
+			http.Error(w, "No quotes available", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to get daily quote", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeCached(w, r, op, quote, "")
+}
+
+func (h *QuoteHandler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.UpdateQuote"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writePreconditionError(w, op, err)
+		return
+	}
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var quote models.Quote
+	if err := json.NewDecoder(r.Body).Decode(&quote); err != nil {
+		log.Printf("%s: failed to decode request body: %v", op, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	quote.ID = id
+	quote.Version = version
+
+	h.saveQuote(w, op, &quote, changedBy)
+}
+
+func (h *QuoteHandler) PatchQuote(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.PatchQuote"
 
-	author := r.URL.Query().Get("author")
-	if author == "" {
-		log.Printf("%s: author parameter is missing", op)
-		http.Error(w, "Author parameter is required", http.StatusBadRequest)
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != mergePatchMediaType {
+		log.Printf("%s: unsupported content type %q", op, r.Header.Get("Content-Type"))
+		http.Error(w, "Content-Type must be "+mergePatchMediaType, http.StatusUnsupportedMediaType)
 		return
 	}
 
-	quotes, err := h.service.GetQuotesByAuthor(author)
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writePreconditionError(w, op, err)
+		return
+	}
+	changedBy, err := parseChangedBy(r)
 	if err != nil {
-		log.Printf("%s: failed to get quotes by author: %v", op, err)
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var patch map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		log.Printf("%s: failed to decode request body: %v", op, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	current, err := h.service.GetQuoteByID(id)
+	if err != nil {
+		log.Printf("%s: failed to get quote: %v", op, err)
 		switch {
+		case errors.Is(err, storage.ErrQuoteNotFound):
+			http.Error(w, "Quote not found", http.StatusNotFound)
+		case errors.Is(err, storage.ErrInvalidID):
+			http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to get quote", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	quote, err := applyMergePatch(current, patch)
+	if err != nil {
+		log.Printf("%s: failed to apply patch: %v", op, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if version != 0 && version != current.Version {
+		writePreconditionError(w, op, errPreconditionFailed)
+		return
+	}
+	// The patch was applied to the current version, so the update must not
+	// overwrite anything saved since then even if the client sent "*".
+	quote.ID = id
+	quote.Version = current.Version
+
+	h.saveQuote(w, op, quote, changedBy)
+}
+
+// saveQuote stores the updated quote and writes it to the response.
+func (h *QuoteHandler) saveQuote(w http.ResponseWriter, op string, quote *models.Quote, changedBy string) {
+	if err := h.service.UpdateQuote(quote, changedBy); err != nil {
+		log.Printf("%s: failed to update quote: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrQuoteNotFound):
+			http.Error(w, "Quote not found", http.StatusNotFound)
+		case errors.Is(err, storage.ErrInvalidID):
+			http.Error(w, "Invalid quote ID", http.StatusBadRequest)
 		case errors.Is(err, storage.ErrEmptyAuthor):
 			http.Error(w, "Author cannot be empty", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrEmptyText):
+			http.Error(w, "Quote text cannot be empty", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrAuthorNotFound):
+			http.Error(w, "Author not found", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrInvalidRating):
+			http.Error(w, "Rating must be from 0 to 5", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrInvalidSource):
+			http.Error(w, "Invalid quote source", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrInvalidStatus):
+			http.Error(w, "Invalid verification status", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrSourceRequired):
+			http.Error(w, "Verified quote must have a source", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrVersionMismatch):
+			http.Error(w, "Quote has been modified", http.StatusPreconditionFailed)
 		default:
-			http.Error(w, "Failed to get quotes by author", http.StatusInternalServerError)
+			http.Error(w, "Failed to update quote", http.StatusInternalServerError)
 		}
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(quotes); err != nil {
+	w.Header().Set("ETag", quoteETag(quote))
+	if err := json.NewEncoder(w).Encode(quote); err != nil {
 		log.Printf("%s: failed to encode response: %v", op, err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
@@ -141,13 +568,27 @@ func (h *QuoteHandler) DeleteQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteQuote(id); err != nil {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writePreconditionError(w, op, err)
+		return
+	}
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteQuote(id, version, changedBy); err != nil {
 		log.Printf("%s: failed to delete quote: %v", op, err)
 		switch {
 		case errors.Is(err, storage.ErrQuoteNotFound):
 			http.Error(w, "Quote not found", http.StatusNotFound)
 		case errors.Is(err, storage.ErrInvalidID):
 			http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrVersionMismatch):
+			http.Error(w, "Quote has been modified", http.StatusPreconditionFailed)
 		default:
 			http.Error(w, "Failed to delete quote", http.StatusInternalServerError)
 		}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"quotes/internal/domain/models"
+)
+
+// parseQuoteQuery reads the listing filters from the query string:
+// author with its match mode, text, created_after, created_before and
+// repeated tag parameters with their match mode, max_length and verification.
+func parseQuoteQuery(values url.Values) (models.QuoteQuery, error) {
+	query := models.QuoteQuery{
+		Author:       values.Get("author"),
+		TextContains: values.Get("text"),
+	}
+
+	switch values.Get("match") {
+	case "", "exact":
+	case "fuzzy":
+		query.AuthorFuzzy = true
+	default:
+		return query, fmt.Errorf("invalid match: expected exact or fuzzy, got %q", values.Get("match"))
+	}
+
+	query.Tags = models.NormalizeTags(values["tag"])
+	switch values.Get("tag_match") {
+	case "", "all":
+	case "any":
+		query.AnyTag = true
+	default:
+		return query, fmt.Errorf("invalid tag_match: expected all or any, got %q", values.Get("tag_match"))
+	}
+
+	if value := values.Get("max_length"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return query, fmt.Errorf("invalid max_length: expected a positive number, got %q", value)
+		}
+		query.MaxLength = n
+	}
+
+	if value := values.Get("verification"); value != "" {
+		query.Verification = models.Verification(value)
+		if !query.Verification.Valid() {
+			return query, fmt.Errorf("invalid verification: expected unverified, verified, disputed or apocryphal, got %q", value)
+		}
+	}
+
+	var err error
+	if query.CreatedAfter, err = parseTime(values.Get("created_after")); err != nil {
+		return query, fmt.Errorf("invalid created_after: %w", err)
+	}
+	if query.CreatedBefore, err = parseTime(values.Get("created_before")); err != nil {
+		return query, fmt.Errorf("invalid created_before: %w", err)
+	}
+	return query, nil
+}
+
+// parseTime accepts RFC 3339 timestamps and dates in UTC.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 time or YYYY-MM-DD date, got %q", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import "github.com/gorilla/mux"
+
+// RegisterRoutes registers the quote endpoints on the router.
+func (h *QuoteHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/quotes", h.CreateQuote).Methods("POST")
+	r.HandleFunc("/quotes", h.ListQuotes).Methods("GET")
+	r.HandleFunc("/quotes/batch", h.CreateQuotes).Methods("POST")
+	r.HandleFunc("/quotes/export", h.ExportQuotes).Methods("GET")
+	r.HandleFunc("/quotes/random", h.GetRandomQuote).Methods("GET")
+	r.HandleFunc("/quotes/daily", h.GetDailyQuote).Methods("GET")
+	r.HandleFunc("/quotes/search", h.SearchQuotes).Methods("GET")
+	r.HandleFunc("/quotes/trash", h.ListTrash).Methods("GET")
+	r.HandleFunc("/quotes/{id:[0-9]+}", h.GetQuoteByID).Methods("GET")
+	r.HandleFunc("/quotes/{id:[0-9]+}", h.UpdateQuote).Methods("PUT")
+	r.HandleFunc("/quotes/{id:[0-9]+}", h.PatchQuote).Methods("PATCH")
+	r.HandleFunc("/quotes/{id:[0-9]+}", h.DeleteQuote).Methods("DELETE")
+	r.HandleFunc("/quotes/{id:[0-9]+}/restore", h.RestoreQuote).Methods("POST")
+	r.HandleFunc("/quotes/{id:[0-9]+}/history", h.GetQuoteHistory).Methods("GET")
+	r.HandleFunc("/quotes/{id:[0-9]+}/history/diff", h.DiffRevisions).Methods("GET")
+	r.HandleFunc("/quotes/{id:[0-9]+}/history/{rev:[0-9]+}", h.GetRevision).Methods("GET")
+	r.HandleFunc("/quotes/{id:[0-9]+}/history/{rev:[0-9]+}/revert", h.RevertQuote).Methods("POST")
+	r.HandleFunc("/tags", h.ListTags).Methods("GET")
+	r.HandleFunc("/admin/duplicates", h.FindDuplicates).Methods("GET")
+	r.HandleFunc("/admin/duplicates/merge", h.MergeDuplicates).Methods("POST")
+}
+
+// RegisterRoutes registers the author endpoints on the router.
+func (h *AuthorHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/authors", h.CreateAuthor).Methods("POST")
+	r.HandleFunc("/authors", h.GetAllAuthors).Methods("GET")
+	r.HandleFunc("/authors/{id:[0-9]+}", h.GetAuthorByID).Methods("GET")
+	r.HandleFunc("/authors/{id:[0-9]+}", h.UpdateAuthor).Methods("PUT")
+	r.HandleFunc("/authors/{id:[0-9]+}", h.DeleteAuthor).Methods("DELETE")
+	r.HandleFunc("/authors/{id:[0-9]+}/quotes", h.GetAuthorQuotes).Methods("GET")
+}
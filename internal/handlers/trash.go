@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"quotes/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *QuoteHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.ListTrash"
+
+	quotes, err := h.service.ListTrash()
+	if err != nil {
+		log.Printf("%s: failed to get trash: %v", op, err)
+		http.Error(w, "Failed to get trash", http.StatusInternalServerError)
+		return
+	}
+
+	writeCached(w, r, op, quotes, "")
+}
+
+func (h *QuoteHandler) RestoreQuote(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.quote.RestoreQuote"
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid quote ID: %v", op, err)
+		http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		return
+	}
+
+	changedBy, err := parseChangedBy(r)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.service.RestoreQuote(id, changedBy)
+	if err != nil {
+		log.Printf("%s: failed to restore quote: %v", op, err)
+		switch {
+		case errors.Is(err, storage.ErrQuoteNotFound):
+			http.Error(w, "Quote not found in trash", http.StatusNotFound)
+		case errors.Is(err, storage.ErrInvalidID):
+			http.Error(w, "Invalid quote ID", http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to restore quote", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", quoteETag(quote))
+	if err := json.NewEncoder(w).Encode(quote); err != nil {
+		log.Printf("%s: failed to encode response: %v", op, err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
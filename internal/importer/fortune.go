@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ParseFortune reads a fortune(6) file: entries separated by lines holding a
+// single %, each optionally ending with an attribution line starting with --
+// and its indented continuation lines. The line breaks of the entries are
+// kept, their common indentation is removed.
+func ParseFortune(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var lines []string
+	start := 1
+
+	flush := func() {
+		if entry, ok := fortuneEntry(lines); ok {
+			entry.Line = start
+			entries = append(entries, entry)
+		}
+		lines = lines[:0]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRightFunc(scanner.Text(), unicode.IsSpace)
+		if line == "%" || line == "%%" {
+			flush()
+			start = n + 1
+			continue
+		}
+		if len(lines) == 0 && line == "" {
+			start = n + 1
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return entries, nil
+}
+
+func fortuneEntry(lines []string) (Entry, bool) {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// The attribution is the last line with a marker followed only by
+	// indented lines.
+	var entry Entry
+	for i := len(lines) - 1; i > 0; i-- {
+		if attribution, ok := cutAttribution(lines[i]); ok {
+			for _, line := range lines[i+1:] {
+				attribution += " " + line
+			}
+			setAttribution(&entry.Quote, attribution)
+			lines = lines[:i]
+			break
+		}
+		if lines[i] == "" || !unicode.IsSpace(rune(lines[i][0])) {
+			break
+		}
+	}
+
+	entry.Quote.Text = trimQuotes(strings.TrimSpace(dedent(lines)))
+	return entry, entry.Quote.Text != ""
+}
+
+// dedent joins the lines after removing their common leading whitespace.
+func dedent(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
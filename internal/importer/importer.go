@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+)
+
+// Service creates the imported quotes. It is implemented by
+// services.QuoteService.
+type Service interface {
+	CreateQuote(quote *models.Quote, allowDuplicate bool, changedBy string) error
+	GetQuotesByAuthor(author string) ([]models.Quote, error)
+}
+
+type Options struct {
+	// DryRun counts the quotes that would be created without creating them.
+	// The quotes are not validated then.
+	DryRun bool
+	// DefaultAuthor is given to the quotes without an attribution. If empty,
+	// such quotes are skipped.
+	DefaultAuthor string
+	// ChangedBy is recorded as the maker of the created quotes.
+	ChangedBy string
+}
+
+// Summary counts the outcomes of an import.
+type Summary struct {
+	Parsed int
+	// Created is the number of quotes created or, in a dry run, to be created.
+	Created    int
+	Duplicates int
+	// Unattributed is the number of quotes skipped for lacking an author.
+	Unattributed int
+	Failures     []Failure
+}
+
+// Failure is a quote the service refused to create.
+type Failure struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (f Failure) Error() string {
+	return fmt.Sprintf("%s:%d: %v", f.File, f.Line, f.Err)
+}
+
+// Importer creates quotes read from files, skipping the ones that exist
+// already or repeat an earlier one. Quotes are the same if their authors and
+// texts match regardless of case, spacing and punctuation. Quotes the service
+// refuses as near duplicates are counted as duplicates too.
+type Importer struct {
+	service Service
+	opts    Options
+	summary Summary
+
+	// seen holds the keys of the existing quotes of the authors in authors
+	// and of the imported quotes.
+	seen    map[string]bool
+	authors map[string]bool
+}
+
+func New(service Service, opts Options) *Importer {
+	return &Importer{
+		service: service,
+		opts:    opts,
+		seen:    make(map[string]bool),
+		authors: make(map[string]bool),
+	}
+}
+
+// Import creates the quotes of the entries read from the named file. Quotes
+// the service refuses are listed in the summary; an error is returned only if
+// the existing quotes cannot be checked.
+func (im *Importer) Import(file string, entries []Entry) error {
+	const op = "importer.Import"
+
+	for _, entry := range entries {
+		im.summary.Parsed++
+
+		quote := entry.Quote
+		if quote.Author == "" {
+			quote.Author = im.opts.DefaultAuthor
+		}
+		if quote.Author == "" {
+			im.summary.Unattributed++
+			continue
+		}
+
+		duplicate, err := im.duplicate(quote)
+		if err != nil {
+			return fmt.Errorf("%s: %s:%d: %w", op, file, entry.Line, err)
+		}
+		if duplicate {
+			im.summary.Duplicates++
+			continue
+		}
+
+		key := quoteKey(quote)
+		if !im.opts.DryRun {
+			err := im.service.CreateQuote(&quote, false, im.opts.ChangedBy)
+			if errors.Is(err, storage.ErrDuplicateQuote) {
+				im.summary.Duplicates++
+				continue
+			}
+			if err != nil {
+				im.summary.Failures = append(im.summary.Failures, Failure{File: file, Line: entry.Line, Err: err})
+				continue
+			}
+			// The service may have replaced an alias with the author's name.
+			im.seen[quoteKey(quote)] = true
+		}
+		im.seen[key] = true
+		im.summary.Created++
+	}
+	return nil
+}
+
+func (im *Importer) Summary() Summary {
+	return im.summary
+}
+
+// duplicate reports whether the quote exists already or has been imported.
+func (im *Importer) duplicate(quote models.Quote) (bool, error) {
+	author := models.NormalizeAuthor(quote.Author)
+	if !im.authors[author] {
+		existing, err := im.service.GetQuotesByAuthor(quote.Author)
+		if err != nil {
+			return false, err
+		}
+		for _, q := range existing {
+			im.seen[quoteKey(q)] = true
+		}
+		im.authors[author] = true
+	}
+	return im.seen[quoteKey(quote)], nil
+}
+
+// quoteKey identifies a quote by its normalized author and text.
+func quoteKey(quote models.Quote) string {
+	return models.NormalizeAuthor(quote.Author) + "\x00" + models.NormalizeText(quote.Text)
+}
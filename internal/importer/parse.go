@@ -0,0 +1,94 @@
+// Package importer reads quotes from fortune(6) files and a simple
+// Markdown/plain-text format and creates them through the quote service.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"quotes/internal/domain/models"
+)
+
+// Format is a format of quote files.
+type Format string
+
+const (
+	// FormatFortune is the fortune(6) format, see ParseFortune.
+	FormatFortune Format = "fortune"
+	// FormatText is the Markdown/plain-text format, see ParseText.
+	FormatText Format = "text"
+)
+
+// Entry is a quote read from a file together with the line it starts on.
+type Entry struct {
+	Line  int
+	Quote models.Quote
+}
+
+// FormatOf guesses the format of the file by its extension: Markdown and text
+// files are in FormatText, anything else, like the extensionless fortune
+// databases, in FormatFortune.
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".txt":
+		return FormatText
+	default:
+		return FormatFortune
+	}
+}
+
+// Parse reads the entries of a file in the format.
+func Parse(format Format, r io.Reader) ([]Entry, error) {
+	switch format {
+	case FormatFortune:
+		return ParseFortune(r)
+	case FormatText:
+		return ParseText(r)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// attributionMarkers start the line naming the author of a quote.
+var attributionMarkers = []string{"--", "—", "―", "~"}
+
+// cutAttribution returns the line without its attribution marker.
+func cutAttribution(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	for _, marker := range attributionMarkers {
+		if rest, ok := strings.CutPrefix(line, marker); ok {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+// setAttribution fills the author of the quote from an attribution such as
+// `Mark Twain, "Following the Equator"`: the text after the first comma
+// names the source.
+func setAttribution(quote *models.Quote, attribution string) {
+	attribution = strings.Join(strings.Fields(attribution), " ")
+	author, title, _ := strings.Cut(attribution, ",")
+	quote.Author = strings.TrimSpace(author)
+	if title = trimQuotes(strings.TrimSpace(title)); title != "" {
+		quote.Source = &models.Source{Title: title}
+	}
+}
+
+var quotePairs = [][2]string{{`"`, `"`}, {"“", "”"}, {"«", "»"}, {"„", "“"}}
+
+// trimQuotes removes the quotation marks around the whole text.
+func trimQuotes(text string) string {
+	for _, pair := range quotePairs {
+		if len(text) > len(pair[0])+len(pair[1]) && strings.HasPrefix(text, pair[0]) && strings.HasSuffix(text, pair[1]) {
+			inner := text[len(pair[0]) : len(text)-len(pair[1])]
+			// "A" and "B" is not a quoted text.
+			if !strings.Contains(inner, pair[0]) && !strings.Contains(inner, pair[1]) {
+				return strings.TrimSpace(inner)
+			}
+		}
+	}
+	return text
+}
@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ParseText reads quotes in a simple Markdown/plain-text format:
+//
+//	# Mark Twain
+//
+//	> Get your facts first,
+//	> then you can distort them as you please.
+//	— Mark Twain, "Rudyard Kipling interview"
+//
+//	* The secret of getting ahead is getting started.
+//	* "Never put off till tomorrow..." -- Mark Twain
+//
+// Entries are separated by blank lines, and every bullet starts a new one.
+// Blockquote markers are removed and the lines of an entry are joined into a
+// single paragraph, as in Markdown. The author is named on a last line starting
+// with --, —, ― or ~, or after " — " or " -- " on a single-line entry. Entries
+// without an author are attributed to the author in the latest heading, which
+// suits Wikiquote pages; otherwise they are left without an author.
+func ParseText(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var lines []string
+	var heading string
+	start := 0
+
+	flush := func() {
+		if entry, ok := textEntry(lines, heading); ok {
+			entry.Line = start
+			entries = append(entries, entry)
+		}
+		lines = lines[:0]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			flush()
+			heading = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		for strings.HasPrefix(line, ">") {
+			line = strings.TrimSpace(line[1:])
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		if item, ok := cutBullet(line); ok {
+			flush()
+			line = item
+		}
+		if len(lines) == 0 {
+			start = n
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return entries, nil
+}
+
+func cutBullet(line string) (string, bool) {
+	for _, bullet := range []string{"* ", "- ", "+ "} {
+		if item, ok := strings.CutPrefix(line, bullet); ok {
+			return strings.TrimSpace(item), true
+		}
+	}
+	return line, false
+}
+
+// inlineAttributions separate the text from the author on a single line.
+var inlineAttributions = []string{" — ", " ― ", " -- "}
+
+func textEntry(lines []string, heading string) (Entry, bool) {
+	var entry Entry
+	if len(lines) > 1 {
+		if attribution, ok := cutAttribution(lines[len(lines)-1]); ok {
+			setAttribution(&entry.Quote, attribution)
+			lines = lines[:len(lines)-1]
+		}
+	} else if len(lines) == 1 {
+		for _, sep := range inlineAttributions {
+			if i := strings.LastIndex(lines[0], sep); i > 0 {
+				setAttribution(&entry.Quote, lines[0][i+len(sep):])
+				lines = []string{lines[0][:i]}
+				break
+			}
+		}
+	}
+	if entry.Quote.Author == "" {
+		entry.Quote.Author = heading
+	}
+
+	entry.Quote.Text = trimQuotes(strings.Join(lines, " "))
+	return entry, entry.Quote.Text != ""
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+)
+
+type AuthorRepository interface {
+	// Create stores the author. Its name and aliases must not be taken by
+	// another author.
+	Create(author *models.Author) error
+	// GetAll returns all authors ordered by ID.
+	GetAll() ([]models.Author, error)
+	GetByID(id int64) (*models.Author, error)
+	// FindByName returns the author with the name or alias, compared as
+	// normalized by models.NormalizeAuthor.
+	FindByName(name string) (*models.Author, error)
+	Update(author *models.Author) error
+	Delete(id int64) error
+}
+
+type AuthorService struct {
+	authors AuthorRepository
+	quotes  QuoteRepository
+}
+
+func NewAuthorService(authors AuthorRepository, quotes QuoteRepository) *AuthorService {
+	return &AuthorService{
+		authors: authors,
+		quotes:  quotes,
+	}
+}
+
+func (s *AuthorService) CreateAuthor(author *models.Author) error {
+	const op = "services.author.CreateAuthor"
+
+	if author == nil {
+		return fmt.Errorf("%s: %w", op, fmt.Errorf("author cannot be nil"))
+	}
+
+	if err := s.authors.Create(author); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *AuthorService) GetAllAuthors() ([]models.Author, error) {
+	const op = "services.author.GetAllAuthors"
+
+	authors, err := s.authors.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return authors, nil
+}
+
+func (s *AuthorService) GetAuthorByID(id int64) (*models.Author, error) {
+	const op = "services.author.GetAuthorByID"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	author, err := s.authors.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return author, nil
+}
+
+// UpdateAuthor replaces the author and moves its quotes to the new name on
+// behalf of changedBy. The quotes are renamed at once and only if none of them
+// has changed since they were read; otherwise the author is restored.
+func (s *AuthorService) UpdateAuthor(author *models.Author, changedBy string) error {
+	const op = "services.author.UpdateAuthor"
+
+	if author == nil {
+		return fmt.Errorf("%s: %w", op, fmt.Errorf("author cannot be nil"))
+	}
+	if author.ID <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	old, err := s.authors.GetByID(author.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.authors.Update(author); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.renameQuotes(*author, changedBy); err != nil {
+		if rerr := s.authors.Update(old); rerr != nil {
+			return fmt.Errorf("%s: %w (restoring the author: %v)", op, err, rerr)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// renameQuotes sets the author name of the quotes of the author to its name.
+func (s *AuthorService) renameQuotes(author models.Author, changedBy string) error {
+	result, err := s.quotes.List(models.QuoteQuery{AuthorID: author.ID}, models.Page{})
+	if err != nil {
+		return err
+	}
+	var renamed []models.Quote
+	for _, quote := range result.Quotes {
+		if quote.Author != author.Name {
+			quote.Author = author.Name
+			renamed = append(renamed, quote)
+		}
+	}
+	if len(renamed) == 0 {
+		return nil
+	}
+	return s.quotes.By(changedBy).UpdateBatch(renamed)
+}
+
+// DeleteAuthor removes an author that has no quotes, including the ones in
+// the trash.
+func (s *AuthorService) DeleteAuthor(id int64) error {
+	const op = "services.author.DeleteAuthor"
+
+	if id <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	result, err := s.quotes.List(models.QuoteQuery{AuthorID: id}, models.Page{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(result.Quotes) > 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorHasQuotes)
+	}
+	trash, err := s.quotes.Trash()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if slices.ContainsFunc(trash, func(quote models.Quote) bool { return quote.AuthorID == id }) {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorHasQuotes)
+	}
+
+	if err := s.authors.Delete(id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *AuthorService) GetAuthorQuotes(id int64, page models.Page) (models.QuotePage, error) {
+	const op = "services.author.GetAuthorQuotes"
+
+	if _, err := s.GetAuthorByID(id); err != nil {
+		return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := s.quotes.List(models.QuoteQuery{AuthorID: id}, page)
+	if err != nil {
+		return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// ResolveAuthor links the quote to its author: the one with the quote's author
+// name or alias, or the one with quote.AuthorID if no name is given. An author
+// is created for a name nobody has yet.
+func (s *AuthorService) ResolveAuthor(quote *models.Quote) error {
+	const op = "services.author.ResolveAuthor"
+
+	err := s.lookupAuthor(quote)
+	if errors.Is(err, storage.ErrAuthorNotFound) && models.NormalizeAuthor(quote.Author) != "" {
+		author := &models.Author{Name: quote.Author}
+		err = s.authors.Create(author)
+		switch {
+		case err == nil:
+			quote.AuthorID = author.ID
+			quote.Author = author.Name
+		case errors.Is(err, storage.ErrAuthorExists):
+			// Someone else has just created the author.
+			err = s.lookupAuthor(quote)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// LookupAuthor links the quote to its author like ResolveAuthor, but fails
+// with storage.ErrAuthorNotFound instead of creating a new author.
+func (s *AuthorService) LookupAuthor(quote *models.Quote) error {
+	const op = "services.author.LookupAuthor"
+
+	if err := s.lookupAuthor(quote); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *AuthorService) lookupAuthor(quote *models.Quote) error {
+	var author *models.Author
+	var err error
+	if models.NormalizeAuthor(quote.Author) == "" {
+		if quote.AuthorID == 0 {
+			return storage.ErrEmptyAuthor
+		}
+		author, err = s.authors.GetByID(quote.AuthorID)
+	} else {
+		author, err = s.authors.FindByName(quote.Author)
+	}
+	if err != nil {
+		return err
+	}
+
+	quote.AuthorID = author.ID
+	quote.Author = author.Name
+	return nil
+}
+
+// LinkQuotes derives authors from the author names of quotes that are not
+// linked to an author yet, and returns the number of quotes linked. Linking
+// leaves the quotes' versions and history as they are.
+func (s *AuthorService) LinkQuotes() (int, error) {
+	const op = "services.author.LinkQuotes"
+
+	quotes, err := s.quotes.GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	linked := 0
+	for _, quote := range quotes {
+		if quote.AuthorID != 0 {
+			continue
+		}
+		if err := s.ResolveAuthor(&quote); err != nil {
+			return linked, fmt.Errorf("%s: quote %d: %w", op, quote.ID, err)
+		}
+		if err := s.quotes.LinkAuthor(quote.ID, quote.AuthorID); err != nil {
+			return linked, fmt.Errorf("%s: quote %d: %w", op, quote.ID, err)
+		}
+		linked++
+	}
+	return linked, nil
+}
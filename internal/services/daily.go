@@ -0,0 +1,215 @@
+package services
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+)
+
+// DefaultDailyWindow is the number of days within which a quote of the day
+// is not repeated.
+const DefaultDailyWindow = 30
+
+const secondsPerDay = 24 * 60 * 60
+
+// earliestZoneOffset is the offset of the time zone where a calendar day
+// starts first.
+const earliestZoneOffset = 14 * time.Hour
+
+// DailyQuotes chooses the quote of each calendar day from a hash of the seed,
+// so every replica sharing the seed and the quotes shows the same quote
+// without keeping any state.
+//
+// Days are grouped into blocks of window days. The quotes are split into two
+// classes by their IDs, and even and odd blocks draw from different classes,
+// so a quote cannot be shown twice within window days. Each day of a block
+// takes the highest ranked quote of the class not yet shown in the block.
+// Only quotes added before the day started anywhere in the world are taken
+// into account, so new quotes do not change the quote of a day that has
+// begun. The earlier days of the block are replayed with the deleted and
+// merged quotes as well, so removing a quote does not change the quotes of
+// the other days; the day the quote falls on takes the next quote in line.
+// Quotes purged from the trash are forgotten. When a class has fewer quotes
+// than window, its quotes repeat within the block.
+type DailyQuotes struct {
+	repo   QuoteRepository
+	seed   string
+	window int64
+
+	mu sync.Mutex
+	// candidates caches the result of dailyCandidates for the days that have
+	// begun, as it no longer changes.
+	candidates map[int64][]int64
+}
+
+// maxCachedDays is the number of days whose candidates are cached.
+const maxCachedDays = 64
+
+// cacheDelay is how long after the start of a day its candidates are cached,
+// leaving time for the quotes created just before to be stored.
+const cacheDelay = time.Minute
+
+type rankedQuote struct {
+	key       uint64
+	own       bool
+	id        int64
+	createdAt time.Time
+}
+
+func NewDailyQuotes(repo QuoteRepository, seed string, window int) *DailyQuotes {
+	return &DailyQuotes{
+		repo:       repo,
+		seed:       seed,
+		window:     int64(window),
+		candidates: make(map[int64][]int64),
+	}
+}
+
+// Get returns the quote of the calendar day of the date. The location of the
+// date only matters for which day it falls on.
+func (d *DailyQuotes) Get(date time.Time) (*models.Quote, error) {
+	const op = "services.daily.Get"
+
+	year, month, dayOfMonth := date.Date()
+	day := time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay
+
+	ids, err := d.dayCandidates(day)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, id := range ids {
+		quote, err := d.repo.GetByID(id)
+		if errors.Is(err, storage.ErrQuoteNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return quote, nil
+	}
+	return nil, fmt.Errorf("%s: %w", op, storage.ErrNoQuotesAvailable)
+}
+
+// dayCandidates returns the IDs of the quotes the day may take, in order of
+// preference.
+func (d *DailyQuotes) dayCandidates(day int64) ([]int64, error) {
+	d.mu.Lock()
+	ids, ok := d.candidates[day]
+	d.mu.Unlock()
+	if ok {
+		return ids, nil
+	}
+
+	block := day / d.window
+	if day < 0 && day%d.window != 0 {
+		block--
+	}
+
+	cutoff := dayStart(day)
+	created, err := d.repo.Created(cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	class := uint64(block & 1)
+	ranked := make([]rankedQuote, 0, len(created))
+	for _, quote := range created {
+		ranked = append(ranked, rankedQuote{
+			key:       d.hash(block, quote.ID),
+			own:       d.hash(quote.ID)&1 == class,
+			id:        quote.ID,
+			createdAt: quote.CreatedAt,
+		})
+	}
+	slices.SortFunc(ranked, func(a, b rankedQuote) int {
+		return cmp.Or(cmp.Compare(a.key, b.key), cmp.Compare(a.id, b.id))
+	})
+
+	// Replay the block up to the day to know which quotes it has shown.
+	shown := make(map[int64]bool)
+	for i := block * d.window; i < day; i++ {
+		pickDaily(ranked, dayStart(i), shown)
+	}
+	ids = dailyCandidates(ranked, shown)
+
+	if time.Since(cutoff) > cacheDelay {
+		d.mu.Lock()
+		if len(d.candidates) >= maxCachedDays {
+			clear(d.candidates)
+		}
+		d.candidates[day] = ids
+		d.mu.Unlock()
+	}
+	return ids, nil
+}
+
+// pickDaily marks the highest ranked quote added before the cutoff that has
+// not been shown yet as shown. The quotes of the block's class are preferred;
+// once all of them are shown they start over.
+func pickDaily(ranked []rankedQuote, cutoff time.Time, shown map[int64]bool) {
+	own := slices.ContainsFunc(ranked, func(r rankedQuote) bool {
+		return r.own && r.createdAt.Before(cutoff)
+	})
+
+	var first int64
+	for _, r := range ranked {
+		if (own && !r.own) || !r.createdAt.Before(cutoff) {
+			continue
+		}
+		if !shown[r.id] {
+			shown[r.id] = true
+			return
+		}
+		if first == 0 {
+			first = r.id
+		}
+	}
+	if first != 0 {
+		clear(shown)
+		shown[first] = true
+	}
+}
+
+// dailyCandidates orders the quotes the way pickDaily would choose among them
+// if the ones before were deleted: the quotes of the preferred class not yet
+// shown, then the ones it would start over with, then the other class.
+func dailyCandidates(ranked []rankedQuote, shown map[int64]bool) []int64 {
+	own := slices.ContainsFunc(ranked, func(r rankedQuote) bool {
+		return r.own
+	})
+
+	ids := make([]int64, 0, len(ranked))
+	for _, preferred := range []bool{true, false} {
+		for _, again := range []bool{false, true} {
+			for _, r := range ranked {
+				if (!own || r.own) == preferred && shown[r.id] == again {
+					ids = append(ids, r.id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// dayStart returns the moment the day, counted from the Unix epoch, starts in
+// the earliest time zone.
+func dayStart(day int64) time.Time {
+	return time.Unix(day*secondsPerDay, 0).Add(-earliestZoneOffset)
+}
+
+func (d *DailyQuotes) hash(values ...int64) uint64 {
+	h := sha256.New()
+	h.Write([]byte(d.seed))
+	for _, v := range values {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+	}
+	return binary.BigEndian.Uint64(h.Sum(nil))
+}
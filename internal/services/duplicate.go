@@ -0,0 +1,72 @@
+package services
+
+import (
+	"quotes/internal/domain/models"
+)
+
+// duplicateFinder finds the known quotes a new quote repeats: quotes of the
+// same author with the same normalized text or a nearly the same one. It
+// loads the quotes of each author once, so a batch can reuse it.
+type duplicateFinder struct {
+	repo  QuoteRepository
+	known map[string][]knownQuote
+}
+
+type knownQuote struct {
+	id int64
+	// row is the index of a quote of the batch being created, or -1.
+	row  int
+	text string
+}
+
+func newDuplicateFinder(repo QuoteRepository) *duplicateFinder {
+	return &duplicateFinder{
+		repo:  repo,
+		known: make(map[string][]knownQuote),
+	}
+}
+
+// find returns the known quote the quote duplicates, preferring an exact
+// duplicate to a near one, and whether it is exact.
+func (f *duplicateFinder) find(quote models.Quote) (*knownQuote, bool, error) {
+	known, err := f.quotesOf(quote.Author)
+	if err != nil {
+		return nil, false, err
+	}
+
+	text := models.NormalizeText(quote.Text)
+	var near *knownQuote
+	for i := range known {
+		if known[i].text == text {
+			return &known[i], true, nil
+		}
+		if near == nil && models.NearDuplicate(known[i].text, text) {
+			near = &known[i]
+		}
+	}
+	return near, false, nil
+}
+
+// add makes the quote of the batch row known to later rows.
+func (f *duplicateFinder) add(quote models.Quote, row int) {
+	key := models.NormalizeAuthor(quote.Author)
+	f.known[key] = append(f.known[key], knownQuote{row: row, text: models.NormalizeText(quote.Text)})
+}
+
+func (f *duplicateFinder) quotesOf(author string) ([]knownQuote, error) {
+	key := models.NormalizeAuthor(author)
+	if known, ok := f.known[key]; ok {
+		return known, nil
+	}
+
+	quotes, err := f.repo.GetByAuthor(author)
+	if err != nil {
+		return nil, err
+	}
+	known := make([]knownQuote, 0, len(quotes))
+	for _, quote := range quotes {
+		known = append(known, knownQuote{id: quote.ID, row: -1, text: models.NormalizeText(quote.Text)})
+	}
+	f.known[key] = known
+	return known, nil
+}
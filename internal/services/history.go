@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+)
+
+// QuoteHistory returns the revisions of the quote, oldest first. A quote
+// created before revisions were recorded may have none.
+func (s *QuoteService) QuoteHistory(id int64) ([]models.Revision, error) {
+	const op = "services.quote.QuoteHistory"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	revisions, err := s.repo.Revisions(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(revisions) == 0 {
+		if _, err := s.repo.GetByID(id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return revisions, nil
+}
+
+func (s *QuoteService) GetRevision(id int64, number int64) (*models.Revision, error) {
+	const op = "services.quote.GetRevision"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	revision, err := s.repo.Revision(id, number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revision, nil
+}
+
+// RevertQuote brings the author, text, tags, source, verification status
+// and rating of the quote back to those of the revision with the number. A
+// non-zero version must match the current version of the quote.
+func (s *QuoteService) RevertQuote(id int64, number int64, version int64, changedBy string) (*models.Quote, error) {
+	const op = "services.quote.RevertQuote"
+
+	revision, err := s.GetRevision(id, number)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	old := revision.Quote
+	quote := models.Quote{
+		ID:           id,
+		Author:       old.Author,
+		AuthorID:     old.AuthorID,
+		Text:         old.Text,
+		Tags:         old.Tags,
+		Source:       old.Source,
+		Verification: old.Verification,
+		Rating:       old.Rating,
+		Version:      version,
+	}
+	// Like RestoreQuote, link the quote by the author ID, so that an author
+	// renamed since the revision is not created again under the old name.
+	if old.AuthorID != 0 {
+		quote.Author = ""
+	}
+	err = s.prepareUpdate(&quote)
+	if errors.Is(err, storage.ErrAuthorNotFound) && old.AuthorID != 0 {
+		// The author has been deleted since the revision.
+		quote.Author, quote.AuthorID = old.Author, 0
+		err = s.prepareUpdate(&quote)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.repo.By(changedBy).Revert(&quote, number); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &quote, nil
+}
+
+// DiffRevisions compares two revisions of the quote. Zero to stands for the
+// latest revision and zero from for the one before to.
+func (s *QuoteService) DiffRevisions(id int64, from int64, to int64) (*models.RevisionDiff, error) {
+	const op = "services.quote.DiffRevisions"
+
+	if to == 0 {
+		revisions, err := s.QuoteHistory(id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if len(revisions) == 0 {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+		}
+		to = revisions[len(revisions)-1].Number
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	older, err := s.GetRevision(id, from)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	newer, err := s.GetRevision(id, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	diff, ok := models.DiffRevisions(*older, *newer)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrDiffTooLarge)
+	}
+	return &diff, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"quotes/internal/domain/models"
+)
+
+// FindDuplicates groups the quotes of each author that repeat each other
+// exactly or nearly, see models.NearDuplicate. A quote nearly repeating any
+// quote of a cluster joins it. Clusters come in the order of their canonical
+// quotes.
+func (s *QuoteService) FindDuplicates() ([]models.DuplicateCluster, error) {
+	const op = "services.quote.FindDuplicates"
+
+	quotes, err := s.repo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byAuthor := make(map[string][]models.Quote)
+	for _, quote := range quotes {
+		key := models.NormalizeAuthor(quote.Author)
+		byAuthor[key] = append(byAuthor[key], quote)
+	}
+	clusters := make([]models.DuplicateCluster, 0)
+	for _, quotes := range byAuthor {
+		clusters = append(clusters, clusterDuplicates(quotes)...)
+	}
+	slices.SortFunc(clusters, func(a, b models.DuplicateCluster) int {
+		return cmp.Compare(a.Canonical.ID, b.Canonical.ID)
+	})
+	return clusters, nil
+}
+
+// MergeDuplicates merges every cluster found by FindDuplicates into its
+// canonical quote, which keeps its creation time and gets the tags of the
+// whole cluster. The IDs of the merged quotes redirect to it. It returns the
+// clusters with their canonical quotes as merged; on failure, the clusters
+// merged so far. A cluster with a quote changed since it was found fails with
+// storage.ErrVersionMismatch.
+func (s *QuoteService) MergeDuplicates(changedBy string) ([]models.DuplicateCluster, error) {
+	const op = "services.quote.MergeDuplicates"
+
+	clusters, err := s.FindDuplicates()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	repo := s.repo.By(changedBy)
+	for i := range clusters {
+		survivor := clusters[i].Canonical
+		merged := make([]models.Quote, 0, len(clusters[i].Duplicates))
+		tags := slices.Clone(survivor.Tags)
+		for _, dup := range clusters[i].Duplicates {
+			merged = append(merged, dup.Quote)
+			tags = append(tags, dup.Quote.Tags...)
+		}
+		survivor.Tags = models.NormalizeTags(tags)
+
+		// The versions of all the quotes make the merge fail rather than
+		// undo or throw away a concurrent edit.
+		if err := repo.Merge(&survivor, merged); err != nil {
+			return clusters[:i], fmt.Errorf("%s: quote %d: %w", op, survivor.ID, err)
+		}
+		clusters[i].Canonical = survivor
+	}
+	return clusters, nil
+}
+
+// MergedInto returns the ID of the quote the quote with the given ID was
+// merged into.
+func (s *QuoteService) MergedInto(id int64) (int64, error) {
+	const op = "services.quote.MergedInto"
+
+	to, err := s.repo.Redirect(id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return to, nil
+}
+
+// clusterDuplicates clusters the quotes of one author. The oldest quote of a
+// cluster is its canonical one.
+func clusterDuplicates(quotes []models.Quote) []models.DuplicateCluster {
+	texts := make([]string, len(quotes))
+	for i, quote := range quotes {
+		texts[i] = models.NormalizeText(quote.Text)
+	}
+
+	// Clusters are kept as a union-find forest over the positions of the
+	// quotes.
+	parent := make([]int, len(quotes))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	// Exact duplicates are joined right away, so that only distinct texts
+	// are compared with each other.
+	first := make(map[string]int)
+	var distinct []int
+	for i, text := range texts {
+		if j, ok := first[text]; ok {
+			union(i, j)
+			continue
+		}
+		first[text] = i
+		distinct = append(distinct, i)
+	}
+	for a, i := range distinct {
+		for _, j := range distinct[a+1:] {
+			if find(i) != find(j) && models.NearDuplicate(texts[i], texts[j]) {
+				union(i, j)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range quotes {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+	var clusters []models.DuplicateCluster
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		canonical := slices.MinFunc(group, func(i, j int) int {
+			return cmp.Or(quotes[i].CreatedAt.Compare(quotes[j].CreatedAt), cmp.Compare(quotes[i].ID, quotes[j].ID))
+		})
+		cluster := models.DuplicateCluster{Canonical: quotes[canonical]}
+		for _, i := range group {
+			if i != canonical {
+				cluster.Duplicates = append(cluster.Duplicates, models.DuplicateOfQuote{
+					Quote:      quotes[i],
+					Similarity: models.Similarity(texts[canonical], texts[i]),
+				})
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
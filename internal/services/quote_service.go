@@ -1,56 +1,504 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
 	"quotes/internal/domain/models"
 	"quotes/internal/storage"
 )
 
+// QuoteRepository stores quotes. Every change is recorded, together with the
+// change itself, as a revision of each quote it touches.
 type QuoteRepository interface {
 	Create(quote *models.Quote) error
+	// CreateBatch creates all the quotes, assigning IDs in their order, or
+	// none of them if any is invalid.
+	CreateBatch(quotes []models.Quote) error
+	// CreateUnique creates the quotes like CreateBatch unless one of them
+	// repeats a live quote of its author, see models.FindDuplicate, failing
+	// then with a *storage.DuplicateError. The check and the creation happen
+	// at once, so concurrent creations cannot both pass it.
+	CreateUnique(quotes []models.Quote) error
 	GetAll() ([]models.Quote, error)
-	GetRandom() (*models.Quote, error)
+	// List returns the quotes matching the query in the page order, one page at a time.
+	List(query models.QuoteQuery, page models.Page) (models.QuotePage, error)
+	// Search returns at most limit quotes whose text has words starting with
+	// the words of the query, best matches first. Zero limit means no limit.
+	Search(query string, limit int) ([]models.ScoredQuote, error)
+	GetByID(id int64) (*models.Quote, error)
+	// Created returns the quotes created before the time in ID order,
+	// including the deleted and merged ones until they are purged.
+	Created(before time.Time) ([]models.CreatedQuote, error)
+	// GetRandom picks a random quote matching the query, favoring quotes by
+	// the weight.
+	GetRandom(query models.QuoteQuery, weight models.RandomWeight) (*models.Quote, error)
 	GetByAuthor(author string) ([]models.Quote, error)
-	Delete(id int64) error
+	// AuthorNames returns the distinct author names in sorted order.
+	AuthorNames() ([]string, error)
+	// Tags returns every tag with the number of quotes that have it, the most
+	// used tags first and then by name.
+	Tags() ([]models.TagCount, error)
+	// Update replaces the author and text of the quote. A non-zero
+	// quote.Version must match the stored version.
+	Update(quote *models.Quote) error
+	// UpdateBatch replaces all the quotes like Update or, if any of them is
+	// invalid, missing or stale, none.
+	UpdateBatch(quotes []models.Quote) error
+	// LinkAuthor sets the author ID of the quote without changing its
+	// version or recording a revision. It links quotes stored before they
+	// had authors.
+	LinkAuthor(id int64, authorID int64) error
+	// Revert replaces the quote like Update, recording the change as a
+	// revert to the revision with the number.
+	Revert(quote *models.Quote, number int64) error
+	// Delete moves the quote to the trash, leaving it out of everything but
+	// Trash. A non-zero version must match the stored version.
+	Delete(id int64, version int64) error
+	// Trash returns the deleted quotes, the most recently deleted first.
+	Trash() ([]models.Quote, error)
+	// Undelete brings the deleted quote back from the trash.
+	Undelete(id int64) (*models.Quote, error)
+	// Purge removes the quotes deleted before the time for good and returns
+	// their number.
+	Purge(before time.Time) (int, error)
+	// Merge replaces the survivor like Update and deletes the merged quotes,
+	// given by their IDs, redirecting the IDs to the survivor. A non-zero
+	// version of a merged quote must match the stored version as well.
+	// Either all of it happens or none.
+	Merge(survivor *models.Quote, merged []models.Quote) error
+	// Redirect returns the ID of the quote the quote with the given ID was
+	// merged into.
+	Redirect(id int64) (int64, error)
+	// Revisions returns the revisions of the quote, oldest first. Purged
+	// quotes keep their revisions.
+	Revisions(quoteID int64) ([]models.Revision, error)
+	// Revision returns the revision of the quote with the number.
+	Revision(quoteID int64, number int64) (*models.Revision, error)
+	// By returns the repository recording changedBy as the maker of its
+	// changes.
+	By(changedBy string) QuoteRepository
+}
+
+// DefaultTrashRetention is how long deleted quotes stay in the trash before
+// they are purged.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// maxAuthorSuggestions is the number of "did you mean" author names offered
+// when an author filter matches nothing.
+const maxAuthorSuggestions = 3
+
+// AuthorResolver links quotes to their canonical authors, see
+// AuthorService.ResolveAuthor and AuthorService.LookupAuthor.
+type AuthorResolver interface {
+	ResolveAuthor(quote *models.Quote) error
+	LookupAuthor(quote *models.Quote) error
+	// DeleteAuthor deletes the author unless it has quotes, failing then
+	// with storage.ErrAuthorHasQuotes.
+	DeleteAuthor(id int64) error
+}
+
+// QuoteOptions configures how QuoteService picks random quotes.
+type QuoteOptions struct {
+	// ShuffleTTL is how long an idle client keeps its shuffle bag. Zero means
+	// DefaultShuffleTTL.
+	ShuffleTTL time.Duration
+	// DailySeed determines the quotes of the day. Replicas must share it to
+	// show the same quotes.
+	DailySeed string
+	// DailyWindow is the number of days within which a quote of the day is
+	// not repeated. Zero means DefaultDailyWindow.
+	DailyWindow int
 }
 
 type QuoteService struct {
-	repo QuoteRepository
+	repo    QuoteRepository
+	authors AuthorResolver
+	bags    *ShuffleBags
+	daily   *DailyQuotes
 }
 
-func NewQuoteService(repo QuoteRepository) *QuoteService {
+func NewQuoteService(repo QuoteRepository, authors AuthorResolver, opts QuoteOptions) *QuoteService {
+	if opts.ShuffleTTL == 0 {
+		opts.ShuffleTTL = DefaultShuffleTTL
+	}
+	if opts.DailyWindow == 0 {
+		opts.DailyWindow = DefaultDailyWindow
+	}
 	return &QuoteService{
-		repo: repo,
+		repo:    repo,
+		authors: authors,
+		bags:    NewShuffleBags(repo, opts.ShuffleTTL),
+		daily:   NewDailyQuotes(repo, opts.DailySeed, opts.DailyWindow),
 	}
 }
 
-func (s *QuoteService) CreateQuote(quote *models.Quote) error {
+// CreateQuote creates the quote on behalf of changedBy. Unless allowDuplicate
+// is set, a quote that repeats a quote of its author exactly or nearly fails
+// with a *storage.DuplicateError.
+func (s *QuoteService) CreateQuote(quote *models.Quote, allowDuplicate bool, changedBy string) error {
 	const op = "services.quote.CreateQuote"
 
 	if quote == nil {
 		return fmt.Errorf("%s: %w", op, fmt.Errorf("quote cannot be nil"))
 	}
+	if quote.Rating < 0 || quote.Rating > models.MaxRating {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidRating)
+	}
+	if err := validateSource(quote); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// Duplicates are looked for before the author is resolved, so that a
+	// rejected quote does not create one.
+	known, found, err := s.lookupAuthor(*quote)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !allowDuplicate {
+		if checksDuplicates(known) {
+			dup, exact, err := newDuplicateFinder(s.repo).find(known)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			if dup != nil {
+				return fmt.Errorf("%s: %w", op, &storage.DuplicateError{ID: dup.id, Exact: exact})
+			}
+		}
+	}
+	if err := s.resolveAuthor(quote); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	if err := s.repo.Create(quote); err != nil {
+	repo := s.repo.By(changedBy)
+	if allowDuplicate {
+		if err := repo.Create(quote); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+	// The repository checks again, as another quote may have been created
+	// since. The author created for the quote is then deleted again.
+	created := []models.Quote{*quote}
+	if err := repo.CreateUnique(created); err != nil {
+		if !found {
+			err = errors.Join(err, s.deleteNewAuthors([]int64{quote.AuthorID}))
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	*quote = created[0]
 	return nil
 }
 
-func (s *QuoteService) GetAllQuotes() ([]models.Quote, error) {
-	const op = "services.quote.GetAllQuotes"
+// CreateQuotes validates the quotes by the rules of CreateQuote and creates
+// the valid ones. A quote may also duplicate an earlier quote of the batch. In
+// BatchAtomic mode a single invalid quote rejects the whole batch, and the
+// valid quotes fail with storage.ErrBatchRejected.
+func (s *QuoteService) CreateQuotes(quotes []models.Quote, mode models.BatchMode, allowDuplicate bool, changedBy string) ([]models.BatchResult, error) {
+	const op = "services.quote.CreateQuotes"
+
+	results := make([]models.BatchResult, len(quotes))
+	for i := range quotes {
+		quote := &quotes[i]
+		switch {
+		case quote.Rating < 0 || quote.Rating > models.MaxRating:
+			results[i].Err = storage.ErrInvalidRating
+		case quote.Text == "":
+			results[i].Err = storage.ErrEmptyText
+		default:
+			results[i].Err = validateSource(quote)
+		}
+	}
+
+	// Duplicates are looked for before the authors are resolved, so that
+	// neither rejected quotes nor a rejected batch create any.
+	known := make([]models.Quote, len(quotes))
+	found := make([]bool, len(quotes))
+	for i := range quotes {
+		if results[i].Err == nil {
+			known[i], found[i], results[i].Err = s.lookupAuthor(quotes[i])
+		}
+	}
+	var dupOf map[int]int
+	if !allowDuplicate {
+		var err error
+		if dupOf, err = s.findDuplicates(known, results, mode); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if rejectBatch(results, mode) {
+		return results, nil
+	}
+	resolved := make([]bool, len(quotes))
+	for i := range quotes {
+		if results[i].Err == nil {
+			results[i].Err = s.resolveAuthor(&quotes[i])
+			resolved[i] = results[i].Err == nil
+		}
+	}
+
+	err := s.createValid(s.repo.By(changedBy), quotes, results, mode, allowDuplicate, dupOf)
+
+	// The authors created for quotes that failed after all, having lost a
+	// race with another duplicate, are deleted again.
+	used := make(map[int64]bool)
+	var unused []int64
+	for i := range quotes {
+		if err == nil && results[i].Quote != nil {
+			used[quotes[i].AuthorID] = true
+		}
+	}
+	for i := range quotes {
+		if resolved[i] && !found[i] && !used[quotes[i].AuthorID] && !slices.Contains(unused, quotes[i].AuthorID) {
+			unused = append(unused, quotes[i].AuthorID)
+		}
+	}
+	err = errors.Join(err, s.deleteNewAuthors(unused))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return results, nil
+}
+
+// createValid creates the quotes that have not failed yet and fills in their
+// results. When another quote gets ahead of the repository's duplicate check,
+// the duplicates are looked for again.
+func (s *QuoteService) createValid(repo QuoteRepository, quotes []models.Quote, results []models.BatchResult, mode models.BatchMode, allowDuplicate bool, dupOf map[int]int) error {
+	for {
+		if rejectBatch(results, mode) {
+			return nil
+		}
+		valid := make([]models.Quote, 0, len(quotes))
+		for i, quote := range quotes {
+			if results[i].Err == nil {
+				valid = append(valid, quote)
+			}
+		}
+		if len(valid) == 0 {
+			return nil
+		}
+
+		var err error
+		if allowDuplicate {
+			err = repo.CreateBatch(valid)
+		} else {
+			err = repo.CreateUnique(valid)
+		}
+		if errors.Is(err, storage.ErrDuplicateQuote) {
+			for i := range results {
+				if errors.Is(results[i].Err, storage.ErrDuplicateQuote) {
+					results[i].Err = nil
+				}
+			}
+			if dupOf, err = s.findDuplicates(quotes, results, mode); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Quote = &valid[0]
+				valid = valid[1:]
+			}
+		}
+		for i, row := range dupOf {
+			var dup *storage.DuplicateError
+			if errors.As(results[i].Err, &dup) {
+				dup.ID = results[row].Quote.ID
+			}
+		}
+		return nil
+	}
+}
+
+// findDuplicates fails the quotes of the batch that are still valid and
+// repeat known quotes or earlier quotes of the batch, and maps the latter to
+// the earlier ones.
+func (s *QuoteService) findDuplicates(quotes []models.Quote, results []models.BatchResult, mode models.BatchMode) (map[int]int, error) {
+	dupOf := make(map[int]int)
+	if rejectBatch(results, mode) {
+		return dupOf, nil
+	}
+
+	finder := newDuplicateFinder(s.repo)
+	for i := range quotes {
+		if results[i].Err != nil || !checksDuplicates(quotes[i]) {
+			continue
+		}
+		known, exact, err := finder.find(quotes[i])
+		if err != nil {
+			return nil, err
+		}
+		if known == nil {
+			finder.add(quotes[i], i)
+			continue
+		}
+		results[i].Err = &storage.DuplicateError{ID: known.id, Exact: exact}
+		if known.row >= 0 {
+			dupOf[i] = known.row
+		}
+	}
+	return dupOf, nil
+}
+
+// rejectBatch tells whether the batch is rejected as a whole, failing its
+// valid quotes with storage.ErrBatchRejected if so.
+func rejectBatch(results []models.BatchResult, mode models.BatchMode) bool {
+	if mode != models.BatchAtomic || !slices.ContainsFunc(results, batchFailed) {
+		return false
+	}
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = storage.ErrBatchRejected
+		}
+	}
+	return true
+}
+
+func batchFailed(result models.BatchResult) bool {
+	return result.Err != nil
+}
+
+// checksDuplicates tells whether the quote is valid enough to look for its
+// duplicates. Invalid quotes are left for the repository to reject.
+func checksDuplicates(quote models.Quote) bool {
+	return quote.Text != "" && models.NormalizeAuthor(quote.Author) != ""
+}
+
+func (s *QuoteService) ListQuotes(query models.QuoteQuery, page models.Page) (models.QuotePage, error) {
+	const op = "services.quote.ListQuotes"
 
-	quotes, err := s.repo.GetAll()
+	query, err := s.authorQuery(query)
+	if err != nil {
+		return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	result, err := s.repo.List(query, page)
+	if err != nil {
+		return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(result.Quotes) == 0 && query.Author != "" && page.After == nil {
+		names, err := s.repo.AuthorNames()
+		if err != nil {
+			return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+		}
+		result.Suggestions = models.SuggestAuthors(query.Author, names, maxAuthorSuggestions)
+	}
+	return result, nil
+}
+
+// exportPageSize is the number of quotes ExportQuotes reads from the
+// repository at a time.
+const exportPageSize = 500
+
+// ExportQuotes calls fn for every quote matching the query in ID order. The
+// quotes are read page by page, so the whole collection is never held in
+// memory.
+func (s *QuoteService) ExportQuotes(query models.QuoteQuery, fn func(quote models.Quote) error) error {
+	const op = "services.quote.ExportQuotes"
+
+	query, err := s.authorQuery(query)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	page := models.Page{Limit: exportPageSize}
+	for {
+		result, err := s.repo.List(query, page)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		for _, quote := range result.Quotes {
+			if err := fn(quote); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		if result.Next == nil {
+			return nil
+		}
+		page.After = result.Next
+	}
+}
+
+func (s *QuoteService) SearchQuotes(query string, limit int) ([]models.ScoredQuote, error) {
+	const op = "services.quote.SearchQuotes"
+
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEmptySearchQuery)
+	}
+
+	quotes, err := s.repo.Search(query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return quotes, nil
 }
 
-func (s *QuoteService) GetRandomQuote() (*models.Quote, error) {
+func (s *QuoteService) ListTags() ([]models.TagCount, error) {
+	const op = "services.quote.ListTags"
+
+	tags, err := s.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tags, nil
+}
+
+func (s *QuoteService) GetQuoteByID(id int64) (*models.Quote, error) {
+	const op = "services.quote.GetQuoteByID"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	quote, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quote, nil
+}
+
+func (s *QuoteService) GetRandomQuote(query models.QuoteQuery, weight models.RandomWeight) (*models.Quote, error) {
 	const op = "services.quote.GetRandomQuote"
 
-	quote, err := s.repo.GetRandom()
+	query, err := s.authorQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	quote, err := s.repo.GetRandom(query, weight)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quote, nil
+}
+
+// GetShuffledQuote returns a random quote matching the query that the client
+// has not seen since it was last shown all of them, see ShuffleBags.
+func (s *QuoteService) GetShuffledQuote(client string, query models.QuoteQuery) (*models.Quote, error) {
+	const op = "services.quote.GetShuffledQuote"
+
+	query, err := s.authorQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	quote, err := s.bags.Next(client, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quote, nil
+}
+
+// GetDailyQuote returns the quote of the calendar day of the date.
+func (s *QuoteService) GetDailyQuote(date time.Time) (*models.Quote, error) {
+	const op = "services.quote.GetDailyQuote"
+
+	quote, err := s.daily.Get(date)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -64,22 +512,205 @@ func (s *QuoteService) GetQuotesByAuthor(author string) ([]models.Quote, error)
 		return nil, fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthor)
 	}
 
-	quotes, err := s.repo.GetByAuthor(author)
+	query, err := s.authorQuery(models.QuoteQuery{Author: author})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	quotes, err := s.repo.GetByAuthor(query.Author)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return quotes, nil
 }
 
-func (s *QuoteService) DeleteQuote(id int64) error {
+// authorQuery replaces an alias in the author filter of the query with the
+// author's name, which the quotes of the author are stored under. Other names
+// are left to match as they are.
+func (s *QuoteService) authorQuery(query models.QuoteQuery) (models.QuoteQuery, error) {
+	if models.NormalizeAuthor(query.Author) == "" {
+		return query, nil
+	}
+	quote := models.Quote{Author: query.Author}
+	err := s.authors.LookupAuthor(&quote)
+	switch {
+	case err == nil:
+		query.Author = quote.Author
+	case !errors.Is(err, storage.ErrAuthorNotFound):
+		return query, err
+	}
+	return query, nil
+}
+
+func (s *QuoteService) UpdateQuote(quote *models.Quote, changedBy string) error {
+	const op = "services.quote.UpdateQuote"
+
+	if err := s.prepareUpdate(quote); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.By(changedBy).Update(quote); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// prepareUpdate validates the replacement of a quote and links it to its
+// author.
+func (s *QuoteService) prepareUpdate(quote *models.Quote) error {
+	if quote == nil {
+		return fmt.Errorf("quote cannot be nil")
+	}
+	if quote.ID <= 0 {
+		return storage.ErrInvalidID
+	}
+	if quote.Rating < 0 || quote.Rating > models.MaxRating {
+		return storage.ErrInvalidRating
+	}
+	if err := validateSource(quote); err != nil {
+		return err
+	}
+	return s.resolveAuthor(quote)
+}
+
+func (s *QuoteService) DeleteQuote(id int64, version int64, changedBy string) error {
 	const op = "services.quote.DeleteQuote"
 
 	if id <= 0 {
 		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
 	}
 
-	if err := s.repo.Delete(id); err != nil {
+	if err := s.repo.By(changedBy).Delete(id, version); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
+
+// ListTrash returns the deleted quotes, the most recently deleted first.
+func (s *QuoteService) ListTrash() ([]models.Quote, error) {
+	const op = "services.quote.ListTrash"
+
+	quotes, err := s.repo.Trash()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quotes, nil
+}
+
+// RestoreQuote brings the deleted quote back from the trash. An author
+// renamed while the quote was in the trash gives it the new name.
+func (s *QuoteService) RestoreQuote(id int64, changedBy string) (*models.Quote, error) {
+	const op = "services.quote.RestoreQuote"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	repo := s.repo.By(changedBy)
+	quote, err := repo.Undelete(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if quote.AuthorID == 0 {
+		return quote, nil
+	}
+
+	linked := *quote
+	linked.Author = ""
+	if err := s.authors.ResolveAuthor(&linked); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if linked.Author != quote.Author {
+		if err := repo.Update(&linked); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		quote = &linked
+	}
+	return quote, nil
+}
+
+// PurgeTrash removes the quotes that have been in the trash for longer than
+// the retention for good and returns their number.
+func (s *QuoteService) PurgeTrash(retention time.Duration) (int, error) {
+	const op = "services.quote.PurgeTrash"
+
+	purged, err := s.repo.Purge(time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return purged, nil
+}
+
+// lookupAuthor returns a copy of the quote linked to its existing author, or
+// the quote as is if its author is yet to be created, to look for its
+// duplicates with, and whether the author exists. Invalid quotes are returned
+// as is.
+func (s *QuoteService) lookupAuthor(quote models.Quote) (models.Quote, bool, error) {
+	if quote.Text == "" {
+		return quote, true, nil
+	}
+	err := s.authors.LookupAuthor(&quote)
+	if errors.Is(err, storage.ErrAuthorNotFound) && models.NormalizeAuthor(quote.Author) != "" {
+		return quote, false, nil
+	}
+	return quote, err == nil, err
+}
+
+// deleteNewAuthors deletes the authors created for quotes that were not
+// created after all. An author someone has given a quote to meanwhile is
+// kept.
+func (s *QuoteService) deleteNewAuthors(ids []int64) error {
+	var errs []error
+	for _, id := range ids {
+		if err := s.authors.DeleteAuthor(id); err != nil && !errors.Is(err, storage.ErrAuthorHasQuotes) {
+			errs = append(errs, fmt.Errorf("delete new author %d: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveAuthor links a valid quote to its author. Invalid quotes are left
+// for the repository to reject, so that no author is created for them.
+func (s *QuoteService) resolveAuthor(quote *models.Quote) error {
+	if quote.Text == "" {
+		return nil
+	}
+	return s.authors.ResolveAuthor(quote)
+}
+
+// validateSource cleans up the source of the quote and checks it together
+// with the verification status, which defaults to unverified. Only a quote
+// with a source can be verified.
+func validateSource(quote *models.Quote) error {
+	quote.Verification = quote.Verification.Or(models.VerificationUnverified)
+	if !quote.Verification.Valid() {
+		return fmt.Errorf("%w: %q", storage.ErrInvalidStatus, quote.Verification)
+	}
+
+	source := quote.Source
+	if source == nil {
+		if quote.Verification == models.VerificationVerified {
+			return storage.ErrSourceRequired
+		}
+		return nil
+	}
+
+	source.Title = strings.TrimSpace(source.Title)
+	source.Page = strings.TrimSpace(source.Page)
+	source.URL = strings.TrimSpace(source.URL)
+	if source.Kind != "" && !source.Kind.Valid() {
+		return fmt.Errorf("%w: unknown kind %q", storage.ErrInvalidSource, source.Kind)
+	}
+	if source.Title == "" && source.URL == "" {
+		return fmt.Errorf("%w: title or url is required", storage.ErrInvalidSource)
+	}
+	if source.URL != "" {
+		u, err := url.Parse(source.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute http or https URL", storage.ErrInvalidSource)
+		}
+	}
+	if source.Year > time.Now().Year() {
+		return fmt.Errorf("%w: year %d is in the future", storage.ErrInvalidSource, source.Year)
+	}
+	return nil
+}
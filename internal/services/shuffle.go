@@ -0,0 +1,168 @@
+package services
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+)
+
+// DefaultShuffleTTL is how long an idle client keeps its shuffle bag.
+const DefaultShuffleTTL = 24 * time.Hour
+
+// maxShuffleBags limits the memory taken by bags of clients that never come
+// back before their bags expire.
+const maxShuffleBags = 10000
+
+// ShuffleBags hands out quotes to each client in a random permutation of all
+// matching quotes, so that no quote is repeated until every other one has been
+// shown. Quotes added during a cycle join it at a random position, deleted and
+// no longer matching ones are skipped.
+type ShuffleBags struct {
+	repo QuoteRepository
+	ttl  time.Duration
+
+	mu   sync.Mutex
+	bags map[string]*list.Element
+	// lru holds the bags, the most recently used first. All bags live for
+	// the same TTL, so they expire from the back.
+	lru *list.List
+}
+
+type shuffleBag struct {
+	key string
+
+	mu sync.Mutex
+	// remaining are the IDs left in the cycle, drawn from the end.
+	remaining []int64
+	// maxID is the highest ID put into the cycle. Quotes with higher IDs
+	// were added since then.
+	maxID   int64
+	last    int64
+	expires time.Time
+}
+
+func NewShuffleBags(repo QuoteRepository, ttl time.Duration) *ShuffleBags {
+	return &ShuffleBags{
+		repo: repo,
+		ttl:  ttl,
+		bags: make(map[string]*list.Element),
+		lru:  list.New(),
+	}
+}
+
+// Next returns the next quote matching the query from the client's bag.
+func (b *ShuffleBags) Next(client string, query models.QuoteQuery) (*models.Quote, error) {
+	const op = "services.shuffle.Next"
+
+	bag := b.bag(client + "\x00" + fmt.Sprint(query))
+	bag.mu.Lock()
+	defer bag.mu.Unlock()
+
+	refilled := false
+	for {
+		if err := b.addNew(bag, query); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if len(bag.remaining) == 0 {
+			// A bag that is empty right after a refill has nothing to cycle through.
+			if refilled {
+				return nil, fmt.Errorf("%s: %w", op, storage.ErrNoQuotesAvailable)
+			}
+			bag.maxID = 0
+			refilled = true
+			continue
+		}
+		if refilled {
+			bag.avoidRepeat()
+			refilled = false
+		}
+
+		id := bag.remaining[len(bag.remaining)-1]
+		bag.remaining = bag.remaining[:len(bag.remaining)-1]
+
+		quote, err := b.repo.GetByID(id)
+		if errors.Is(err, storage.ErrQuoteNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !query.Match(*quote) {
+			continue
+		}
+		bag.last = id
+		return quote, nil
+	}
+}
+
+// bag returns the bag stored under the key, creating it if needed, and
+// extends its life.
+func (b *ShuffleBags) bag(key string) *shuffleBag {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for e := b.lru.Back(); e != nil && now.After(e.Value.(*shuffleBag).expires); e = b.lru.Back() {
+		b.evict(e)
+	}
+
+	e, ok := b.bags[key]
+	if ok {
+		b.lru.MoveToFront(e)
+	} else {
+		if len(b.bags) >= maxShuffleBags {
+			b.evict(b.lru.Back())
+		}
+		e = b.lru.PushFront(&shuffleBag{key: key})
+		b.bags[key] = e
+	}
+	bag := e.Value.(*shuffleBag)
+	bag.expires = now.Add(b.ttl)
+	return bag
+}
+
+// evict drops the bag of the element.
+func (b *ShuffleBags) evict(e *list.Element) {
+	b.lru.Remove(e)
+	delete(b.bags, e.Value.(*shuffleBag).key)
+}
+
+// addNew puts the matching quotes created after the bag was last filled at
+// random positions among the remaining ones.
+func (b *ShuffleBags) addNew(bag *shuffleBag, query models.QuoteQuery) error {
+	page := models.Page{}
+	if bag.maxID > 0 {
+		page.After = &models.Cursor{ID: bag.maxID}
+	}
+	result, err := b.repo.List(query, page)
+	if err != nil {
+		return err
+	}
+
+	for _, quote := range result.Quotes {
+		bag.remaining = append(bag.remaining, quote.ID)
+		// One step of the Fisher-Yates shuffle keeps the bag a uniformly
+		// random permutation.
+		i := len(bag.remaining) - 1
+		j := rand.IntN(i + 1)
+		bag.remaining[i], bag.remaining[j] = bag.remaining[j], bag.remaining[i]
+		bag.maxID = max(bag.maxID, quote.ID)
+	}
+	return nil
+}
+
+// avoidRepeat keeps a new cycle from starting with the quote that ended the
+// previous one.
+func (bag *shuffleBag) avoidRepeat() {
+	n := len(bag.remaining)
+	if n > 1 && bag.remaining[n-1] == bag.last {
+		j := rand.IntN(n - 1)
+		bag.remaining[n-1], bag.remaining[j] = bag.remaining[j], bag.remaining[n-1]
+	}
+}
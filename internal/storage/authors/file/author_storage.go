@@ -0,0 +1,321 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage/authors/memory"
+	"quotes/internal/storage/filelock"
+	"quotes/internal/storage/fsutil"
+)
+
+var _ services.AuthorRepository = (*AuthorStorage)(nil)
+
+const (
+	fileName = "authors.json"
+	logName  = "authors.log"
+	// lockFileName is locked while the storage is open, so that no other
+	// process writes the authors files at the same time.
+	lockFileName = "authors.lock"
+	// compactMin is the number of logged changes below which the log is not
+	// folded into the authors file while the storage is open.
+	compactMin = 1024
+)
+
+type snapshot struct {
+	NextID  int64           `json:"next_id"`
+	Authors []models.Author `json:"authors"`
+}
+
+// record is a line of the authors log: either a created or updated author or
+// the ID of a deleted one.
+type record struct {
+	Author  *models.Author `json:"author,omitempty"`
+	Deleted int64          `json:"deleted,omitempty"`
+}
+
+// AuthorStorage keeps authors in memory. The authors file holds all of them
+// as of the last compaction, and every later change is appended to the
+// authors log as a line of JSON, synced before the change returns. The log is
+// folded into the authors file on open and once it outgrows the authors.
+type AuthorStorage struct {
+	mem     *memory.AuthorStorage
+	mu      sync.Mutex
+	dir     string
+	path    string
+	log     *os.File
+	size    int64
+	records int
+	// failed is set when a partial line could not be cut off the log.
+	failed error
+	lock   *os.File
+}
+
+func NewAuthorStorage(dir string) (*AuthorStorage, error) {
+	const op = "storage.authors.file.NewAuthorStorage"
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lock, err := filelock.Lock(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s := &AuthorStorage{
+		mem:  memory.NewAuthorStorage(),
+		dir:  dir,
+		path: filepath.Join(dir, fileName),
+		lock: lock,
+	}
+	if err := s.load(); err != nil {
+		if s.log != nil {
+			s.log.Close()
+		}
+		lock.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s, nil
+}
+
+func (s *AuthorStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.log.Close()
+	if lockErr := s.lock.Close(); err == nil {
+		err = lockErr
+	}
+	return err
+}
+
+func (s *AuthorStorage) Create(author *models.Author) error {
+	const op = "storage.authors.file.Create"
+
+	return s.change(op, 0, func() (record, error) {
+		err := s.mem.Create(author)
+		return record{Author: author}, err
+	})
+}
+
+func (s *AuthorStorage) GetAll() ([]models.Author, error) {
+	return s.mem.GetAll()
+}
+
+func (s *AuthorStorage) GetByID(id int64) (*models.Author, error) {
+	return s.mem.GetByID(id)
+}
+
+func (s *AuthorStorage) FindByName(name string) (*models.Author, error) {
+	return s.mem.FindByName(name)
+}
+
+func (s *AuthorStorage) Update(author *models.Author) error {
+	const op = "storage.authors.file.Update"
+
+	return s.change(op, author.ID, func() (record, error) {
+		err := s.mem.Update(author)
+		return record{Author: author}, err
+	})
+}
+
+func (s *AuthorStorage) Delete(id int64) error {
+	const op = "storage.authors.file.Delete"
+
+	return s.change(op, id, func() (record, error) {
+		err := s.mem.Delete(id)
+		return record{Deleted: id}, err
+	})
+}
+
+// change applies fn to the in-memory authors and logs the change it returns,
+// undoing it if it cannot be logged. id is the author being changed, or 0 for
+// a new one.
+func (s *AuthorStorage) change(op string, id int64, fn func() (record, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	var old *models.Author
+	if id > 0 {
+		old, _ = s.mem.GetByID(id)
+	}
+	rec, err := fn()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.append(rec); err != nil {
+		if old != nil {
+			s.mem.Put(*old)
+		} else {
+			s.mem.Remove(rec.Author.ID)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if s.records >= compactMin && s.records >= s.mem.Len() {
+		// The change is already durable, so a failed compaction only leaves
+		// the log longer than it needs to be.
+		if err := s.compact(); err != nil {
+			log.Printf("%s: compact authors: %v", op, err)
+		}
+	}
+	return nil
+}
+
+func (s *AuthorStorage) append(rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode author: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := s.log.WriteAt(line, s.size); err != nil {
+		s.discardTail()
+		return fmt.Errorf("write authors log: %w", err)
+	}
+	if err := s.log.Sync(); err != nil {
+		s.discardTail()
+		return fmt.Errorf("sync authors log: %w", err)
+	}
+	s.size += int64(len(line))
+	s.records++
+	return nil
+}
+
+// discardTail cuts off a partially written line. If that fails the log can no
+// longer be appended to safely.
+func (s *AuthorStorage) discardTail() {
+	if err := s.log.Truncate(s.size); err != nil {
+		s.failed = fmt.Errorf("authors log is left with a partial line: %w", err)
+		return
+	}
+	if err := s.log.Sync(); err != nil {
+		s.failed = fmt.Errorf("authors log is left with a partial line: %w", err)
+	}
+}
+
+// load reads the authors file, replays the log over it and folds the log
+// into the file. A line without its newline at the end of the log, left by a
+// crash in the middle of a write, is cut off.
+func (s *AuthorStorage) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		var snap snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return fmt.Errorf("decode %s: %w", s.path, err)
+		}
+		s.mem.Restore(snap.Authors, snap.NextID)
+	}
+
+	s.log, err = os.OpenFile(filepath.Join(s.dir, logName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open authors log: %w", err)
+	}
+	// The log may have just been created.
+	if err := fsutil.SyncDir(s.dir); err != nil {
+		return err
+	}
+	r := bufio.NewReader(s.log)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				log.Printf("storage.authors.file: truncating torn line at offset %d in %s", s.size, s.log.Name())
+				if err := s.log.Truncate(s.size); err != nil {
+					return fmt.Errorf("truncate authors log: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read authors log: %w", err)
+		}
+
+		var rec record
+		if err := json.Unmarshal(bytes.TrimSpace(line), &rec); err != nil {
+			return fmt.Errorf("decode author at offset %d in %s: %w", s.size, s.log.Name(), err)
+		}
+		if rec.Author != nil {
+			s.mem.Put(*rec.Author)
+		} else {
+			s.mem.Remove(rec.Deleted)
+		}
+		s.size += int64(len(line))
+		s.records++
+	}
+
+	if s.size > 0 {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact writes all authors to the authors file and empties the log.
+// Replaying the log over the new file gives the same authors, so a crash
+// between the two steps loses nothing.
+func (s *AuthorStorage) compact() error {
+	if err := s.save(); err != nil {
+		return err
+	}
+	if err := s.log.Truncate(0); err != nil {
+		return fmt.Errorf("truncate authors log: %w", err)
+	}
+	s.size, s.records = 0, 0
+	if err := s.log.Sync(); err != nil {
+		return fmt.Errorf("sync authors log: %w", err)
+	}
+	return nil
+}
+
+// save atomically replaces the authors file: the data is written to a
+// temporary file, synced and renamed over it.
+func (s *AuthorStorage) save() error {
+	authors, nextID := s.mem.Snapshot()
+	data, err := json.Marshal(snapshot{NextID: nextID, Authors: authors})
+	if err != nil {
+		return fmt.Errorf("encode authors: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create authors file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("write authors file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("sync authors file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("close authors file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename authors file: %w", err)
+	}
+	return fsutil.SyncDir(s.dir)
+}
@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+)
+
+var _ services.AuthorRepository = (*AuthorStorage)(nil)
+
+type AuthorStorage struct {
+	authors map[int64]models.Author
+	// byName maps normalized names and aliases to author IDs.
+	byName map[string]int64
+	mu     sync.RWMutex
+	nextID int64
+}
+
+func NewAuthorStorage() *AuthorStorage {
+	return &AuthorStorage{
+		authors: make(map[int64]models.Author),
+		byName:  make(map[string]int64),
+		nextID:  1,
+	}
+}
+
+func (s *AuthorStorage) Create(author *models.Author) error {
+	const op = "storage.authors.memory.Create"
+
+	author.Clean()
+	if author.Name == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthorName)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.taken(author.Names(), 0) {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorExists)
+	}
+
+	author.ID = s.nextID
+	author.CreatedAt = time.Now()
+	author.UpdatedAt = time.Time{}
+	s.put(*author)
+	s.nextID++
+	return nil
+}
+
+func (s *AuthorStorage) GetAll() ([]models.Author, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authors := make([]models.Author, 0, len(s.authors))
+	for _, id := range slices.Sorted(maps.Keys(s.authors)) {
+		authors = append(authors, s.authors[id])
+	}
+	return authors, nil
+}
+
+func (s *AuthorStorage) GetByID(id int64) (*models.Author, error) {
+	const op = "storage.authors.memory.GetByID"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	author, ok := s.authors[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrAuthorNotFound)
+	}
+	return &author, nil
+}
+
+func (s *AuthorStorage) FindByName(name string) (*models.Author, error) {
+	const op = "storage.authors.memory.FindByName"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byName[models.NormalizeAuthor(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrAuthorNotFound)
+	}
+	author := s.authors[id]
+	return &author, nil
+}
+
+func (s *AuthorStorage) Update(author *models.Author) error {
+	const op = "storage.authors.memory.Update"
+
+	if author.ID <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+	author.Clean()
+	if author.Name == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthorName)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.authors[author.ID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorNotFound)
+	}
+	if s.taken(author.Names(), author.ID) {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorExists)
+	}
+
+	author.CreatedAt = current.CreatedAt
+	author.UpdatedAt = time.Now()
+	s.remove(current)
+	s.put(*author)
+	return nil
+}
+
+func (s *AuthorStorage) Delete(id int64) error {
+	const op = "storage.authors.memory.Delete"
+
+	if id <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	author, ok := s.authors[id]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorNotFound)
+	}
+	s.remove(author)
+	return nil
+}
+
+// Snapshot returns a copy of all authors together with the next ID to be assigned.
+func (s *AuthorStorage) Snapshot() ([]models.Author, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authors := make([]models.Author, 0, len(s.authors))
+	for _, id := range slices.Sorted(maps.Keys(s.authors)) {
+		authors = append(authors, s.authors[id])
+	}
+	return authors, s.nextID
+}
+
+// Restore replaces the stored authors with the ones taken by Snapshot.
+func (s *AuthorStorage) Restore(authors []models.Author, nextID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authors = make(map[int64]models.Author, len(authors))
+	s.byName = make(map[string]int64, len(authors))
+	s.nextID = nextID
+	for _, author := range authors {
+		s.put(author)
+		if author.ID >= s.nextID {
+			s.nextID = author.ID + 1
+		}
+	}
+}
+
+// Put stores the author as is, replacing the one with the same ID. It is used
+// to replay saved changes and to undo the ones that could not be saved.
+func (s *AuthorStorage) Put(author models.Author) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.authors[author.ID]; ok {
+		s.remove(current)
+	}
+	s.put(author)
+	if author.ID >= s.nextID {
+		s.nextID = author.ID + 1
+	}
+}
+
+// Remove deletes the author if it is stored.
+func (s *AuthorStorage) Remove(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if author, ok := s.authors[id]; ok {
+		s.remove(author)
+	}
+}
+
+// Len returns the number of stored authors.
+func (s *AuthorStorage) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.authors)
+}
+
+// taken reports whether any of the names belongs to an author other than id.
+func (s *AuthorStorage) taken(names []string, id int64) bool {
+	for _, name := range names {
+		if owner, ok := s.byName[models.NormalizeAuthor(name)]; ok && owner != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AuthorStorage) put(author models.Author) {
+	s.authors[author.ID] = author
+	for _, name := range author.Names() {
+		s.byName[models.NormalizeAuthor(name)] = author.ID
+	}
+}
+
+func (s *AuthorStorage) remove(author models.Author) {
+	delete(s.authors, author.ID)
+	for _, name := range author.Names() {
+		delete(s.byName, models.NormalizeAuthor(name))
+	}
+}
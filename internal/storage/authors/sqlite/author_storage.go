@@ -0,0 +1,292 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+)
+
+var _ services.AuthorRepository = (*AuthorStorage)(nil)
+
+const authorColumns = "id, name, bio, birth_year, death_year, created_at, updated_at"
+
+// AuthorStorage keeps authors in the database opened by the quotes SQLite
+// storage. Names and aliases live in the author_names table, whose primary key
+// makes every name belong to a single author.
+type AuthorStorage struct {
+	db *sql.DB
+}
+
+func NewAuthorStorage(db *sql.DB) *AuthorStorage {
+	return &AuthorStorage{
+		db: db,
+	}
+}
+
+func (s *AuthorStorage) Create(author *models.Author) error {
+	const op = "storage.authors.sqlite.Create"
+
+	author.Clean()
+	if author.Name == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthorName)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := checkNames(tx, author.Names(), 0); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	createdAt := time.Now().UTC()
+	res, err := tx.Exec(
+		"INSERT INTO authors (name, bio, birth_year, death_year, created_at) VALUES (?, ?, ?, ?, ?)",
+		author.Name, author.Bio, author.BirthYear, author.DeathYear, createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertNames(tx, id, author); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	author.ID = id
+	author.CreatedAt = createdAt
+	author.UpdatedAt = time.Time{}
+	return nil
+}
+
+func (s *AuthorStorage) GetAll() ([]models.Author, error) {
+	const op = "storage.authors.sqlite.GetAll"
+
+	rows, err := s.db.Query("SELECT " + authorColumns + " FROM authors ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	authors := make([]models.Author, 0)
+	for rows.Next() {
+		author, err := scanAuthor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		authors = append(authors, author)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	aliases, err := s.aliases("")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for i := range authors {
+		authors[i].Aliases = aliases[authors[i].ID]
+	}
+	return authors, nil
+}
+
+func (s *AuthorStorage) GetByID(id int64) (*models.Author, error) {
+	const op = "storage.authors.sqlite.GetByID"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	author, err := s.get("SELECT "+authorColumns+" FROM authors WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return author, nil
+}
+
+func (s *AuthorStorage) FindByName(name string) (*models.Author, error) {
+	const op = "storage.authors.sqlite.FindByName"
+
+	author, err := s.get(
+		"SELECT "+authorColumns+" FROM authors WHERE id = (SELECT author_id FROM author_names WHERE key = ?)",
+		models.NormalizeAuthor(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return author, nil
+}
+
+func (s *AuthorStorage) Update(author *models.Author) error {
+	const op = "storage.authors.sqlite.Update"
+
+	if author.ID <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+	author.Clean()
+	if author.Name == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthorName)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	updatedAt := time.Now().UTC()
+	var createdAt time.Time
+	err = tx.QueryRow(
+		`UPDATE authors SET name = ?, bio = ?, birth_year = ?, death_year = ?, updated_at = ?
+		WHERE id = ?
+		RETURNING created_at`,
+		author.Name, author.Bio, author.BirthYear, author.DeathYear, updatedAt, author.ID,
+	).Scan(&createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := checkNames(tx, author.Names(), author.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.Exec("DELETE FROM author_names WHERE author_id = ?", author.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertNames(tx, author.ID, author); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	author.CreatedAt = createdAt
+	author.UpdatedAt = updatedAt
+	return nil
+}
+
+func (s *AuthorStorage) Delete(id int64) error {
+	const op = "storage.authors.sqlite.Delete"
+
+	if id <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	// The names go with the author by ON DELETE CASCADE.
+	res, err := s.db.Exec("DELETE FROM authors WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAuthorNotFound)
+	}
+	return nil
+}
+
+// get returns the single author selected by the query, with its aliases.
+func (s *AuthorStorage) get(query string, args ...any) (*models.Author, error) {
+	author, err := scanAuthor(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrAuthorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.aliases("AND author_id = ?", author.ID)
+	if err != nil {
+		return nil, err
+	}
+	author.Aliases = aliases[author.ID]
+	return &author, nil
+}
+
+// aliases returns the aliases matching the extra condition by author ID, in
+// the order they were given.
+func (s *AuthorStorage) aliases(cond string, args ...any) (map[int64][]string, error) {
+	rows, err := s.db.Query("SELECT author_id, name FROM author_names WHERE alias "+cond+" ORDER BY rowid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[int64][]string)
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		aliases[id] = append(aliases[id], name)
+	}
+	return aliases, rows.Err()
+}
+
+// checkNames returns storage.ErrAuthorExists if any of the names belongs to
+// an author other than id.
+func checkNames(tx *sql.Tx, names []string, id int64) error {
+	keys := make([]any, 0, len(names)+1)
+	for _, name := range names {
+		keys = append(keys, models.NormalizeAuthor(name))
+	}
+	keys = append(keys, id)
+
+	var taken bool
+	err := tx.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM author_names WHERE key IN (?"+strings.Repeat(", ?", len(names)-1)+") AND author_id != ?)",
+		keys...,
+	).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return storage.ErrAuthorExists
+	}
+	return nil
+}
+
+func insertNames(tx *sql.Tx, id int64, author *models.Author) error {
+	for i, name := range author.Names() {
+		_, err := tx.Exec(
+			"INSERT INTO author_names (key, author_id, name, alias) VALUES (?, ?, ?, ?)",
+			models.NormalizeAuthor(name), id, name, i > 0,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAuthor(row scanner) (models.Author, error) {
+	var author models.Author
+	var updatedAt sql.NullTime
+	if err := row.Scan(&author.ID, &author.Name, &author.Bio, &author.BirthYear, &author.DeathYear, &author.CreatedAt, &updatedAt); err != nil {
+		return author, err
+	}
+	author.UpdatedAt = updatedAt.Time
+	return author, nil
+}
@@ -0,0 +1,31 @@
+// Package filelock keeps a file storage from being opened by two processes at
+// once, such as the server and a command working with the same data
+// directory.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrLocked is returned by Lock when another process holds the lock.
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lock opens the file at path for reading and writing, creating it if needed,
+// and takes an exclusive lock on it without waiting. The lock is held until
+// the file is closed or the process exits.
+func Lock(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock(f); err != nil {
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,21 @@
+// Package fsutil holds the file system helpers shared by the file storages.
+package fsutil
+
+import (
+	"fmt"
+	"os"
+)
+
+// SyncDir makes the creation or removal of files in dir durable.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open dir: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package file
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"quotes/internal/domain/models"
+)
+
+const (
+	opCreate      = "create"
+	opCreateBatch = "create_batch"
+	opUpdate      = "update"
+	opUpdateBatch = "update_batch"
+	// opDelete removes a quote for good. Logs written before the trash
+	// existed use it for every deletion.
+	opDelete  = "delete"
+	opMerge   = "merge"
+	opTrash   = "trash"
+	opRestore = "restore"
+	opPurge   = "purge"
+)
+
+// headerSize is the size of a frame header: payload length and CRC-32C of the payload.
+const headerSize = 8
+
+// maxFrameSize protects replay from allocating huge buffers for a corrupted length.
+const maxFrameSize = 1 << 30
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	errTornFrame    = errors.New("torn frame")
+	errCorruptFrame = errors.New("corrupt frame")
+)
+
+type record struct {
+	Op     string         `json:"op"`
+	ID     int64          `json:"id,omitempty"`
+	Quote  *models.Quote  `json:"quote,omitempty"`
+	Quotes []models.Quote `json:"quotes,omitempty"`
+	// IDs are the quotes merged into Quote or purged.
+	IDs []int64 `json:"ids,omitempty"`
+	// Revisions are the revisions made by the change.
+	Revisions []models.Revision `json:"revisions,omitempty"`
+}
+
+type snapshot struct {
+	NextID    int64             `json:"next_id"`
+	Quotes    []models.Quote    `json:"quotes"`
+	Redirects map[int64]int64   `json:"redirects,omitempty"`
+	Revisions []models.Revision `json:"revisions,omitempty"`
+}
+
+// encodeFrame marshals v to JSON and prepends the frame header.
+func encodeFrame(v any) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[headerSize:], payload)
+	return buf, nil
+}
+
+// readFrame reads the next frame into v. It returns io.EOF at a clean end of the
+// file, errTornFrame if the file ends in the middle of the frame and
+// errCorruptFrame if the frame has an invalid length, does not match its
+// checksum or cannot be decoded.
+func readFrame(r *bufio.Reader, v any) (int64, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, io.EOF
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, errTornFrame
+		}
+		return 0, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[0:4])
+	if size == 0 || size > maxFrameSize {
+		return 0, errCorruptFrame
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, errTornFrame
+		}
+		return 0, err
+	}
+
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return 0, errCorruptFrame
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return 0, fmt.Errorf("%w: %v", errCorruptFrame, err)
+	}
+	return int64(headerSize + len(payload)), nil
+}
+
+// restIsZero reports whether nothing but zero bytes is left in r. A crash can
+// leave the end of a file zero-filled when its size was updated before the
+// data reached the disk.
+func restIsZero(r *bufio.Reader) (bool, error) {
+	for {
+		b, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if b != 0 {
+			return false, nil
+		}
+	}
+}
+
+// writeSnapshot atomically replaces path with the snapshot: the data is written
+// to a temporary file, synced and renamed over path.
+func writeSnapshot(path string, snap snapshot) error {
+	buf, err := encodeFrame(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmp := path + tmpSuffix
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+	return nil
+}
+
+func readSnapshot(path string) (snapshot, error) {
+	var snap snapshot
+
+	f, err := os.Open(path)
+	if err != nil {
+		return snap, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if _, err := readFrame(r, &snap); err != nil {
+		if errors.Is(err, io.EOF) {
+			return snap, errTornFrame
+		}
+		return snap, err
+	}
+	if _, err := r.Peek(1); !errors.Is(err, io.EOF) {
+		return snap, errTornFrame
+	}
+	return snap, nil
+}
@@ -0,0 +1,886 @@
+package file
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+	"quotes/internal/storage/filelock"
+	"quotes/internal/storage/fsutil"
+	"quotes/internal/storage/quotes/memory"
+)
+
+var _ services.QuoteRepository = (*QuoteStorage)(nil)
+
+const (
+	logPrefix      = "wal-"
+	logSuffix      = ".log"
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".snap"
+	tmpSuffix      = ".tmp"
+	// legacyLogName is the single log written before snapshots were added.
+	// Its records are framed the same way as in the numbered logs.
+	legacyLogName = "quotes.log"
+	// lockFileName is locked while the storage is open, so that no other
+	// process writes to the log at the same time.
+	lockFileName = "quotes.lock"
+)
+
+type Options struct {
+	// SnapshotInterval is how often a snapshot is taken if the log is not empty.
+	// Zero disables periodic snapshots.
+	SnapshotInterval time.Duration
+	// SnapshotThreshold is the number of log records after which a snapshot is
+	// taken right away. Zero disables the threshold.
+	SnapshotThreshold int
+}
+
+// QuoteStorage keeps quotes in memory and persists every change, together
+// with the revisions it made, to an append-only log. The log is compacted by
+// periodic snapshots: snapshot N holds the full state at the moment it was
+// taken and log N holds the changes made after it. On startup the latest
+// valid snapshot is loaded and only the logs that follow it are replayed. The
+// storages returned by By share the state.
+type QuoteStorage struct {
+	*state
+	// changedBy is recorded as the maker of the changes.
+	changedBy string
+}
+
+type state struct {
+	mem  *memory.QuoteStorage
+	opts Options
+
+	mu      sync.Mutex
+	dir     string
+	lock    *os.File
+	gen     uint64
+	log     *os.File
+	size    int64
+	records int
+	failed  error
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewQuoteStorage(dir string, opts Options) (*QuoteStorage, error) {
+	const op = "storage.quotes.file.NewQuoteStorage"
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lock, err := filelock.Lock(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s := &QuoteStorage{state: &state{
+		mem:  memory.NewQuoteStorage(),
+		opts: opts,
+		dir:  dir,
+		lock: lock,
+		done: make(chan struct{}),
+	}}
+	if err := s.load(); err != nil {
+		if s.log != nil {
+			s.log.Close()
+		}
+		lock.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if opts.SnapshotInterval > 0 {
+		s.wg.Add(1)
+		go s.snapshotLoop()
+	}
+	return s, nil
+}
+
+// By returns a storage sharing the state of s that records changedBy as the
+// maker of its changes.
+func (s *QuoteStorage) By(changedBy string) services.QuoteRepository {
+	return &QuoteStorage{state: s.state, changedBy: changedBy}
+}
+
+func (s *QuoteStorage) Close() error {
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.log.Close()
+	if lockErr := s.lock.Close(); err == nil {
+		err = lockErr
+	}
+	return err
+}
+
+func (s *QuoteStorage) Create(quote *models.Quote) error {
+	const op = "storage.quotes.file.Create"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	if err := s.mem.By(s.changedBy).Create(quote); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	saved := *quote
+	ids := []int64{quote.ID}
+	if err := s.append(record{Op: opCreate, Quote: &saved, Revisions: s.mem.LastRevisions(ids)}); err != nil {
+		s.mem.Remove(quote.ID)
+		s.mem.RemoveLastRevisions(ids)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CreateBatch creates all the quotes or, if any of them is invalid, none. The
+// batch is logged as a single record, so it is never restored in part.
+func (s *QuoteStorage) CreateBatch(quotes []models.Quote) error {
+	const op = "storage.quotes.file.CreateBatch"
+
+	return s.createBatch(op, quotes, func(repo services.QuoteRepository) error {
+		return repo.CreateBatch(quotes)
+	})
+}
+
+// CreateUnique creates the quotes like CreateBatch unless any of them repeats
+// a live quote of its author.
+func (s *QuoteStorage) CreateUnique(quotes []models.Quote) error {
+	const op = "storage.quotes.file.CreateUnique"
+
+	return s.createBatch(op, quotes, func(repo services.QuoteRepository) error {
+		return repo.CreateUnique(quotes)
+	})
+}
+
+// createBatch creates the quotes in memory with fn and logs them as a single
+// record.
+func (s *QuoteStorage) createBatch(op string, quotes []models.Quote, fn func(repo services.QuoteRepository) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	if err := fn(s.mem.By(s.changedBy)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids := make([]int64, 0, len(quotes))
+	for _, quote := range quotes {
+		ids = append(ids, quote.ID)
+	}
+	rec := record{Op: opCreateBatch, Quotes: slices.Clone(quotes), Revisions: s.mem.LastRevisions(ids)}
+	if err := s.append(rec); err != nil {
+		s.mem.Remove(ids...)
+		s.mem.RemoveLastRevisions(ids)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) GetAll() ([]models.Quote, error) {
+	return s.mem.GetAll()
+}
+
+func (s *QuoteStorage) List(query models.QuoteQuery, page models.Page) (models.QuotePage, error) {
+	return s.mem.List(query, page)
+}
+
+func (s *QuoteStorage) Search(query string, limit int) ([]models.ScoredQuote, error) {
+	return s.mem.Search(query, limit)
+}
+
+func (s *QuoteStorage) GetByID(id int64) (*models.Quote, error) {
+	return s.mem.GetByID(id)
+}
+
+func (s *QuoteStorage) Created(before time.Time) ([]models.CreatedQuote, error) {
+	return s.mem.Created(before)
+}
+
+func (s *QuoteStorage) GetRandom(query models.QuoteQuery, weight models.RandomWeight) (*models.Quote, error) {
+	return s.mem.GetRandom(query, weight)
+}
+
+func (s *QuoteStorage) GetByAuthor(author string) ([]models.Quote, error) {
+	return s.mem.GetByAuthor(author)
+}
+
+func (s *QuoteStorage) AuthorNames() ([]string, error) {
+	return s.mem.AuthorNames()
+}
+
+func (s *QuoteStorage) Tags() ([]models.TagCount, error) {
+	return s.mem.Tags()
+}
+
+func (s *QuoteStorage) Update(quote *models.Quote) error {
+	const op = "storage.quotes.file.Update"
+
+	return s.replace(op, quote, func(repo services.QuoteRepository) error {
+		return repo.Update(quote)
+	})
+}
+
+func (s *QuoteStorage) Revert(quote *models.Quote, number int64) error {
+	const op = "storage.quotes.file.Revert"
+
+	return s.replace(op, quote, func(repo services.QuoteRepository) error {
+		return repo.Revert(quote, number)
+	})
+}
+
+// replace replaces the quote in memory with fn and logs the new quote as an
+// update.
+func (s *QuoteStorage) replace(op string, quote *models.Quote, fn func(repo services.QuoteRepository) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	prev, err := s.mem.GetByID(quote.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := fn(s.mem.By(s.changedBy)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	saved := *quote
+	ids := []int64{quote.ID}
+	if err := s.append(record{Op: opUpdate, Quote: &saved, Revisions: s.mem.LastRevisions(ids)}); err != nil {
+		_ = s.mem.Insert(*prev)
+		s.mem.RemoveLastRevisions(ids)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UpdateBatch replaces all the quotes or none. The batch is logged as a
+// single record, so it is never restored in part.
+func (s *QuoteStorage) UpdateBatch(quotes []models.Quote) error {
+	const op = "storage.quotes.file.UpdateBatch"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	prevs := make([]models.Quote, 0, len(quotes))
+	ids := make([]int64, 0, len(quotes))
+	for _, quote := range quotes {
+		if prev, err := s.mem.GetByID(quote.ID); err == nil {
+			prevs = append(prevs, *prev)
+		}
+		ids = append(ids, quote.ID)
+	}
+	if err := s.mem.By(s.changedBy).UpdateBatch(quotes); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rec := record{Op: opUpdateBatch, Quotes: slices.Clone(quotes), Revisions: s.mem.LastRevisions(ids)}
+	if err := s.append(rec); err != nil {
+		for _, prev := range prevs {
+			_ = s.mem.Insert(prev)
+		}
+		s.mem.RemoveLastRevisions(ids)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// LinkAuthor sets the author ID of the quote and logs the quote as an update
+// without a revision.
+func (s *QuoteStorage) LinkAuthor(id int64, authorID int64) error {
+	const op = "storage.quotes.file.LinkAuthor"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	prev, err := s.mem.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.mem.LinkAuthor(id, authorID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	linked := *prev
+	linked.AuthorID = authorID
+	if err := s.append(record{Op: opUpdate, Quote: &linked}); err != nil {
+		_ = s.mem.Insert(*prev)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) Delete(id int64, version int64) error {
+	const op = "storage.quotes.file.Delete"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	prev, err := s.mem.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.mem.By(s.changedBy).Delete(id, version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	trashed, err := s.mem.GetDeleted(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids := []int64{id}
+	if err := s.append(record{Op: opTrash, Quote: trashed, Revisions: s.mem.LastRevisions(ids)}); err != nil {
+		_ = s.mem.Insert(*prev)
+		s.mem.RemoveLastRevisions(ids)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) Trash() ([]models.Quote, error) {
+	return s.mem.Trash()
+}
+
+func (s *QuoteStorage) Undelete(id int64) (*models.Quote, error) {
+	const op = "storage.quotes.file.Undelete"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	prev, err := s.mem.GetDeleted(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	quote, err := s.mem.By(s.changedBy).Undelete(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids := []int64{id}
+	if err := s.append(record{Op: opRestore, ID: id, Revisions: s.mem.LastRevisions(ids)}); err != nil {
+		_ = s.mem.Insert(*prev)
+		s.mem.RemoveLastRevisions(ids)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quote, nil
+}
+
+// Purge removes the quotes deleted before the time for good. They are logged
+// as a single record.
+func (s *QuoteStorage) Purge(before time.Time) (int, error) {
+	const op = "storage.quotes.file.Purge"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	trash, err := s.mem.Trash()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	var purged []models.Quote
+	var ids []int64
+	for _, quote := range trash {
+		if quote.DeletedAt.Before(before) {
+			purged = append(purged, quote)
+			ids = append(ids, quote.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if _, err := s.mem.By(s.changedBy).Purge(before); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.append(record{Op: opPurge, IDs: ids, Revisions: s.mem.LastRevisions(ids)}); err != nil {
+		for _, quote := range purged {
+			_ = s.mem.Insert(quote)
+		}
+		s.mem.RemoveLastRevisions(ids)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(ids), nil
+}
+
+// Merge replaces the survivor like Update and deletes the merged quotes,
+// redirecting their IDs to the survivor. The merge is logged as a single
+// record.
+func (s *QuoteStorage) Merge(survivor *models.Quote, merged []models.Quote) error {
+	const op = "storage.quotes.file.Merge"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return fmt.Errorf("%s: %w", op, s.failed)
+	}
+
+	prev, err := s.mem.GetByID(survivor.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	removed := make([]models.Quote, 0, len(merged))
+	mergedIDs := make([]int64, 0, len(merged))
+	for _, quote := range merged {
+		if stored, err := s.mem.GetByID(quote.ID); err == nil {
+			removed = append(removed, *stored)
+		}
+		mergedIDs = append(mergedIDs, quote.ID)
+	}
+	if err := s.mem.By(s.changedBy).Merge(survivor, merged); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	saved := *survivor
+	ids := append([]int64{survivor.ID}, mergedIDs...)
+	rec := record{Op: opMerge, Quote: &saved, IDs: mergedIDs, Revisions: s.mem.LastRevisions(ids)}
+	if err := s.append(rec); err != nil {
+		// Inserting the quotes back also drops their redirects.
+		_ = s.mem.Insert(*prev)
+		for _, quote := range removed {
+			_ = s.mem.Insert(quote)
+		}
+		s.mem.RemoveLastRevisions(ids)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) Redirect(id int64) (int64, error) {
+	return s.mem.Redirect(id)
+}
+
+func (s *QuoteStorage) Revisions(quoteID int64) ([]models.Revision, error) {
+	return s.mem.Revisions(quoteID)
+}
+
+func (s *QuoteStorage) Revision(quoteID int64, number int64) (*models.Revision, error) {
+	return s.mem.Revision(quoteID, number)
+}
+
+// Snapshot writes the full state to a new snapshot, starts a new log behind it
+// and removes the files no longer needed for recovery.
+func (s *QuoteStorage) Snapshot() error {
+	const op = "storage.quotes.file.Snapshot"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.snapshot(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) snapshotLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.opts.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.records > 0 {
+				if err := s.snapshot(); err != nil {
+					log.Printf("storage.quotes.file: failed to take snapshot: %v", err)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// snapshot must be called with s.mu held.
+func (s *QuoteStorage) snapshot() error {
+	if s.failed != nil {
+		return s.failed
+	}
+
+	gen := s.gen + 1
+	quotes, redirects, revisions, nextID := s.mem.Snapshot()
+	snap := snapshot{NextID: nextID, Quotes: quotes, Redirects: redirects, Revisions: revisions}
+	if err := writeSnapshot(s.path(snapshotPrefix, gen, snapshotSuffix), snap); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path(logPrefix, gen, logSuffix), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create log: %w", err)
+	}
+	if err := fsutil.SyncDir(s.dir); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := s.log.Close(); err != nil {
+		log.Printf("storage.quotes.file: failed to close log: %v", err)
+	}
+	s.log = f
+	s.size = 0
+	s.gen = gen
+	s.records = 0
+
+	// The previous generation is kept as a fallback in case the new snapshot
+	// turns out to be unreadable.
+	s.removeBefore(gen - 1)
+	return nil
+}
+
+// append writes the record to the end of the log and waits until it reaches the disk.
+// It must be called with s.mu held.
+func (s *QuoteStorage) append(rec record) error {
+	buf, err := encodeFrame(rec)
+	if err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+	if _, err := s.log.Write(buf); err != nil {
+		s.discardTail()
+		return fmt.Errorf("write log: %w", err)
+	}
+	if err := s.log.Sync(); err != nil {
+		s.discardTail()
+		return fmt.Errorf("sync log: %w", err)
+	}
+
+	s.size += int64(len(buf))
+	s.records++
+	if s.opts.SnapshotThreshold > 0 && s.records >= s.opts.SnapshotThreshold {
+		if err := s.snapshot(); err != nil {
+			log.Printf("storage.quotes.file: failed to take snapshot: %v", err)
+		}
+	}
+	return nil
+}
+
+// discardTail cuts off a partially written record so that the caller can roll
+// back the change. If that fails the log can no longer be appended to safely.
+func (s *QuoteStorage) discardTail() {
+	if err := s.log.Truncate(s.size); err != nil {
+		s.failed = fmt.Errorf("log is left with a partial record: %w", err)
+		return
+	}
+	if err := s.log.Sync(); err != nil {
+		s.failed = fmt.Errorf("log is left with a partial record: %w", err)
+	}
+}
+
+// load restores the state from the latest valid snapshot and the logs written after it.
+func (s *QuoteStorage) load() error {
+	if err := s.migrateLegacyLog(); err != nil {
+		return err
+	}
+
+	snapshots, logs, err := s.listGenerations()
+	if err != nil {
+		return err
+	}
+
+	var base uint64
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snap, err := readSnapshot(s.path(snapshotPrefix, snapshots[i], snapshotSuffix))
+		if err != nil {
+			log.Printf("storage.quotes.file: skipping snapshot %d: %v", snapshots[i], err)
+			continue
+		}
+		s.mem.Restore(snap.Quotes, snap.Redirects, snap.Revisions, snap.NextID)
+		base = snapshots[i]
+		break
+	}
+
+	var tail []uint64
+	for _, gen := range logs {
+		if gen >= base {
+			tail = append(tail, gen)
+		}
+	}
+	for i, gen := range tail {
+		if gen != base+uint64(i) {
+			return fmt.Errorf("log %d is missing", base+uint64(i))
+		}
+	}
+	// Falling back to an older snapshot is only safe while its log is still around.
+	fellBack := len(snapshots) > 0 && snapshots[len(snapshots)-1] != base
+	if fellBack && len(tail) == 0 {
+		return fmt.Errorf("no valid snapshot can be restored: log %d is missing", base)
+	}
+
+	for i, gen := range tail {
+		if err := s.replay(gen, i == len(tail)-1); err != nil {
+			return err
+		}
+	}
+
+	s.gen = base
+	if len(tail) > 0 {
+		s.gen = tail[len(tail)-1]
+	}
+
+	f, err := os.OpenFile(s.path(logPrefix, s.gen, logSuffix), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log: %w", err)
+	}
+	s.log = f
+	s.size = info.Size()
+	return fsutil.SyncDir(s.dir)
+}
+
+// migrateLegacyLog turns the log of a data directory written before snapshots
+// were added into log 0. If the directory also has numbered logs or snapshots
+// it is unclear which of them is current, so opening fails.
+func (s *QuoteStorage) migrateLegacyLog() error {
+	legacy := filepath.Join(s.dir, legacyLogName)
+	if _, err := os.Stat(legacy); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("stat legacy log: %w", err)
+	}
+
+	snapshots, logs, err := s.listGenerations()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) > 0 || len(logs) > 0 {
+		return fmt.Errorf("both %s and numbered logs or snapshots exist in %s; move one of them away", legacyLogName, s.dir)
+	}
+
+	if err := os.Rename(legacy, s.path(logPrefix, 0, logSuffix)); err != nil {
+		return fmt.Errorf("migrate legacy log: %w", err)
+	}
+	log.Printf("storage.quotes.file: migrated %s to log 0", legacy)
+	return fsutil.SyncDir(s.dir)
+}
+
+// replay applies the records of the log with the given generation. A torn
+// record at the end of the last log, left by a crash in the middle of a write,
+// is cut off. Any other damage fails the replay, as the records after it were
+// acknowledged to clients.
+func (s *QuoteStorage) replay(gen uint64, last bool) error {
+	f, err := os.OpenFile(s.path(logPrefix, gen, logSuffix), os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		var rec record
+		n, err := readFrame(r, &rec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errCorruptFrame) {
+			// A frame whose end never reached the disk is torn as well.
+			zero, zerr := restIsZero(r)
+			if zerr != nil {
+				return fmt.Errorf("read log: %w", zerr)
+			}
+			if zero {
+				err = errTornFrame
+			}
+		}
+		if errors.Is(err, errTornFrame) && last {
+			log.Printf("storage.quotes.file: truncating torn record at offset %d in %s", offset, f.Name())
+			if err := f.Truncate(offset); err != nil {
+				return fmt.Errorf("truncate log: %w", err)
+			}
+			if err := f.Sync(); err != nil {
+				return fmt.Errorf("sync log: %w", err)
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read record at offset %d in %s: %w", offset, f.Name(), err)
+		}
+
+		if err := s.apply(rec); err != nil {
+			return fmt.Errorf("apply record at offset %d in %s: %w", offset, f.Name(), err)
+		}
+		offset += n
+		s.records++
+	}
+	return nil
+}
+
+// apply applies the change logged in the record and restores the revisions
+// it made.
+func (s *QuoteStorage) apply(rec record) error {
+	if err := s.applyChange(rec); err != nil {
+		return err
+	}
+	for _, revision := range rec.Revisions {
+		if err := s.mem.InsertRevision(revision); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *QuoteStorage) applyChange(rec record) error {
+	switch rec.Op {
+	case opCreate, opUpdate:
+		if rec.Quote == nil {
+			return fmt.Errorf("%s record without quote", rec.Op)
+		}
+		return s.mem.Insert(*rec.Quote)
+	case opCreateBatch, opUpdateBatch:
+		for _, quote := range rec.Quotes {
+			if err := s.mem.Insert(quote); err != nil {
+				return err
+			}
+		}
+		return nil
+	case opDelete:
+		s.mem.Remove(rec.ID)
+		return nil
+	case opTrash:
+		if rec.Quote == nil {
+			return fmt.Errorf("%s record without quote", rec.Op)
+		}
+		return s.mem.Insert(*rec.Quote)
+	case opRestore:
+		quote, err := s.mem.GetDeleted(rec.ID)
+		if errors.Is(err, storage.ErrQuoteNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		quote.DeletedAt = time.Time{}
+		return s.mem.Insert(*quote)
+	case opPurge:
+		s.mem.Remove(rec.IDs...)
+		return nil
+	case opMerge:
+		if rec.Quote == nil {
+			return fmt.Errorf("%s record without quote", rec.Op)
+		}
+		if err := s.mem.Insert(*rec.Quote); err != nil {
+			return err
+		}
+		s.mem.InsertRedirects(rec.IDs, rec.Quote.ID)
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+}
+
+// listGenerations returns the sorted generations of snapshots and logs in the
+// directory, removing temporary files left by an interrupted snapshot.
+func (s *QuoteStorage) listGenerations() (snapshots, logs []uint64, err error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, tmpSuffix) {
+			os.Remove(filepath.Join(s.dir, name))
+			continue
+		}
+		if gen, ok := parseGeneration(name, snapshotPrefix, snapshotSuffix); ok {
+			snapshots = append(snapshots, gen)
+		}
+		if gen, ok := parseGeneration(name, logPrefix, logSuffix); ok {
+			logs = append(logs, gen)
+		}
+	}
+	slices.Sort(snapshots)
+	slices.Sort(logs)
+	return snapshots, logs, nil
+}
+
+// removeBefore deletes snapshots and logs older than the given generation.
+func (s *QuoteStorage) removeBefore(gen uint64) {
+	snapshots, logs, err := s.listGenerations()
+	if err != nil {
+		log.Printf("storage.quotes.file: failed to list files: %v", err)
+		return
+	}
+
+	for _, g := range snapshots {
+		if g < gen {
+			os.Remove(s.path(snapshotPrefix, g, snapshotSuffix))
+		}
+	}
+	for _, g := range logs {
+		if g < gen {
+			os.Remove(s.path(logPrefix, g, logSuffix))
+		}
+	}
+	if err := fsutil.SyncDir(s.dir); err != nil {
+		log.Printf("storage.quotes.file: %v", err)
+	}
+}
+
+func (s *QuoteStorage) path(prefix string, gen uint64, suffix string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", prefix, gen, suffix))
+}
+
+func parseGeneration(name, prefix, suffix string) (uint64, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return 0, false
+	}
+	gen, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return gen, true
+}
@@ -1,27 +1,78 @@
 package memory
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"math"
 	"math/rand/v2"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"quotes/internal/domain/models"
 	"quotes/internal/services"
 	"quotes/internal/storage"
+	"quotes/internal/storage/search"
 )
 
+var _ services.QuoteRepository = (*QuoteStorage)(nil)
+
+// QuoteStorage keeps quotes and their revisions in memory. The storages
+// returned by By share them.
 type QuoteStorage struct {
+	*state
+	// changedBy is recorded as the maker of the changes.
+	changedBy string
+}
+
+type state struct {
+	// quotes are kept in ID order.
 	quotes []models.Quote
-	mu     sync.RWMutex
-	nextID int64
+	// byID maps quote IDs to their positions in quotes.
+	byID map[int64]int
+	// index is the full-text index of quote texts.
+	index *search.Index
+	// tags maps each tag to the IDs of the quotes that have it.
+	tags map[string]map[int64]bool
+	// authors maps each normalized author name to the IDs of the quotes by
+	// the author.
+	authors map[string]map[int64]bool
+	// trash holds the deleted quotes, which are left out of all the above.
+	trash map[int64]models.Quote
+	// redirects maps the IDs of merged quotes to the quotes they were merged
+	// into.
+	redirects map[int64]int64
+	// merged holds the quotes merged into others, which are left out of
+	// everything but Created.
+	merged map[int64]models.Quote
+	// revisions holds the revisions of each quote in the order of their
+	// numbers.
+	revisions map[int64][]models.Revision
+	mu        sync.RWMutex
+	nextID    int64
 }
 
-func NewQuoteStorage() services.QuoteRepository {
-	return &QuoteStorage{
-		quotes: make([]models.Quote, 0),
-		nextID: 1,
-	}
+func NewQuoteStorage() *QuoteStorage {
+	return &QuoteStorage{state: &state{
+		quotes:    make([]models.Quote, 0),
+		byID:      make(map[int64]int),
+		index:     search.NewIndex(),
+		tags:      make(map[string]map[int64]bool),
+		authors:   make(map[string]map[int64]bool),
+		trash:     make(map[int64]models.Quote),
+		redirects: make(map[int64]int64),
+		merged:    make(map[int64]models.Quote),
+		revisions: make(map[int64][]models.Revision),
+		nextID:    1,
+	}}
+}
+
+// By returns a storage sharing the quotes of s that records changedBy as the
+// maker of its changes.
+func (s *QuoteStorage) By(changedBy string) services.QuoteRepository {
+	return &QuoteStorage{state: s.state, changedBy: changedBy}
 }
 
 func (s *QuoteStorage) Create(quote *models.Quote) error {
@@ -37,11 +88,81 @@ func (s *QuoteStorage) Create(quote *models.Quote) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.create(quote)
+	s.record(models.Revision{Action: models.RevisionCreate, Quote: *quote})
+	return nil
+}
+
+// CreateBatch creates all the quotes or, if any of them is invalid, none.
+func (s *QuoteStorage) CreateBatch(quotes []models.Quote) error {
+	const op = "storage.quotes.memory.CreateBatch"
+
+	for i, quote := range quotes {
+		if quote.Author == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyAuthor)
+		}
+		if quote.Text == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyText)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range quotes {
+		s.create(&quotes[i])
+		s.record(models.Revision{Action: models.RevisionCreate, Quote: quotes[i]})
+	}
+	return nil
+}
+
+// CreateUnique creates all the quotes unless any of them is invalid or repeats
+// a live quote of its author.
+func (s *QuoteStorage) CreateUnique(quotes []models.Quote) error {
+	const op = "storage.quotes.memory.CreateUnique"
+
+	for i, quote := range quotes {
+		if quote.Author == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyAuthor)
+		}
+		if quote.Text == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyText)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, quote := range quotes {
+		key := models.NormalizeAuthor(quote.Author)
+		if key == "" {
+			continue
+		}
+		if known, exact := models.FindDuplicate(quote, s.byAuthor(key)); known != nil {
+			return fmt.Errorf("%s: quote %d: %w", op, i, &storage.DuplicateError{ID: known.ID, Exact: exact})
+		}
+	}
+	for i := range quotes {
+		s.create(&quotes[i])
+		s.record(models.Revision{Action: models.RevisionCreate, Quote: quotes[i]})
+	}
+	return nil
+}
+
+// create stores a new valid quote. The caller must hold the write lock.
+func (s *QuoteStorage) create(quote *models.Quote) {
 	quote.ID = s.nextID
 	quote.CreatedAt = time.Now()
+	quote.UpdatedAt = time.Time{}
+	quote.DeletedAt = time.Time{}
+	quote.Version = 1
+	quote.Tags = models.NormalizeTags(quote.Tags)
+	quote.Verification = quote.Verification.Or(models.VerificationUnverified)
+	s.byID[quote.ID] = len(s.quotes)
 	s.quotes = append(s.quotes, *quote)
+	s.index.Add(quote.ID, quote.Text)
+	s.link(*quote)
 	s.nextID++
-	return nil
 }
 
 func (s *QuoteStorage) GetAll() ([]models.Quote, error) {
@@ -53,40 +174,334 @@ func (s *QuoteStorage) GetAll() ([]models.Quote, error) {
 	return quotes, nil
 }
 
-func (s *QuoteStorage) GetRandom() (*models.Quote, error) {
+func (s *QuoteStorage) List(query models.QuoteQuery, page models.Page) (models.QuotePage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order := models.OrderBy(page.Sort)
+	pool := s.quotes
+	if len(query.Tags) > 0 {
+		pool = s.tagged(query)
+	}
+	quotes := make([]models.Quote, 0)
+
+	// Quotes are kept in ID order, so the default listing can start right
+	// after the cursor and stop as soon as the page is full.
+	if len(order) == 1 && !order[0].Desc {
+		start := 0
+		if page.After != nil {
+			start, _ = slices.BinarySearchFunc(pool, page.After.ID+1, func(q models.Quote, id int64) int {
+				return cmp.Compare(q.ID, id)
+			})
+		}
+		for _, quote := range pool[start:] {
+			if page.Limit > 0 && len(quotes) > page.Limit {
+				break
+			}
+			if query.Match(quote) {
+				quotes = append(quotes, quote)
+			}
+		}
+		return models.NewQuotePage(quotes, page.Limit), nil
+	}
+
+	for _, quote := range pool {
+		if page.After != nil && models.Compare(models.CursorOf(quote), *page.After, order) <= 0 {
+			continue
+		}
+		if query.Match(quote) {
+			quotes = append(quotes, quote)
+		}
+	}
+	slices.SortFunc(quotes, func(a, b models.Quote) int {
+		return models.Compare(models.CursorOf(a), models.CursorOf(b), order)
+	})
+	if page.Limit > 0 && len(quotes) > page.Limit+1 {
+		quotes = quotes[:page.Limit+1]
+	}
+	return models.NewQuotePage(quotes, page.Limit), nil
+}
+
+func (s *QuoteStorage) Search(query string, limit int) ([]models.ScoredQuote, error) {
+	const op = "storage.quotes.memory.Search"
+
+	if len(search.QueryTerms(query)) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEmptySearchQuery)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits := s.index.Search(query)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	result := make([]models.ScoredQuote, 0, len(hits))
+	for _, hit := range hits {
+		result = append(result, models.ScoredQuote{
+			Quote: s.quotes[s.byID[hit.ID]],
+			Score: hit.Score,
+		})
+	}
+	return result, nil
+}
+
+func (s *QuoteStorage) GetByID(id int64) (*models.Quote, error) {
+	const op = "storage.quotes.memory.GetByID"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	quote := s.quotes[i]
+	return &quote, nil
+}
+
+func (s *QuoteStorage) Created(before time.Time) ([]models.CreatedQuote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	created := make([]models.CreatedQuote, 0, len(s.quotes))
+	add := func(quote models.Quote) {
+		if quote.CreatedAt.Before(before) {
+			created = append(created, models.CreatedQuote{ID: quote.ID, CreatedAt: quote.CreatedAt})
+		}
+	}
+	for _, quote := range s.quotes {
+		add(quote)
+	}
+	for _, quote := range s.trash {
+		add(quote)
+	}
+	for _, quote := range s.merged {
+		add(quote)
+	}
+	slices.SortFunc(created, func(a, b models.CreatedQuote) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return created, nil
+}
+
+// GetRandom picks a quote in a single pass without collecting the matches:
+// every matching quote draws a key from the exponential distribution with its
+// weight as the rate, and the smallest key wins (Efraimidis-Spirakis sampling).
+func (s *QuoteStorage) GetRandom(query models.QuoteQuery, weight models.RandomWeight) (*models.Quote, error) {
 	const op = "storage.quotes.memory.GetRandom"
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if len(s.quotes) == 0 {
+	if query.IsZero() && weight == models.WeightUniform {
+		if len(s.quotes) == 0 {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrNoQuotesAvailable)
+		}
+		quote := s.quotes[rand.IntN(len(s.quotes))]
+		return &quote, nil
+	}
+
+	pool := s.quotes
+	if len(query.Tags) > 0 {
+		pool = s.tagged(query)
+	}
+	best := -1
+	bestKey := math.Inf(1)
+	for i, quote := range pool {
+		if !query.Match(quote) {
+			continue
+		}
+		// Keys are compared as logarithms, see models.RandomWeight.LogWeight.
+		if key := math.Log(rand.ExpFloat64()) - weight.LogWeight(quote); key < bestKey {
+			best, bestKey = i, key
+		}
+	}
+	if best < 0 {
 		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoQuotesAvailable)
 	}
-	randomIndex := rand.IntN(len(s.quotes))
-	quote := s.quotes[randomIndex]
+	quote := pool[best]
 	return &quote, nil
 }
 
 func (s *QuoteStorage) GetByAuthor(author string) ([]models.Quote, error) {
 	const op = "storage.quotes.memory.GetByAuthor"
 
-	if author == "" {
+	key := models.NormalizeAuthor(author)
+	if key == "" {
 		return nil, fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthor)
 	}
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var result []models.Quote
+	return s.byAuthor(key), nil
+}
+
+// byAuthor returns the live quotes of the author with the normalized name in
+// ID order. The caller must hold the lock.
+func (s *QuoteStorage) byAuthor(key string) []models.Quote {
+	var quotes []models.Quote
+	for _, id := range slices.Sorted(maps.Keys(s.authors[key])) {
+		quotes = append(quotes, s.quotes[s.byID[id]])
+	}
+	return quotes
+}
+
+func (s *QuoteStorage) AuthorNames() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	names := make([]string, 0)
 	for _, quote := range s.quotes {
-		if quote.Author == author {
-			result = append(result, quote)
+		if !seen[quote.Author] {
+			seen[quote.Author] = true
+			names = append(names, quote.Author)
 		}
 	}
-	return result, nil
+	slices.Sort(names)
+	return names, nil
 }
 
-func (s *QuoteStorage) Delete(id int64) error {
+func (s *QuoteStorage) Tags() ([]models.TagCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make([]models.TagCount, 0, len(s.tags))
+	for tag, ids := range s.tags {
+		counts = append(counts, models.TagCount{Tag: tag, Count: len(ids)})
+	}
+	slices.SortFunc(counts, func(a, b models.TagCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return counts, nil
+}
+
+func (s *QuoteStorage) Update(quote *models.Quote) error {
+	const op = "storage.quotes.memory.Update"
+
+	if err := s.replace(quote, models.Revision{Action: models.RevisionUpdate}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) Revert(quote *models.Quote, number int64) error {
+	const op = "storage.quotes.memory.Revert"
+
+	if err := s.replace(quote, models.Revision{Action: models.RevisionRevert, RevertedTo: number}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UpdateBatch replaces all the quotes or, if any of them is invalid, missing
+// or stale, none.
+func (s *QuoteStorage) UpdateBatch(quotes []models.Quote) error {
+	const op = "storage.quotes.memory.UpdateBatch"
+
+	ids := make([]int64, 0, len(quotes))
+	for i, quote := range quotes {
+		if quote.ID <= 0 {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrInvalidID)
+		}
+		if quote.Author == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyAuthor)
+		}
+		if quote.Text == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyText)
+		}
+		ids = append(ids, quote.ID)
+	}
+	if hasDuplicateIDs(ids) {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, quote := range quotes {
+		j, ok := s.byID[quote.ID]
+		if !ok {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrQuoteNotFound)
+		}
+		if quote.Version != 0 && quote.Version != s.quotes[j].Version {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrVersionMismatch)
+		}
+	}
+	for i := range quotes {
+		s.update(s.byID[quotes[i].ID], &quotes[i])
+		s.record(models.Revision{Action: models.RevisionUpdate, Quote: quotes[i]})
+	}
+	return nil
+}
+
+func (s *QuoteStorage) LinkAuthor(id int64, authorID int64) error {
+	const op = "storage.quotes.memory.LinkAuthor"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	s.quotes[i].AuthorID = authorID
+	return nil
+}
+
+// replace replaces the quote like Update and records the revision of it.
+func (s *QuoteStorage) replace(quote *models.Quote, revision models.Revision) error {
+	if quote.ID <= 0 {
+		return storage.ErrInvalidID
+	}
+	if quote.Author == "" {
+		return storage.ErrEmptyAuthor
+	}
+	if quote.Text == "" {
+		return storage.ErrEmptyText
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byID[quote.ID]
+	if !ok {
+		return storage.ErrQuoteNotFound
+	}
+	if quote.Version != 0 && quote.Version != s.quotes[i].Version {
+		return storage.ErrVersionMismatch
+	}
+
+	s.update(i, quote)
+	revision.Quote = *quote
+	s.record(revision)
+	return nil
+}
+
+// update replaces the quote at position i. The caller must hold the write lock.
+func (s *QuoteStorage) update(i int, quote *models.Quote) {
+	quote.CreatedAt = s.quotes[i].CreatedAt
+	quote.UpdatedAt = time.Now()
+	quote.DeletedAt = time.Time{}
+	quote.Version = s.quotes[i].Version + 1
+	quote.Tags = models.NormalizeTags(quote.Tags)
+	quote.Verification = quote.Verification.Or(models.VerificationUnverified)
+	s.unlink(s.quotes[i])
+	s.quotes[i] = *quote
+	s.index.Add(quote.ID, quote.Text)
+	s.link(*quote)
+}
+
+func (s *QuoteStorage) Delete(id int64, version int64) error {
 	const op = "storage.quotes.memory.Delete"
 
 	if id <= 0 {
@@ -96,12 +511,516 @@ func (s *QuoteStorage) Delete(id int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	i, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	if version != 0 && version != s.quotes[i].Version {
+		return fmt.Errorf("%s: %w", op, storage.ErrVersionMismatch)
+	}
+
+	quote := s.quotes[i]
+	quote.DeletedAt = time.Now()
+	s.remove([]int64{id})
+	s.trash[id] = quote
+	s.record(models.Revision{Action: models.RevisionDelete, Quote: quote})
+	return nil
+}
+
+// GetDeleted returns the quote with the given ID from the trash.
+func (s *QuoteStorage) GetDeleted(id int64) (*models.Quote, error) {
+	const op = "storage.quotes.memory.GetDeleted"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quote, ok := s.trash[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	return &quote, nil
+}
+
+// Trash returns the deleted quotes, the most recently deleted first.
+func (s *QuoteStorage) Trash() ([]models.Quote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quotes := slices.Collect(maps.Values(s.trash))
+	if quotes == nil {
+		quotes = make([]models.Quote, 0)
+	}
+	slices.SortFunc(quotes, func(a, b models.Quote) int {
+		return cmp.Or(b.DeletedAt.Compare(a.DeletedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return quotes, nil
+}
+
+// Undelete brings the deleted quote back from the trash.
+func (s *QuoteStorage) Undelete(id int64) (*models.Quote, error) {
+	const op = "storage.quotes.memory.Undelete"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quote, ok := s.trash[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	quote.DeletedAt = time.Time{}
+	s.insert(quote)
+	s.record(models.Revision{Action: models.RevisionRestore, Quote: quote})
+	return &quote, nil
+}
+
+// Purge removes the quotes deleted before the time for good and returns their
+// number.
+func (s *QuoteStorage) Purge(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, quote := range s.trash {
+		if quote.DeletedAt.Before(before) {
+			delete(s.trash, id)
+			s.record(models.Revision{Action: models.RevisionPurge, Quote: quote})
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Remove deletes the quotes for good, whether they are in the trash or not. It
+// is used by persistent storages to undo and replay changes.
+func (s *QuoteStorage) Remove(ids ...int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(ids)
+	for _, id := range ids {
+		delete(s.trash, id)
+		delete(s.merged, id)
+	}
+}
+
+// remove removes the live quotes with the given IDs, if they exist, in a
+// single pass over the quotes after the first of them. The caller must hold
+// the write lock.
+func (s *QuoteStorage) remove(ids []int64) {
+	first := len(s.quotes)
+	for _, id := range ids {
+		i, ok := s.byID[id]
+		if !ok {
+			continue
+		}
+		first = min(first, i)
+		s.unlink(s.quotes[i])
+		s.index.Remove(id)
+		delete(s.byID, id)
+	}
+	if first == len(s.quotes) {
+		return
+	}
+
+	kept := slices.DeleteFunc(s.quotes[first:], func(quote models.Quote) bool {
+		_, ok := s.byID[quote.ID]
+		return !ok
+	})
+	s.quotes = s.quotes[:first+len(kept)]
+	s.reindex(first)
+}
+
+// Merge replaces the survivor like Update and deletes the merged quotes,
+// redirecting their IDs to the survivor. Nothing changes if any of the quotes
+// is missing.
+func (s *QuoteStorage) Merge(survivor *models.Quote, merged []models.Quote) error {
+	const op = "storage.quotes.memory.Merge"
+
+	ids := quoteIDs(merged)
+	if survivor.ID <= 0 || slices.Contains(ids, survivor.ID) || hasDuplicateIDs(ids) {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+	if survivor.Author == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthor)
+	}
+	if survivor.Text == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyText)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byID[survivor.ID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	if survivor.Version != 0 && survivor.Version != s.quotes[i].Version {
+		return fmt.Errorf("%s: %w", op, storage.ErrVersionMismatch)
+	}
+	for _, quote := range merged {
+		j, ok := s.byID[quote.ID]
+		if !ok {
+			return fmt.Errorf("%s: quote %d: %w", op, quote.ID, storage.ErrQuoteNotFound)
+		}
+		if quote.Version != 0 && quote.Version != s.quotes[j].Version {
+			return fmt.Errorf("%s: quote %d: %w", op, quote.ID, storage.ErrVersionMismatch)
+		}
+	}
+
+	s.update(i, survivor)
+	s.record(models.Revision{Action: models.RevisionUpdate, Quote: *survivor})
+	s.redirect(ids, survivor.ID)
+	for _, id := range ids {
+		s.record(models.Revision{Action: models.RevisionMerge, Quote: s.merged[id], MergedInto: survivor.ID})
+	}
+	return nil
+}
+
+// InsertRedirects deletes the quotes with the given IDs, if they exist, and
+// redirects the IDs to the quote with ID to. It is used by persistent
+// storages to restore merges.
+func (s *QuoteStorage) InsertRedirects(ids []int64, to int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.redirect(ids, to)
+}
+
+// redirect deletes the quotes and points their IDs to the quote with ID to.
+// The caller must hold the write lock.
+func (s *QuoteStorage) redirect(ids []int64, to int64) {
+	for _, id := range ids {
+		if i, ok := s.byID[id]; ok {
+			s.merged[id] = s.quotes[i]
+		}
+		s.redirects[id] = to
+	}
+	s.remove(ids)
+}
+
+// Redirect returns the ID of the quote the quote with the given ID was merged
+// into.
+func (s *QuoteStorage) Redirect(id int64) (int64, error) {
+	const op = "storage.quotes.memory.Redirect"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// A survivor merged later on redirects further. Deleting the final
+	// survivor ends the redirect.
+	to, ok := s.redirects[id]
+	for ok {
+		if _, exists := s.byID[to]; exists {
+			return to, nil
+		}
+		to, ok = s.redirects[to]
+	}
+	return 0, fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+}
+
+func (s *QuoteStorage) Revisions(quoteID int64) ([]models.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := slices.Clone(s.revisions[quoteID])
+	if revisions == nil {
+		revisions = make([]models.Revision, 0)
+	}
+	return revisions, nil
+}
+
+func (s *QuoteStorage) Revision(quoteID int64, number int64) (*models.Revision, error) {
+	const op = "storage.quotes.memory.Revision"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := s.revisions[quoteID]
+	if number <= 0 || number > int64(len(revisions)) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+	}
+	revision := revisions[number-1]
+	return &revision, nil
+}
+
+// record adds the revision made by a change as the next revision of its
+// quote. The caller must hold the write lock.
+func (s *QuoteStorage) record(revision models.Revision) {
+	revision.QuoteID = revision.Quote.ID
+	revision.Number = int64(len(s.revisions[revision.QuoteID])) + 1
+	revision.ChangedBy = s.changedBy
+	revision.ChangedAt = time.Now()
+	s.revisions[revision.QuoteID] = append(s.revisions[revision.QuoteID], revision)
+}
+
+// LastRevisions returns the last revision of each of the quotes. It is used by
+// persistent storages to save the revisions made by a change.
+func (s *QuoteStorage) LastRevisions(ids []int64) []models.Revision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := make([]models.Revision, 0, len(ids))
+	for _, id := range ids {
+		if n := len(s.revisions[id]); n > 0 {
+			revisions = append(revisions, s.revisions[id][n-1])
+		}
+	}
+	return revisions
+}
+
+// RemoveLastRevisions removes the last revision of each of the quotes. It is
+// used by persistent storages to undo a change that could not be saved.
+func (s *QuoteStorage) RemoveLastRevisions(ids []int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if n := len(s.revisions[id]); n > 0 {
+			s.revisions[id] = s.revisions[id][:n-1]
+		}
+	}
+}
+
+// InsertRevision stores the revision as is. It must be the next revision of
+// its quote. It is used by persistent storages to restore saved revisions.
+func (s *QuoteStorage) InsertRevision(revision models.Revision) error {
+	const op = "storage.quotes.memory.InsertRevision"
+
+	if revision.QuoteID <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if revision.Number != int64(len(s.revisions[revision.QuoteID]))+1 {
+		return fmt.Errorf("%s: revision %d of quote %d is out of order", op, revision.Number, revision.QuoteID)
+	}
+	s.revisions[revision.QuoteID] = append(s.revisions[revision.QuoteID], revision)
+	return nil
+}
+
+// Insert stores the quote as is, keeping its ID and CreatedAt, in the trash if
+// it has DeletedAt set. It is used by persistent storages to restore
+// previously created quotes.
+func (s *QuoteStorage) Insert(quote models.Quote) error {
+	const op = "storage.quotes.memory.Insert"
+
+	if quote.ID <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+	// Quotes saved before verification statuses existed are unverified.
+	quote.Verification = quote.Verification.Or(models.VerificationUnverified)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !quote.DeletedAt.IsZero() {
+		s.remove([]int64{quote.ID})
+		delete(s.merged, quote.ID)
+		s.trash[quote.ID] = quote
+		s.nextID = max(s.nextID, quote.ID+1)
+		return nil
+	}
+	s.insert(quote)
+	return nil
+}
+
+// insert stores the quote among the live ones. The caller must hold the write
+// lock.
+func (s *QuoteStorage) insert(quote models.Quote) {
+	delete(s.trash, quote.ID)
+	delete(s.merged, quote.ID)
+	if i, ok := s.byID[quote.ID]; ok {
+		s.unlink(s.quotes[i])
+		s.quotes[i] = quote
+	} else {
+		i, _ := slices.BinarySearchFunc(s.quotes, quote.ID, func(q models.Quote, id int64) int {
+			return cmp.Compare(q.ID, id)
+		})
+		s.quotes = slices.Insert(s.quotes, i, quote)
+		s.reindex(i)
+	}
+	s.index.Add(quote.ID, quote.Text)
+	s.link(quote)
+	delete(s.redirects, quote.ID)
+	if quote.ID >= s.nextID {
+		s.nextID = quote.ID + 1
+	}
+}
+
+// Snapshot returns a copy of all quotes, including the deleted and merged
+// ones, redirects and revisions together with the next ID to be assigned.
+func (s *QuoteStorage) Snapshot() ([]models.Quote, map[int64]int64, []models.Revision, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quotes := make([]models.Quote, len(s.quotes), len(s.quotes)+len(s.trash)+len(s.merged))
+	copy(quotes, s.quotes)
+	for _, id := range slices.Sorted(maps.Keys(s.trash)) {
+		quotes = append(quotes, s.trash[id])
+	}
+	for _, id := range slices.Sorted(maps.Keys(s.merged)) {
+		quotes = append(quotes, s.merged[id])
+	}
+	var revisions []models.Revision
+	for _, id := range slices.Sorted(maps.Keys(s.revisions)) {
+		revisions = append(revisions, s.revisions[id]...)
+	}
+	return quotes, maps.Clone(s.redirects), revisions, s.nextID
+}
+
+// Restore replaces the stored quotes, redirects and revisions with the ones
+// taken by Snapshot. Quotes whose IDs are redirected are the merged ones.
+func (s *QuoteStorage) Restore(quotes []models.Quote, redirects map[int64]int64, revisions []models.Revision, nextID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revisions = make(map[int64][]models.Revision)
+	for _, revision := range revisions {
+		s.revisions[revision.QuoteID] = append(s.revisions[revision.QuoteID], revision)
+	}
+
+	s.nextID = nextID
+	s.quotes = make([]models.Quote, 0, len(quotes))
+	s.trash = make(map[int64]models.Quote)
+	s.merged = make(map[int64]models.Quote)
+	for _, quote := range quotes {
+		_, merged := redirects[quote.ID]
+		switch {
+		case merged:
+			s.merged[quote.ID] = quote
+		case !quote.DeletedAt.IsZero():
+			s.trash[quote.ID] = quote
+		default:
+			s.quotes = append(s.quotes, quote)
+		}
+		s.nextID = max(s.nextID, quote.ID+1)
+	}
+	slices.SortFunc(s.quotes, func(a, b models.Quote) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	s.byID = make(map[int64]int, len(quotes))
+	s.index = search.NewIndex()
+	s.tags = make(map[string]map[int64]bool)
+	s.authors = make(map[string]map[int64]bool)
+	s.redirects = maps.Clone(redirects)
+	if s.redirects == nil {
+		s.redirects = make(map[int64]int64)
+	}
 	for i, quote := range s.quotes {
-		if quote.ID == id {
-			s.quotes[i] = s.quotes[len(s.quotes)-1]
-			s.quotes = s.quotes[:len(s.quotes)-1]
-			return nil
+		s.quotes[i].Verification = quote.Verification.Or(models.VerificationUnverified)
+		s.byID[quote.ID] = i
+		s.index.Add(quote.ID, quote.Text)
+		s.link(quote)
+	}
+}
+
+func quoteIDs(quotes []models.Quote) []int64 {
+	ids := make([]int64, len(quotes))
+	for i, quote := range quotes {
+		ids[i] = quote.ID
+	}
+	return ids
+}
+
+func hasDuplicateIDs(ids []int64) bool {
+	return len(slices.Compact(slices.Sorted(slices.Values(ids)))) != len(ids)
+}
+
+// reindex updates the positions of the quotes starting from i.
+func (s *QuoteStorage) reindex(i int) {
+	for ; i < len(s.quotes); i++ {
+		s.byID[s.quotes[i].ID] = i
+	}
+}
+
+// tagged returns the quotes that can match the tag filter of the query, in ID
+// order, looked up in the tag index.
+func (s *QuoteStorage) tagged(query models.QuoteQuery) []models.Quote {
+	sets := make([]map[int64]bool, 0, len(query.Tags))
+	for _, tag := range query.Tags {
+		sets = append(sets, s.tags[models.NormalizeTag(tag)])
+	}
+
+	var ids []int64
+	if query.AnyTag {
+		seen := make(map[int64]bool)
+		for _, set := range sets {
+			for id := range set {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	} else {
+		// Quotes having all of the tags are found among the ones with the
+		// rarest tag.
+		rarest := slices.MinFunc(sets, func(a, b map[int64]bool) int {
+			return cmp.Compare(len(a), len(b))
+		})
+	candidates:
+		for id := range rarest {
+			for _, set := range sets {
+				if !set[id] {
+					continue candidates
+				}
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+	quotes := make([]models.Quote, 0, len(ids))
+	for _, id := range ids {
+		quotes = append(quotes, s.quotes[s.byID[id]])
+	}
+	return quotes
+}
+
+// link adds the live quote to the tag and author indexes.
+func (s *QuoteStorage) link(quote models.Quote) {
+	s.addTags(quote)
+	key := models.NormalizeAuthor(quote.Author)
+	if s.authors[key] == nil {
+		s.authors[key] = make(map[int64]bool)
+	}
+	s.authors[key][quote.ID] = true
+}
+
+// unlink removes the quote from the tag and author indexes.
+func (s *QuoteStorage) unlink(quote models.Quote) {
+	s.removeTags(quote)
+	key := models.NormalizeAuthor(quote.Author)
+	delete(s.authors[key], quote.ID)
+	if len(s.authors[key]) == 0 {
+		delete(s.authors, key)
+	}
+}
+
+func (s *QuoteStorage) addTags(quote models.Quote) {
+	for _, tag := range quote.Tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[int64]bool)
+		}
+		s.tags[tag][quote.ID] = true
+	}
+}
+
+func (s *QuoteStorage) removeTags(quote models.Quote) {
+	for _, tag := range quote.Tags {
+		delete(s.tags[tag], quote.ID)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
 		}
 	}
-	return fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
 }
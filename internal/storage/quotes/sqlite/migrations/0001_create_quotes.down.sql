@@ -0,0 +1,2 @@
+DROP INDEX idx_quotes_author;
+DROP TABLE quotes;
@@ -0,0 +1,8 @@
+CREATE TABLE quotes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    author     TEXT NOT NULL,
+    text       TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_quotes_author ON quotes (author);
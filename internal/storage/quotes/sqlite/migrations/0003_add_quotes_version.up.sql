@@ -0,0 +1 @@
+ALTER TABLE quotes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+DROP INDEX idx_quotes_created_at;
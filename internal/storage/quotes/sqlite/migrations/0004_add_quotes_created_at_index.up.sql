@@ -0,0 +1 @@
+CREATE INDEX idx_quotes_created_at ON quotes (created_at);
@@ -0,0 +1,22 @@
+-- Full-text index of quote texts kept in sync with the quotes table.
+CREATE VIRTUAL TABLE quotes_fts USING fts5(
+    text,
+    content = 'quotes',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 0'
+);
+
+CREATE TRIGGER quotes_fts_insert AFTER INSERT ON quotes BEGIN
+    INSERT INTO quotes_fts (rowid, text) VALUES (new.id, new.text);
+END;
+
+CREATE TRIGGER quotes_fts_delete AFTER DELETE ON quotes BEGIN
+    INSERT INTO quotes_fts (quotes_fts, rowid, text) VALUES ('delete', old.id, old.text);
+END;
+
+CREATE TRIGGER quotes_fts_update AFTER UPDATE OF text ON quotes BEGIN
+    INSERT INTO quotes_fts (quotes_fts, rowid, text) VALUES ('delete', old.id, old.text);
+    INSERT INTO quotes_fts (rowid, text) VALUES (new.id, new.text);
+END;
+
+INSERT INTO quotes_fts (quotes_fts) VALUES ('rebuild');
@@ -0,0 +1,2 @@
+DROP INDEX idx_quotes_author_key;
+ALTER TABLE quotes DROP COLUMN author_key;
@@ -0,0 +1,7 @@
+-- author_key is the normalized author name used for lookups, see
+-- models.NormalizeAuthor. normalize_author is registered by the storage.
+ALTER TABLE quotes ADD COLUMN author_key TEXT NOT NULL DEFAULT '';
+
+UPDATE quotes SET author_key = normalize_author(author);
+
+CREATE INDEX idx_quotes_author_key ON quotes (author_key);
@@ -0,0 +1,4 @@
+DROP INDEX idx_quotes_author_id;
+ALTER TABLE quotes DROP COLUMN author_id;
+DROP TABLE author_names;
+DROP TABLE authors;
@@ -0,0 +1,34 @@
+CREATE TABLE authors (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT NOT NULL,
+    bio        TEXT NOT NULL DEFAULT '',
+    birth_year INTEGER NOT NULL DEFAULT 0,
+    death_year INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP
+);
+
+-- author_names holds the normalized name and every alias of each author, so
+-- that a name can belong to a single author only.
+CREATE TABLE author_names (
+    key       TEXT PRIMARY KEY,
+    author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+    name      TEXT NOT NULL,
+    alias     INTEGER NOT NULL
+);
+
+CREATE INDEX idx_author_names_author_id ON author_names (author_id);
+
+ALTER TABLE quotes ADD COLUMN author_id INTEGER;
+
+-- Derive authors from the names of existing quotes, spelled as in the oldest
+-- quote of each author.
+INSERT INTO authors (name, created_at)
+SELECT author, min(created_at) FROM quotes GROUP BY author_key ORDER BY min(id);
+
+INSERT INTO author_names (key, author_id, name, alias)
+SELECT normalize_author(name), id, name, 0 FROM authors;
+
+UPDATE quotes SET author_id = (SELECT author_id FROM author_names WHERE key = quotes.author_key);
+
+CREATE INDEX idx_quotes_author_id ON quotes (author_id);
@@ -0,0 +1 @@
+DROP TABLE quote_tags;
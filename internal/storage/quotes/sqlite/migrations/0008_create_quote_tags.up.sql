@@ -0,0 +1,7 @@
+CREATE TABLE quote_tags (
+    quote_id INTEGER NOT NULL REFERENCES quotes (id) ON DELETE CASCADE,
+    tag      TEXT NOT NULL,
+    PRIMARY KEY (quote_id, tag)
+);
+
+CREATE INDEX idx_quote_tags_tag ON quote_tags (tag);
@@ -0,0 +1,3 @@
+DROP INDEX idx_quotes_verification;
+ALTER TABLE quotes DROP COLUMN verification;
+ALTER TABLE quotes DROP COLUMN source;
@@ -0,0 +1,5 @@
+-- source is the JSON encoded models.Source, NULL for unsourced quotes.
+ALTER TABLE quotes ADD COLUMN source TEXT;
+ALTER TABLE quotes ADD COLUMN verification TEXT NOT NULL DEFAULT 'unverified';
+
+CREATE INDEX idx_quotes_verification ON quotes (verification);
@@ -0,0 +1 @@
+ALTER TABLE quotes ADD COLUMN rating INTEGER NOT NULL DEFAULT 0;
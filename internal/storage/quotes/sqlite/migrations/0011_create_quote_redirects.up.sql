@@ -0,0 +1,6 @@
+CREATE TABLE quote_redirects (
+    id       INTEGER PRIMARY KEY,
+    quote_id INTEGER NOT NULL REFERENCES quotes (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_quote_redirects_quote_id ON quote_redirects (quote_id);
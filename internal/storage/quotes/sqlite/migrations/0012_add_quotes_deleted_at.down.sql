@@ -0,0 +1,3 @@
+DROP INDEX idx_quotes_deleted_at;
+
+ALTER TABLE quotes DROP COLUMN deleted_at;
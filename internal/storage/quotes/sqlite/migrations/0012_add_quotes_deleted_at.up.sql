@@ -0,0 +1,3 @@
+ALTER TABLE quotes ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_quotes_deleted_at ON quotes (deleted_at) WHERE deleted_at IS NOT NULL;
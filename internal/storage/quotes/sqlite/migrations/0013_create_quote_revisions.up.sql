@@ -0,0 +1,14 @@
+-- quote_revisions keeps every revision of a quote, including the quotes
+-- that have been purged, so it has no foreign key to quotes. The quote column
+-- holds the quote as it was, encoded as JSON.
+CREATE TABLE quote_revisions (
+    quote_id    INTEGER NOT NULL,
+    number      INTEGER NOT NULL,
+    action      TEXT NOT NULL,
+    changed_by  TEXT NOT NULL DEFAULT '',
+    changed_at  TIMESTAMP NOT NULL,
+    quote       TEXT NOT NULL,
+    reverted_to INTEGER NOT NULL DEFAULT 0,
+    merged_into INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (quote_id, number)
+);
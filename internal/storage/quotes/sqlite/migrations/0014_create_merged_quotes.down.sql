@@ -0,0 +1 @@
+DROP TABLE merged_quotes;
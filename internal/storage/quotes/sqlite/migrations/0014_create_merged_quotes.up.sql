@@ -0,0 +1,6 @@
+-- merged_quotes remembers when the quotes merged into others were created,
+-- as the quotes themselves are deleted by the merge.
+CREATE TABLE merged_quotes (
+    id         INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL
+);
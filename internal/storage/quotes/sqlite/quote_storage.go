@@ -0,0 +1,1182 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+	"quotes/internal/storage/search"
+
+	"modernc.org/sqlite"
+)
+
+var _ services.QuoteRepository = (*QuoteStorage)(nil)
+
+const revisionColumns = "quote_id, number, action, changed_by, changed_at, quote, reverted_to, merged_into"
+
+// quoteColumns selects a quote from the quotes table, with its tags as a JSON
+// array.
+const quoteColumns = "quotes.id, quotes.author, quotes.text, quotes.created_at, quotes.updated_at, quotes.version, quotes.author_id, " +
+	"quotes.source, quotes.verification, quotes.rating, quotes.deleted_at, " +
+	"(SELECT json_group_array(tag) FROM (SELECT tag FROM quote_tags WHERE quote_id = quotes.id ORDER BY tag))"
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns the schema migrations of the storage.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+func init() {
+	// SQLite's lower() only folds ASCII letters.
+	sqlite.MustRegisterDeterministicScalarFunction("casefold", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+		return strings.ToLower(s), nil
+	})
+	sqlite.MustRegisterDeterministicScalarFunction("normalize_author", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+		return models.NormalizeAuthor(s), nil
+	})
+	sqlite.MustRegisterDeterministicScalarFunction("fuzzy_author_match", 2, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		query, _ := args[0].(string)
+		key, _ := args[1].(string)
+		return models.FuzzyAuthorMatch(query, key), nil
+	})
+	// The logarithm of an exponentially distributed random number, used for
+	// weighted random picks.
+	sqlite.MustRegisterScalarFunction("random_log_key", 0, func(_ *sqlite.FunctionContext, _ []driver.Value) (driver.Value, error) {
+		return math.Log(rand.ExpFloat64()), nil
+	})
+}
+
+// Open opens an SQLite database, e.g. "file:quotes.db" or ":memory:".
+func Open(dsn string) (*sql.DB, error) {
+	const op = "storage.quotes.sqlite.Open"
+
+	// Times are written as "2006-01-02 15:04:05.999999999-07:00" in UTC so
+	// that they can be compared as strings.
+	if !strings.Contains(dsn, "_time_format=") {
+		if strings.Contains(dsn, "?") {
+			dsn += "&_time_format=sqlite"
+		} else {
+			dsn += "?_time_format=sqlite"
+		}
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// SQLite allows a single writer at a time, and every connection to
+	// ":memory:" would get its own empty database.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return db, nil
+}
+
+// QuoteStorage keeps quotes in the database and adds the revisions made by
+// each change in the transaction making it.
+type QuoteStorage struct {
+	db *sql.DB
+	// changedBy is recorded as the maker of the changes.
+	changedBy string
+}
+
+func NewQuoteStorage(db *sql.DB) *QuoteStorage {
+	return &QuoteStorage{
+		db: db,
+	}
+}
+
+// By returns a storage sharing the database of s that records changedBy as
+// the maker of its changes.
+func (s *QuoteStorage) By(changedBy string) services.QuoteRepository {
+	return &QuoteStorage{db: s.db, changedBy: changedBy}
+}
+
+func (s *QuoteStorage) Create(quote *models.Quote) error {
+	const op = "storage.quotes.sqlite.Create"
+
+	if quote.Author == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthor)
+	}
+	if quote.Text == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyText)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	created, err := insertQuote(tx, *quote)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.record(tx, models.Revision{Action: models.RevisionCreate, Quote: created}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	*quote = created
+	return nil
+}
+
+// CreateBatch creates all the quotes in one transaction or, if any of them is
+// invalid, none.
+func (s *QuoteStorage) CreateBatch(quotes []models.Quote) error {
+	const op = "storage.quotes.sqlite.CreateBatch"
+
+	if err := s.createBatch(quotes, false); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CreateUnique creates the quotes like CreateBatch unless any of them repeats
+// a live quote of its author, looking for the duplicates in the same
+// transaction.
+func (s *QuoteStorage) CreateUnique(quotes []models.Quote) error {
+	const op = "storage.quotes.sqlite.CreateUnique"
+
+	if err := s.createBatch(quotes, true); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) createBatch(quotes []models.Quote, unique bool) error {
+	for i, quote := range quotes {
+		if quote.Author == "" {
+			return fmt.Errorf("quote %d: %w", i, storage.ErrEmptyAuthor)
+		}
+		if quote.Text == "" {
+			return fmt.Errorf("quote %d: %w", i, storage.ErrEmptyText)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if unique {
+		for i, quote := range quotes {
+			key := models.NormalizeAuthor(quote.Author)
+			if key == "" {
+				continue
+			}
+			known, err := queryQuotes(tx, "SELECT "+quoteColumns+" FROM quotes WHERE author_key = ? AND deleted_at IS NULL ORDER BY id", key)
+			if err != nil {
+				return fmt.Errorf("quote %d: %w", i, err)
+			}
+			if known, exact := models.FindDuplicate(quote, known); known != nil {
+				return fmt.Errorf("quote %d: %w", i, &storage.DuplicateError{ID: known.ID, Exact: exact})
+			}
+		}
+	}
+
+	created := make([]models.Quote, len(quotes))
+	for i, quote := range quotes {
+		if created[i], err = insertQuote(tx, quote); err != nil {
+			return fmt.Errorf("quote %d: %w", i, err)
+		}
+		if err := s.record(tx, models.Revision{Action: models.RevisionCreate, Quote: created[i]}); err != nil {
+			return fmt.Errorf("quote %d: %w", i, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	copy(quotes, created)
+	return nil
+}
+
+// insertQuote inserts a new valid quote and returns it as stored.
+func insertQuote(tx *sql.Tx, quote models.Quote) (models.Quote, error) {
+	source, err := encodeSource(quote.Source)
+	if err != nil {
+		return quote, err
+	}
+	createdAt := time.Now().UTC()
+	tags := models.NormalizeTags(quote.Tags)
+	verification := quote.Verification.Or(models.VerificationUnverified)
+	res, err := tx.Exec(
+		`INSERT INTO quotes (author, author_key, author_id, text, created_at, source, verification, rating)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		quote.Author, models.NormalizeAuthor(quote.Author), nullID(quote.AuthorID), quote.Text, createdAt,
+		source, verification, quote.Rating,
+	)
+	if err != nil {
+		return quote, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return quote, err
+	}
+	if err := insertTags(tx, id, tags); err != nil {
+		return quote, err
+	}
+
+	quote.ID = id
+	quote.CreatedAt = createdAt
+	quote.UpdatedAt = time.Time{}
+	quote.DeletedAt = time.Time{}
+	quote.Version = 1
+	quote.Tags = tags
+	quote.Verification = verification
+	return quote, nil
+}
+
+func (s *QuoteStorage) GetAll() ([]models.Quote, error) {
+	const op = "storage.quotes.sqlite.GetAll"
+
+	quotes, err := s.query("SELECT " + quoteColumns + " FROM quotes WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quotes, nil
+}
+
+func (s *QuoteStorage) List(query models.QuoteQuery, page models.Page) (models.QuotePage, error) {
+	const op = "storage.quotes.sqlite.List"
+
+	order := models.OrderBy(page.Sort)
+	conds, args := filterConditions(query)
+	if page.After != nil {
+		cond, condArgs, err := afterCondition(*page.After, order)
+		if err != nil {
+			return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+		}
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+	orderBy, err := orderByClause(order)
+	if err != nil {
+		return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt := "SELECT " + quoteColumns + " FROM quotes"
+	if len(conds) > 0 {
+		stmt += " WHERE " + strings.Join(conds, " AND ")
+	}
+	stmt += " ORDER BY " + orderBy
+	// One extra row tells whether there is a next page.
+	if page.Limit > 0 {
+		stmt += " LIMIT ?"
+		args = append(args, page.Limit+1)
+	}
+
+	quotes, err := s.query(stmt, args...)
+	if err != nil {
+		return models.QuotePage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return models.NewQuotePage(quotes, page.Limit), nil
+}
+
+func (s *QuoteStorage) Search(query string, limit int) ([]models.ScoredQuote, error) {
+	const op = "storage.quotes.sqlite.Search"
+
+	// Tokenizing the query the same way as the memory index keeps the FTS5
+	// syntax out of user input: every word becomes a quoted prefix phrase.
+	terms := search.QueryTerms(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEmptySearchQuery)
+	}
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		phrases = append(phrases, `"`+term+`"*`)
+	}
+
+	stmt := `
+		SELECT ` + quoteColumns + `, -bm25(quotes_fts) AS score
+		FROM quotes_fts JOIN quotes ON quotes.id = quotes_fts.rowid
+		WHERE quotes_fts MATCH ? AND quotes.deleted_at IS NULL
+		ORDER BY score DESC, quotes.id`
+	args := []any{strings.Join(phrases, " OR ")}
+	if limit > 0 {
+		stmt += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make([]models.ScoredQuote, 0)
+	for rows.Next() {
+		var score float64
+		quote, err := scanQuote(rows, &score)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, models.ScoredQuote{Quote: quote, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+func (s *QuoteStorage) GetByID(id int64) (*models.Quote, error) {
+	const op = "storage.quotes.sqlite.GetByID"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	row := s.db.QueryRow("SELECT "+quoteColumns+" FROM quotes WHERE id = ? AND deleted_at IS NULL", id)
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &quote, nil
+}
+
+func (s *QuoteStorage) Created(before time.Time) ([]models.CreatedQuote, error) {
+	const op = "storage.quotes.sqlite.Created"
+
+	rows, err := s.db.Query(`
+		SELECT id, created_at FROM quotes WHERE created_at < ?
+		UNION ALL
+		SELECT id, created_at FROM merged_quotes WHERE created_at < ?
+		ORDER BY id`, before.UTC(), before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	created := make([]models.CreatedQuote, 0)
+	for rows.Next() {
+		var quote models.CreatedQuote
+		if err := rows.Scan(&quote.ID, &quote.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		created = append(created, quote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return created, nil
+}
+
+func (s *QuoteStorage) GetRandom(query models.QuoteQuery, weight models.RandomWeight) (*models.Quote, error) {
+	const op = "storage.quotes.sqlite.GetRandom"
+
+	if !query.IsZero() || weight != models.WeightUniform {
+		return s.getRandomMatching(query, weight)
+	}
+
+	// Skips a random number of live quotes, so every one of them is equally
+	// likely to be chosen however the IDs are spread. The modulo is taken
+	// before abs, which overflows on the smallest integer, and is NULL when
+	// there are no quotes.
+	row := s.db.QueryRow(`
+		SELECT ` + quoteColumns + ` FROM quotes
+		WHERE deleted_at IS NULL
+		ORDER BY id
+		LIMIT 1 OFFSET (
+			SELECT coalesce(abs(random() % count(*)), 0)
+			FROM quotes WHERE deleted_at IS NULL
+		)`)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoQuotesAvailable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &quote, nil
+}
+
+// getRandomMatching scans the matching quotes once, like the memory storage:
+// each quote gets the key random_log_key() - log(weight), and the smallest
+// key wins.
+func (s *QuoteStorage) getRandomMatching(query models.QuoteQuery, weight models.RandomWeight) (*models.Quote, error) {
+	const op = "storage.quotes.sqlite.GetRandom"
+
+	// The log weights match models.RandomWeight.LogWeight.
+	var logWeight string
+	var args []any
+	switch weight {
+	case models.WeightUniform:
+		logWeight = "0"
+	case models.WeightRating:
+		logWeight = "ln(rating + 1)"
+	case models.WeightRecency:
+		logWeight = "unixepoch(created_at, 'subsec') * ?"
+		args = append(args, math.Ln2/models.RecencyHalfLife.Seconds())
+	default:
+		return nil, fmt.Errorf("%s: unknown weight %q", op, weight)
+	}
+
+	stmt := "SELECT " + quoteColumns + " FROM quotes"
+	conds, condArgs := filterConditions(query)
+	if len(conds) > 0 {
+		stmt += " WHERE " + strings.Join(conds, " AND ")
+	}
+	stmt += " ORDER BY random_log_key() - " + logWeight + " LIMIT 1"
+
+	quote, err := scanQuote(s.db.QueryRow(stmt, append(condArgs, args...)...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoQuotesAvailable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &quote, nil
+}
+
+func (s *QuoteStorage) GetByAuthor(author string) ([]models.Quote, error) {
+	const op = "storage.quotes.sqlite.GetByAuthor"
+
+	key := models.NormalizeAuthor(author)
+	if key == "" {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthor)
+	}
+
+	quotes, err := s.query("SELECT "+quoteColumns+" FROM quotes WHERE author_key = ? AND deleted_at IS NULL ORDER BY id", key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quotes, nil
+}
+
+func (s *QuoteStorage) AuthorNames() ([]string, error) {
+	const op = "storage.quotes.sqlite.AuthorNames"
+
+	rows, err := s.db.Query("SELECT DISTINCT author FROM quotes WHERE deleted_at IS NULL ORDER BY author")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return names, nil
+}
+
+func (s *QuoteStorage) Tags() ([]models.TagCount, error) {
+	const op = "storage.quotes.sqlite.Tags"
+
+	rows, err := s.db.Query(`
+		SELECT tag, count(*) AS n FROM quote_tags
+		WHERE quote_id IN (SELECT id FROM quotes WHERE deleted_at IS NULL)
+		GROUP BY tag ORDER BY n DESC, tag`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagCount, 0)
+	for rows.Next() {
+		var tag models.TagCount
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tags, nil
+}
+
+func (s *QuoteStorage) Update(quote *models.Quote) error {
+	const op = "storage.quotes.sqlite.Update"
+
+	if err := s.replace(quote, models.Revision{Action: models.RevisionUpdate}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) Revert(quote *models.Quote, number int64) error {
+	const op = "storage.quotes.sqlite.Revert"
+
+	if err := s.replace(quote, models.Revision{Action: models.RevisionRevert, RevertedTo: number}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UpdateBatch replaces all the quotes in one transaction or, if any of them
+// is invalid, missing or stale, none.
+func (s *QuoteStorage) UpdateBatch(quotes []models.Quote) error {
+	const op = "storage.quotes.sqlite.UpdateBatch"
+
+	ids := make([]int64, 0, len(quotes))
+	for i, quote := range quotes {
+		if quote.ID <= 0 {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrInvalidID)
+		}
+		if quote.Author == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyAuthor)
+		}
+		if quote.Text == "" {
+			return fmt.Errorf("%s: quote %d: %w", op, i, storage.ErrEmptyText)
+		}
+		ids = append(ids, quote.ID)
+	}
+	if len(slices.Compact(slices.Sorted(slices.Values(ids)))) != len(ids) {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	updated := make([]models.Quote, len(quotes))
+	for i, quote := range quotes {
+		if updated[i], err = updateQuote(tx, quote); err != nil {
+			return fmt.Errorf("%s: quote %d: %w", op, i, err)
+		}
+		if err := s.record(tx, models.Revision{Action: models.RevisionUpdate, Quote: updated[i]}); err != nil {
+			return fmt.Errorf("%s: quote %d: %w", op, i, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	copy(quotes, updated)
+	return nil
+}
+
+func (s *QuoteStorage) LinkAuthor(id int64, authorID int64) error {
+	const op = "storage.quotes.sqlite.LinkAuthor"
+
+	res, err := s.db.Exec("UPDATE quotes SET author_id = ? WHERE id = ? AND deleted_at IS NULL", nullID(authorID), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	return nil
+}
+
+// replace replaces the quote like Update and adds the revision of it.
+func (s *QuoteStorage) replace(quote *models.Quote, revision models.Revision) error {
+	if quote.ID <= 0 {
+		return storage.ErrInvalidID
+	}
+	if quote.Author == "" {
+		return storage.ErrEmptyAuthor
+	}
+	if quote.Text == "" {
+		return storage.ErrEmptyText
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updated, err := updateQuote(tx, *quote)
+	if err != nil {
+		return err
+	}
+	revision.Quote = updated
+	if err := s.record(tx, revision); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	*quote = updated
+	return nil
+}
+
+// updateQuote replaces a valid quote and returns it as stored.
+func updateQuote(tx *sql.Tx, quote models.Quote) (models.Quote, error) {
+	source, err := encodeSource(quote.Source)
+	if err != nil {
+		return quote, err
+	}
+	updatedAt := time.Now().UTC()
+	tags := models.NormalizeTags(quote.Tags)
+	verification := quote.Verification.Or(models.VerificationUnverified)
+	row := tx.QueryRow(
+		`UPDATE quotes SET author = ?, author_key = ?, author_id = ?, text = ?, updated_at = ?,
+			source = ?, verification = ?, rating = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		RETURNING created_at, version`,
+		quote.Author, models.NormalizeAuthor(quote.Author), nullID(quote.AuthorID), quote.Text, updatedAt,
+		source, verification, quote.Rating,
+		quote.ID, quote.Version, quote.Version,
+	)
+	var createdAt time.Time
+	var version int64
+	if err := row.Scan(&createdAt, &version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return quote, missingOrStale(tx, quote.ID)
+		}
+		return quote, err
+	}
+	if _, err := tx.Exec("DELETE FROM quote_tags WHERE quote_id = ?", quote.ID); err != nil {
+		return quote, err
+	}
+	if err := insertTags(tx, quote.ID, tags); err != nil {
+		return quote, err
+	}
+
+	quote.CreatedAt = createdAt
+	quote.UpdatedAt = updatedAt
+	quote.DeletedAt = time.Time{}
+	quote.Version = version
+	quote.Tags = tags
+	quote.Verification = verification
+	return quote, nil
+}
+
+// Merge replaces the survivor like Update and deletes the merged quotes,
+// redirecting their IDs, and the IDs redirected to them, to the survivor in
+// one transaction.
+func (s *QuoteStorage) Merge(survivor *models.Quote, merged []models.Quote) error {
+	const op = "storage.quotes.sqlite.Merge"
+
+	ids := make([]int64, 0, len(merged))
+	for _, quote := range merged {
+		ids = append(ids, quote.ID)
+	}
+	if survivor.ID <= 0 || slices.Contains(ids, survivor.ID) ||
+		len(slices.Compact(slices.Sorted(slices.Values(ids)))) != len(ids) {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+	if survivor.Author == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyAuthor)
+	}
+	if survivor.Text == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrEmptyText)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	updated, err := updateQuote(tx, *survivor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.record(tx, models.Revision{Action: models.RevisionUpdate, Quote: updated}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, m := range merged {
+		id := m.ID
+		row := tx.QueryRow("SELECT "+quoteColumns+" FROM quotes WHERE id = ? AND deleted_at IS NULL", id)
+		quote, err := scanQuote(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: quote %d: %w", op, id, storage.ErrQuoteNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if m.Version != 0 && m.Version != quote.Version {
+			return fmt.Errorf("%s: quote %d: %w", op, id, storage.ErrVersionMismatch)
+		}
+		revision := models.Revision{Action: models.RevisionMerge, Quote: quote, MergedInto: survivor.ID}
+		if err := s.record(tx, revision); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		// Redirects are moved off the quote before deleting it cascades to them.
+		if _, err := tx.Exec("UPDATE quote_redirects SET quote_id = ? WHERE quote_id = ?", survivor.ID, id); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO merged_quotes (id, created_at) SELECT id, created_at FROM quotes WHERE id = ?", id,
+		); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.Exec("DELETE FROM quotes WHERE id = ?", id); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.Exec("INSERT INTO quote_redirects (id, quote_id) VALUES (?, ?)", id, survivor.ID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	*survivor = updated
+	return nil
+}
+
+// Redirect returns the ID of the quote the quote with the given ID was merged
+// into.
+func (s *QuoteStorage) Redirect(id int64) (int64, error) {
+	const op = "storage.quotes.sqlite.Redirect"
+
+	var to int64
+	row := s.db.QueryRow(`
+		SELECT quote_id FROM quote_redirects
+		JOIN quotes ON quotes.id = quote_redirects.quote_id
+		WHERE quote_redirects.id = ? AND quotes.deleted_at IS NULL`, id)
+	if err := row.Scan(&to); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return to, nil
+}
+
+func (s *QuoteStorage) Delete(id int64, version int64) error {
+	const op = "storage.quotes.sqlite.Delete"
+
+	if id <= 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(
+		"UPDATE quotes SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?) RETURNING "+quoteColumns,
+		time.Now().UTC(), id, version, version,
+	)
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, missingOrStale(tx, id))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.record(tx, models.Revision{Action: models.RevisionDelete, Quote: quote}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *QuoteStorage) Trash() ([]models.Quote, error) {
+	const op = "storage.quotes.sqlite.Trash"
+
+	quotes, err := s.query("SELECT " + quoteColumns + " FROM quotes WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return quotes, nil
+}
+
+func (s *QuoteStorage) Undelete(id int64) (*models.Quote, error) {
+	const op = "storage.quotes.sqlite.Undelete"
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(
+		"UPDATE quotes SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL RETURNING "+quoteColumns, id)
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrQuoteNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.record(tx, models.Revision{Action: models.RevisionRestore, Quote: quote}); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &quote, nil
+}
+
+// Purge removes the quotes deleted before the time for good, adding a
+// revision of each of them in the same transaction.
+func (s *QuoteStorage) Purge(before time.Time) (int, error) {
+	const op = "storage.quotes.sqlite.Purge"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT "+quoteColumns+" FROM quotes WHERE deleted_at < ? ORDER BY id", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	var purged []models.Quote
+	for rows.Next() {
+		quote, err := scanQuote(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		purged = append(purged, quote)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, quote := range purged {
+		if err := s.record(tx, models.Revision{Action: models.RevisionPurge, Quote: quote}); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.Exec("DELETE FROM quotes WHERE id = ?", quote.ID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(purged), nil
+}
+
+func (s *QuoteStorage) Revisions(quoteID int64) ([]models.Revision, error) {
+	const op = "storage.quotes.sqlite.Revisions"
+
+	rows, err := s.db.Query("SELECT "+revisionColumns+" FROM quote_revisions WHERE quote_id = ? ORDER BY number", quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.Revision, 0)
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return revisions, nil
+}
+
+func (s *QuoteStorage) Revision(quoteID int64, number int64) (*models.Revision, error) {
+	const op = "storage.quotes.sqlite.Revision"
+
+	row := s.db.QueryRow("SELECT "+revisionColumns+" FROM quote_revisions WHERE quote_id = ? AND number = ?", quoteID, number)
+	revision, err := scanRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &revision, nil
+}
+
+// record adds the revision made by a change in the transaction making it,
+// numbering it after the last revision of its quote.
+func (s *QuoteStorage) record(tx *sql.Tx, revision models.Revision) error {
+	quote, err := json.Marshal(revision.Quote)
+	if err != nil {
+		return fmt.Errorf("encode revision: %w", err)
+	}
+	_, err = tx.Exec(
+		`INSERT INTO quote_revisions (`+revisionColumns+`)
+		SELECT ?, coalesce(max(number), 0) + 1, ?, ?, ?, ?, ?, ?
+		FROM quote_revisions WHERE quote_id = ?`,
+		revision.Quote.ID, revision.Action, s.changedBy, time.Now().UTC(), string(quote),
+		revision.RevertedTo, revision.MergedInto, revision.Quote.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("add revision: %w", err)
+	}
+	return nil
+}
+
+// missingOrStale tells why a conditional statement did not affect the quote.
+func missingOrStale(db querier, id int64) error {
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM quotes WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return storage.ErrVersionMismatch
+	}
+	return storage.ErrQuoteNotFound
+}
+
+func (s *QuoteStorage) query(query string, args ...any) ([]models.Quote, error) {
+	return queryQuotes(s.db, query, args...)
+}
+
+func queryQuotes(q querier, query string, args ...any) ([]models.Quote, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotes := make([]models.Quote, 0)
+	for rows.Next() {
+		quote, err := scanQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, rows.Err()
+}
+
+// filterConditions translates the query into SQL conditions with their arguments.
+func filterConditions(query models.QuoteQuery) ([]string, []any) {
+	conds := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if query.Author != "" {
+		if query.AuthorFuzzy {
+			conds = append(conds, "fuzzy_author_match(?, author_key)")
+		} else {
+			conds = append(conds, "author_key = ?")
+		}
+		args = append(args, models.NormalizeAuthor(query.Author))
+	}
+	if query.AuthorID != 0 {
+		conds = append(conds, "author_id = ?")
+		args = append(args, query.AuthorID)
+	}
+	if query.TextContains != "" {
+		conds = append(conds, "instr(casefold(text), casefold(?)) > 0")
+		args = append(args, query.TextContains)
+	}
+	if !query.CreatedAfter.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, query.CreatedAfter.UTC())
+	}
+	if !query.CreatedBefore.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, query.CreatedBefore.UTC())
+	}
+	if query.MaxLength > 0 {
+		conds = append(conds, "length(text) <= ?")
+		args = append(args, query.MaxLength)
+	}
+	if query.Verification != "" {
+		conds = append(conds, "verification = ?")
+		args = append(args, query.Verification)
+	}
+	if len(query.Tags) > 0 {
+		tags := make([]string, 0, len(query.Tags))
+		for _, tag := range query.Tags {
+			tags = append(tags, models.NormalizeTag(tag))
+		}
+		tags = slices.Compact(slices.Sorted(slices.Values(tags)))
+
+		cond := "id IN (SELECT quote_id FROM quote_tags WHERE tag IN (?" + strings.Repeat(", ?", len(tags)-1) + ")"
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+		if !query.AnyTag {
+			cond += " GROUP BY quote_id HAVING count(*) = ?"
+			args = append(args, len(tags))
+		}
+		conds = append(conds, cond+")")
+	}
+	return conds, args
+}
+
+// sortColumn returns the SQL expression of the sort field and the value of
+// the cursor for it.
+func sortColumn(field models.SortField, cursor models.Cursor) (string, any, error) {
+	switch field {
+	case models.SortByID:
+		return "id", cursor.ID, nil
+	case models.SortByAuthor:
+		return "author", cursor.Author, nil
+	case models.SortByCreatedAt:
+		return "created_at", cursor.CreatedAt.UTC(), nil
+	case models.SortByTextLength:
+		return "length(text)", cursor.TextLength, nil
+	}
+	return "", nil, fmt.Errorf("unknown sort field %q", field)
+}
+
+func orderByClause(order []models.SortKey) (string, error) {
+	terms := make([]string, 0, len(order))
+	for _, key := range order {
+		column, _, err := sortColumn(key.Field, models.Cursor{})
+		if err != nil {
+			return "", err
+		}
+		if key.Desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	return strings.Join(terms, ", "), nil
+}
+
+// afterCondition selects the rows that follow the cursor in the order:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func afterCondition(cursor models.Cursor, order []models.SortKey) (string, []any, error) {
+	var alternatives []string
+	var args []any
+	var equal []string
+	var equalArgs []any
+	for _, key := range order {
+		column, value, err := sortColumn(key.Field, cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		cmp := " > ?"
+		if key.Desc {
+			cmp = " < ?"
+		}
+		alternatives = append(alternatives, "("+strings.Join(append(slices.Clip(equal), column+cmp), " AND ")+")")
+		args = append(append(args, equalArgs...), value)
+		equal = append(equal, column+" = ?")
+		equalArgs = append(equalArgs, value)
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// scanQuote scans a row selected with quoteColumns, followed by the extra
+// columns, if any.
+func scanQuote(row scanner, extra ...any) (models.Quote, error) {
+	var quote models.Quote
+	var updatedAt, deletedAt sql.NullTime
+	var authorID sql.NullInt64
+	var source sql.NullString
+	var tags string
+	dest := append([]any{
+		&quote.ID, &quote.Author, &quote.Text, &quote.CreatedAt, &updatedAt, &quote.Version, &authorID,
+		&source, &quote.Verification, &quote.Rating, &deletedAt, &tags,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return quote, err
+	}
+	quote.UpdatedAt = updatedAt.Time
+	quote.DeletedAt = deletedAt.Time
+	quote.AuthorID = authorID.Int64
+	if source.Valid {
+		quote.Source = new(models.Source)
+		if err := json.Unmarshal([]byte(source.String), quote.Source); err != nil {
+			return quote, fmt.Errorf("decode source: %w", err)
+		}
+	}
+	if err := json.Unmarshal([]byte(tags), &quote.Tags); err != nil {
+		return quote, fmt.Errorf("decode tags: %w", err)
+	}
+	if len(quote.Tags) == 0 {
+		quote.Tags = nil
+	}
+	return quote, nil
+}
+
+func scanRevision(row scanner) (models.Revision, error) {
+	var revision models.Revision
+	var quote []byte
+	if err := row.Scan(&revision.QuoteID, &revision.Number, &revision.Action, &revision.ChangedBy,
+		&revision.ChangedAt, &quote, &revision.RevertedTo, &revision.MergedInto); err != nil {
+		return revision, err
+	}
+	if err := json.Unmarshal(quote, &revision.Quote); err != nil {
+		return revision, fmt.Errorf("decode quote of revision %d of quote %d: %w", revision.Number, revision.QuoteID, err)
+	}
+	return revision, nil
+}
+
+// encodeSource stores sources as JSON and missing ones as NULL.
+func encodeSource(source *models.Source) (sql.NullString, error) {
+	if source == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(source)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encode source: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func insertTags(tx *sql.Tx, id int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO quote_tags (quote_id, tag) VALUES (?, ?)", id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullID stores zero IDs as NULL.
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
@@ -0,0 +1,153 @@
+// Package search implements an in-memory inverted index over quote texts with
+// prefix matching and BM25 ranking.
+package search
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters, the same as the defaults of SQLite FTS5.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Tokenize splits the text into lowercase words: runs of letters and digits
+// in any script.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Hit is a document found by Search.
+type Hit struct {
+	ID    int64
+	Score float64
+}
+
+// Index is an inverted index of documents identified by int64 IDs. It is not
+// safe for concurrent use.
+type Index struct {
+	// postings maps a term to the number of its occurrences in each document.
+	postings map[string]map[int64]int
+	// terms holds the keys of postings in sorted order for prefix lookups.
+	terms []string
+	docs  map[int64]document
+	total int
+}
+
+type document struct {
+	// length is the number of tokens in the document.
+	length int
+	// terms are the distinct tokens of the document.
+	terms []string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[int64]int),
+		docs:     make(map[int64]document),
+	}
+}
+
+// Add indexes the text of the document, replacing its previous text.
+func (idx *Index) Add(id int64, text string) {
+	idx.Remove(id)
+
+	tokens := Tokenize(text)
+	doc := document{length: len(tokens)}
+	for _, term := range tokens {
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = make(map[int64]int)
+			idx.postings[term] = docs
+			i, _ := slices.BinarySearch(idx.terms, term)
+			idx.terms = slices.Insert(idx.terms, i, term)
+		}
+		if docs[id] == 0 {
+			doc.terms = append(doc.terms, term)
+		}
+		docs[id]++
+	}
+	idx.docs[id] = doc
+	idx.total += doc.length
+}
+
+// Remove drops the document from the index.
+func (idx *Index) Remove(id int64) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	delete(idx.docs, id)
+	idx.total -= doc.length
+
+	for _, term := range doc.terms {
+		docs := idx.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+			i, _ := slices.BinarySearch(idx.terms, term)
+			idx.terms = slices.Delete(idx.terms, i, i+1)
+		}
+	}
+}
+
+// Search returns the documents that contain a word starting with any of the
+// query words, best matches first.
+func (idx *Index) Search(query string) []Hit {
+	n := float64(len(idx.docs))
+	if n == 0 {
+		return nil
+	}
+	avg := float64(idx.total) / n
+
+	scores := make(map[int64]float64)
+	for _, prefix := range QueryTerms(query) {
+		// Every query word is a phrase of its own: its frequency in a document
+		// is the number of words there that start with it.
+		freqs := make(map[int64]int)
+		start, _ := slices.BinarySearch(idx.terms, prefix)
+		for _, term := range idx.terms[start:] {
+			if !strings.HasPrefix(term, prefix) {
+				break
+			}
+			for id, tf := range idx.postings[term] {
+				freqs[id] += tf
+			}
+		}
+
+		idf := math.Log((n - float64(len(freqs)) + 0.5) / (float64(len(freqs)) + 0.5))
+		if idf <= 0 {
+			idf = 1e-6
+		}
+		for id, tf := range freqs {
+			f := float64(tf)
+			scores[id] += idf * f * (k1 + 1) / (f + k1*(1-b+b*float64(idx.docs[id].length)/avg))
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	slices.SortFunc(hits, func(x, y Hit) int {
+		if c := cmp.Compare(y.Score, x.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.ID, y.ID)
+	})
+	return hits
+}
+
+// QueryTerms returns the distinct words of the query.
+func QueryTerms(query string) []string {
+	tokens := Tokenize(query)
+	slices.Sort(tokens)
+	return slices.Compact(tokens)
+}
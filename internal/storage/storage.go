@@ -1,6 +1,9 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrQuoteNotFound     = errors.New("quote not found")
@@ -8,4 +11,40 @@ var (
 	ErrEmptyText         = errors.New("quote text cannot be empty")
 	ErrNoQuotesAvailable = errors.New("no quotes available")
 	ErrInvalidID         = errors.New("invalid quote ID")
+	ErrVersionMismatch   = errors.New("quote version mismatch")
+	ErrEmptySearchQuery  = errors.New("search query has no words")
+	ErrAuthorNotFound    = errors.New("author not found")
+	ErrEmptyAuthorName   = errors.New("author name cannot be empty")
+	ErrAuthorExists      = errors.New("author name or alias is already taken")
+	ErrAuthorHasQuotes   = errors.New("author has quotes")
+	ErrInvalidSource     = errors.New("invalid quote source")
+	ErrInvalidStatus     = errors.New("invalid verification status")
+	ErrSourceRequired    = errors.New("verified quote must have a source")
+	ErrInvalidRating     = errors.New("invalid quote rating")
+	ErrBatchRejected     = errors.New("batch rejected because of invalid quotes")
+	ErrDuplicateQuote    = errors.New("quote already exists")
+	ErrRevisionNotFound  = errors.New("revision not found")
+	ErrDiffTooLarge      = errors.New("revisions differ in too many words to compare")
 )
+
+// DuplicateError reports the existing quote a new quote repeats. It matches
+// ErrDuplicateQuote.
+type DuplicateError struct {
+	// ID is the ID of the existing quote. It is zero for a quote repeating
+	// another quote of a batch that was not created.
+	ID int64
+	// Exact tells whether the texts differ only in case, spacing and
+	// punctuation rather than being merely similar.
+	Exact bool
+}
+
+func (e *DuplicateError) Error() string {
+	if e.Exact {
+		return fmt.Sprintf("%v: quote %d", ErrDuplicateQuote, e.ID)
+	}
+	return fmt.Sprintf("%v: quote %d is nearly the same", ErrDuplicateQuote, e.ID)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicateQuote
+}
@@ -0,0 +1,252 @@
+package storagetest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+)
+
+// AuthorFactory returns a new empty author repository. Cleanup of the
+// resources it holds should be registered with t.Cleanup.
+type AuthorFactory func(t *testing.T) services.AuthorRepository
+
+// RunAuthors runs the conformance suite against author repositories created
+// by newRepo.
+func RunAuthors(t *testing.T, newRepo AuthorFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo services.AuthorRepository)
+	}{
+		{"Create", testAuthorCreate},
+		{"CreateValidates", testAuthorCreateValidates},
+		{"CreateRejectsTakenNames", testAuthorCreateRejectsTakenNames},
+		{"GetAll", testAuthorGetAll},
+		{"FindByName", testAuthorFindByName},
+		{"Update", testAuthorUpdate},
+		{"UpdateRejectsTakenNames", testAuthorUpdateRejectsTakenNames},
+		{"Delete", testAuthorDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func createAuthor(t *testing.T, repo services.AuthorRepository, name string, aliases ...string) models.Author {
+	t.Helper()
+
+	author := models.Author{Name: name, Aliases: aliases}
+	if err := repo.Create(&author); err != nil {
+		t.Fatalf("Create(%q) failed: %v", name, err)
+	}
+	return author
+}
+
+func testAuthorCreate(t *testing.T, repo services.AuthorRepository) {
+	author := models.Author{
+		Name:      "  Confucius ",
+		Aliases:   []string{"Конфуций", "confucius", "Kong  Fuzi", "конфуций"},
+		Bio:       "Chinese philosopher",
+		BirthYear: -551,
+		DeathYear: -479,
+	}
+	if err := repo.Create(&author); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if author.ID <= 0 || author.CreatedAt.IsZero() {
+		t.Errorf("Create did not stamp the author: %+v", author)
+	}
+	if author.Name != "Confucius" {
+		t.Errorf("Create: got name %q, want %q", author.Name, "Confucius")
+	}
+	if want := []string{"Конфуций", "Kong Fuzi"}; !slices.Equal(author.Aliases, want) {
+		t.Errorf("Create: got aliases %q, want %q", author.Aliases, want)
+	}
+
+	got, err := repo.GetByID(author.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Name != author.Name || !slices.Equal(got.Aliases, author.Aliases) || got.Bio != author.Bio ||
+		got.BirthYear != author.BirthYear || got.DeathYear != author.DeathYear || !got.CreatedAt.Equal(author.CreatedAt) {
+		t.Errorf("GetByID: got %+v, want %+v", got, author)
+	}
+
+	second := createAuthor(t, repo, "Seneca")
+	if second.ID <= author.ID {
+		t.Errorf("Create assigned ID %d after %d", second.ID, author.ID)
+	}
+}
+
+func testAuthorCreateValidates(t *testing.T, repo services.AuthorRepository) {
+	for _, name := range []string{"", "   "} {
+		author := models.Author{Name: name}
+		if err := repo.Create(&author); !errors.Is(err, storage.ErrEmptyAuthorName) {
+			t.Errorf("Create(%q): got %v, want %v", name, err, storage.ErrEmptyAuthorName)
+		}
+	}
+	if _, err := repo.GetByID(0); !errors.Is(err, storage.ErrInvalidID) {
+		t.Errorf("GetByID(0): got %v, want %v", err, storage.ErrInvalidID)
+	}
+	if _, err := repo.GetByID(42); !errors.Is(err, storage.ErrAuthorNotFound) {
+		t.Errorf("GetByID(42): got %v, want %v", err, storage.ErrAuthorNotFound)
+	}
+}
+
+func testAuthorCreateRejectsTakenNames(t *testing.T, repo services.AuthorRepository) {
+	createAuthor(t, repo, "Confucius", "Конфуций")
+
+	for _, author := range []models.Author{
+		{Name: "CONFUCIUS"},
+		{Name: "конфуций"},
+		{Name: "Kong Fuzi", Aliases: []string{"Confucius"}},
+	} {
+		if err := repo.Create(&author); !errors.Is(err, storage.ErrAuthorExists) {
+			t.Errorf("Create(%q, %q): got %v, want %v", author.Name, author.Aliases, err, storage.ErrAuthorExists)
+		}
+	}
+
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("rejected authors were stored: %+v", all)
+	}
+}
+
+func testAuthorGetAll(t *testing.T, repo services.AuthorRepository) {
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if all == nil || len(all) != 0 {
+		t.Errorf("GetAll on empty repository: got %#v, want empty non-nil slice", all)
+	}
+
+	var want []int64
+	for _, name := range []string{"Seneca", "Confucius", "Aristotle"} {
+		want = append(want, createAuthor(t, repo, name, name+" the Wise").ID)
+	}
+
+	all, err = repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	var ids []int64
+	for _, author := range all {
+		ids = append(ids, author.ID)
+		if len(author.Aliases) != 1 || author.Aliases[0] != author.Name+" the Wise" {
+			t.Errorf("GetAll: got aliases %q for %q", author.Aliases, author.Name)
+		}
+	}
+	if !slices.Equal(ids, want) {
+		t.Errorf("GetAll: got IDs %v, want %v", ids, want)
+	}
+}
+
+func testAuthorFindByName(t *testing.T, repo services.AuthorRepository) {
+	confucius := createAuthor(t, repo, "Confucius", "Конфуций")
+	createAuthor(t, repo, "Seneca")
+
+	for _, name := range []string{"Confucius", "  confucius  ", "КОНФУЦИЙ"} {
+		author, err := repo.FindByName(name)
+		if err != nil {
+			t.Errorf("FindByName(%q) failed: %v", name, err)
+			continue
+		}
+		if author.ID != confucius.ID {
+			t.Errorf("FindByName(%q): got author %d, want %d", name, author.ID, confucius.ID)
+		}
+	}
+
+	for _, name := range []string{"Confucious", ""} {
+		if _, err := repo.FindByName(name); !errors.Is(err, storage.ErrAuthorNotFound) {
+			t.Errorf("FindByName(%q): got %v, want %v", name, err, storage.ErrAuthorNotFound)
+		}
+	}
+}
+
+func testAuthorUpdate(t *testing.T, repo services.AuthorRepository) {
+	original := createAuthor(t, repo, "Confucius", "Конфуций")
+
+	updated := models.Author{ID: original.ID, Name: "Kong Fuzi", Aliases: []string{"Confucius"}, Bio: "Philosopher"}
+	if err := repo.Update(&updated); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !updated.CreatedAt.Equal(original.CreatedAt) || updated.UpdatedAt.IsZero() {
+		t.Errorf("Update did not keep the creation time or stamp the update: %+v", updated)
+	}
+
+	got, err := repo.GetByID(original.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Name != "Kong Fuzi" || !slices.Equal(got.Aliases, []string{"Confucius"}) || got.Bio != "Philosopher" {
+		t.Errorf("GetByID after Update: got %+v", got)
+	}
+
+	// The dropped alias is free again, the new names lead to the author.
+	if _, err := repo.FindByName("Конфуций"); !errors.Is(err, storage.ErrAuthorNotFound) {
+		t.Errorf("FindByName of dropped alias: got %v, want %v", err, storage.ErrAuthorNotFound)
+	}
+	if author, err := repo.FindByName("kong fuzi"); err != nil || author.ID != original.ID {
+		t.Errorf("FindByName of new name: got %+v, %v", author, err)
+	}
+
+	missing := models.Author{ID: original.ID + 100, Name: "Nobody"}
+	if err := repo.Update(&missing); !errors.Is(err, storage.ErrAuthorNotFound) {
+		t.Errorf("Update of missing author: got %v, want %v", err, storage.ErrAuthorNotFound)
+	}
+	empty := models.Author{ID: original.ID, Name: " "}
+	if err := repo.Update(&empty); !errors.Is(err, storage.ErrEmptyAuthorName) {
+		t.Errorf("Update with empty name: got %v, want %v", err, storage.ErrEmptyAuthorName)
+	}
+}
+
+func testAuthorUpdateRejectsTakenNames(t *testing.T, repo services.AuthorRepository) {
+	confucius := createAuthor(t, repo, "Confucius", "Конфуций")
+	createAuthor(t, repo, "Seneca")
+
+	taken := models.Author{ID: confucius.ID, Name: "Confucius", Aliases: []string{"seneca"}}
+	if err := repo.Update(&taken); !errors.Is(err, storage.ErrAuthorExists) {
+		t.Errorf("Update with taken alias: got %v, want %v", err, storage.ErrAuthorExists)
+	}
+
+	got, err := repo.GetByID(confucius.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if !slices.Equal(got.Aliases, []string{"Конфуций"}) {
+		t.Errorf("rejected Update changed aliases: got %q", got.Aliases)
+	}
+	if author, err := repo.FindByName("Конфуций"); err != nil || author.ID != confucius.ID {
+		t.Errorf("rejected Update changed names: got %+v, %v", author, err)
+	}
+}
+
+func testAuthorDelete(t *testing.T, repo services.AuthorRepository) {
+	author := createAuthor(t, repo, "Confucius", "Конфуций")
+
+	if err := repo.Delete(author.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(author.ID); !errors.Is(err, storage.ErrAuthorNotFound) {
+		t.Errorf("second Delete: got %v, want %v", err, storage.ErrAuthorNotFound)
+	}
+	if _, err := repo.FindByName("Конфуций"); !errors.Is(err, storage.ErrAuthorNotFound) {
+		t.Errorf("FindByName after Delete: got %v, want %v", err, storage.ErrAuthorNotFound)
+	}
+
+	// The names of a deleted author can be used again, but not its ID.
+	again := createAuthor(t, repo, "Confucius")
+	if again.ID <= author.ID {
+		t.Errorf("Create reused ID: got %d after deleted %d", again.ID, author.ID)
+	}
+}
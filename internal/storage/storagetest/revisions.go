@@ -0,0 +1,183 @@
+package storagetest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+)
+
+// revisionActions returns the actions of the revisions of the quote, checking
+// that they are numbered in order.
+func revisionActions(t *testing.T, repo services.QuoteRepository, id int64) []models.RevisionAction {
+	t.Helper()
+
+	revisions, err := repo.Revisions(id)
+	if err != nil {
+		t.Fatalf("Revisions(%d) failed: %v", id, err)
+	}
+	var actions []models.RevisionAction
+	for i, revision := range revisions {
+		if revision.QuoteID != id || revision.Number != int64(i+1) {
+			t.Errorf("Revisions(%d): revision %d is %d of quote %d", id, i, revision.Number, revision.QuoteID)
+		}
+		actions = append(actions, revision.Action)
+	}
+	return actions
+}
+
+func testRevisions(t *testing.T, repo services.QuoteRepository) {
+	revisions, err := repo.Revisions(7)
+	if err != nil {
+		t.Fatalf("Revisions failed: %v", err)
+	}
+	if revisions == nil || len(revisions) != 0 {
+		t.Errorf("Revisions of missing quote: got %#v, want empty non-nil slice", revisions)
+	}
+
+	before := time.Now()
+	quote := models.Quote{
+		Author:       "Confucius",
+		AuthorID:     3,
+		Text:         "Life is simple",
+		Tags:         []string{"life", "wisdom"},
+		Source:       &models.Source{Title: "Analects", Year: -400},
+		Verification: models.VerificationVerified,
+		Rating:       5,
+	}
+	if err := repo.By("alice").Create(&quote); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	update := models.Quote{ID: quote.ID, Author: "Confucius", Text: "Life is really simple"}
+	if err := repo.By("bob").Update(&update); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.By("carol").Delete(quote.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.Undelete(quote.ID); err != nil {
+		t.Fatalf("Undelete failed: %v", err)
+	}
+	reverted := models.Quote{ID: quote.ID, Author: "Confucius", Text: "Life is simple"}
+	if err := repo.By("dave").Revert(&reverted, 1); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+
+	actions := revisionActions(t, repo, quote.ID)
+	want := []models.RevisionAction{
+		models.RevisionCreate, models.RevisionUpdate, models.RevisionDelete, models.RevisionRestore, models.RevisionRevert,
+	}
+	if !slices.Equal(actions, want) {
+		t.Errorf("Revisions: got actions %v, want %v", actions, want)
+	}
+
+	got, err := repo.Revision(quote.ID, 1)
+	if err != nil {
+		t.Fatalf("Revision failed: %v", err)
+	}
+	q := got.Quote
+	if got.ChangedBy != "alice" || q.Author != quote.Author || q.AuthorID != quote.AuthorID || q.Text != quote.Text ||
+		!q.CreatedAt.Equal(quote.CreatedAt) || q.Version != 1 || !slices.Equal(q.Tags, quote.Tags) ||
+		q.Source == nil || *q.Source != *quote.Source || q.Verification != quote.Verification || q.Rating != quote.Rating {
+		t.Errorf("Revision: got %+v, want quote %+v", got, quote)
+	}
+	if got.ChangedAt.Before(before.Add(-time.Second)) || got.ChangedAt.After(time.Now().Add(time.Second)) {
+		t.Errorf("Revision: got time %v, want about %v", got.ChangedAt, before)
+	}
+
+	if got, err := repo.Revision(quote.ID, 3); err != nil || got.ChangedBy != "carol" || got.Quote.DeletedAt.IsZero() {
+		t.Errorf("Revision of delete: got %+v, %v", got, err)
+	}
+	if got, err := repo.Revision(quote.ID, 4); err != nil || got.ChangedBy != "" || !got.Quote.DeletedAt.IsZero() {
+		t.Errorf("Revision of restore: got %+v, %v", got, err)
+	}
+	if got, err := repo.Revision(quote.ID, 5); err != nil || got.RevertedTo != 1 || got.ChangedBy != "dave" ||
+		got.Quote.Version != reverted.Version {
+		t.Errorf("Revision of revert: got %+v, %v", got, err)
+	}
+
+	for _, tt := range []struct{ quoteID, number int64 }{{quote.ID, 6}, {quote.ID, 0}, {quote.ID + 1, 1}} {
+		if _, err := repo.Revision(tt.quoteID, tt.number); !errors.Is(err, storage.ErrRevisionNotFound) {
+			t.Errorf("Revision(%d, %d): got %v, want %v", tt.quoteID, tt.number, err, storage.ErrRevisionNotFound)
+		}
+	}
+}
+
+func testRevisionsOfFailedChanges(t *testing.T, repo services.QuoteRepository) {
+	quote := create(t, repo, "Confucius", "First")
+
+	stale := models.Quote{ID: quote.ID, Author: "Confucius", Text: "Stale", Version: quote.Version + 1}
+	if err := repo.Update(&stale); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Fatalf("Update with stale version: got %v, want %v", err, storage.ErrVersionMismatch)
+	}
+	if err := repo.Delete(quote.ID, quote.Version+1); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Fatalf("Delete with stale version: got %v, want %v", err, storage.ErrVersionMismatch)
+	}
+	if err := repo.Merge(&models.Quote{ID: quote.ID, Author: "Confucius", Text: "First"}, []models.Quote{{ID: quote.ID + 1}}); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Fatalf("Merge with missing quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+
+	if actions := revisionActions(t, repo, quote.ID); !slices.Equal(actions, []models.RevisionAction{models.RevisionCreate}) {
+		t.Errorf("Revisions after failed changes: got %v, want only %v", actions, models.RevisionCreate)
+	}
+}
+
+func testRevisionsOfBatch(t *testing.T, repo services.QuoteRepository) {
+	quotes := []models.Quote{
+		{Author: "Confucius", Text: "First"},
+		{Author: "Seneca", Text: "Second"},
+	}
+	if err := repo.By("importer").CreateBatch(quotes); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	for _, quote := range quotes {
+		got, err := repo.Revision(quote.ID, 1)
+		if err != nil || got.Action != models.RevisionCreate || got.ChangedBy != "importer" || got.Quote.Text != quote.Text {
+			t.Errorf("Revision of batch quote %d: got %+v, %v", quote.ID, got, err)
+		}
+	}
+}
+
+func testRevisionsOfMerge(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Confucius", "First")
+	second := create(t, repo, "Confucius", "Second")
+
+	survivor := models.Quote{ID: first.ID, Author: "Confucius", Text: "First", Tags: []string{"merged"}}
+	if err := repo.By("alice").Merge(&survivor, []models.Quote{second}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if got, err := repo.Revision(first.ID, 2); err != nil || got.Action != models.RevisionUpdate ||
+		got.ChangedBy != "alice" || !slices.Equal(got.Quote.Tags, []string{"merged"}) {
+		t.Errorf("Revision of survivor: got %+v, %v", got, err)
+	}
+	if got, err := repo.Revision(second.ID, 2); err != nil || got.Action != models.RevisionMerge ||
+		got.MergedInto != first.ID || got.ChangedBy != "alice" || got.Quote.Text != "Second" {
+		t.Errorf("Revision of merged quote: got %+v, %v", got, err)
+	}
+}
+
+func testRevisionsOfPurge(t *testing.T, repo services.QuoteRepository) {
+	quote := create(t, repo, "Confucius", "First")
+	if err := repo.Delete(quote.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if n, err := repo.By("cleaner").Purge(time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("Purge: got %d, %v, want 1 quote", n, err)
+	}
+
+	// Purged quotes keep their revisions.
+	actions := revisionActions(t, repo, quote.ID)
+	want := []models.RevisionAction{models.RevisionCreate, models.RevisionDelete, models.RevisionPurge}
+	if !slices.Equal(actions, want) {
+		t.Errorf("Revisions of purged quote: got %v, want %v", actions, want)
+	}
+	if got, err := repo.Revision(quote.ID, 3); err != nil || got.ChangedBy != "cleaner" || got.Quote.Text != "First" {
+		t.Errorf("Revision of purge: got %+v, %v", got, err)
+	}
+}
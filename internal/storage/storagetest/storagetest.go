@@ -0,0 +1,1587 @@
+// Package storagetest provides a conformance suite for services.QuoteRepository
+// implementations. A new backend proves it behaves like the memory storage by
+// calling Run from its tests:
+//
+//	storagetest.Run(t, func(t *testing.T) services.QuoteRepository {
+//		return memory.NewQuoteStorage()
+//	})
+package storagetest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+)
+
+// Factory returns a new empty repository. Cleanup of the resources it holds
+// should be registered with t.Cleanup.
+type Factory func(t *testing.T) services.QuoteRepository
+
+// Run runs the conformance suite against repositories created by newRepo.
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo services.QuoteRepository)
+	}{
+		{"CreateAssignsIDs", testCreateAssignsIDs},
+		{"CreateStampsCreatedAt", testCreateStampsCreatedAt},
+		{"CreateValidates", testCreateValidates},
+		{"CreateIgnoresDeletedAt", testCreateIgnoresDeletedAt},
+		{"CreateBatch", testCreateBatch},
+		{"CreateUnique", testCreateUnique},
+		{"ConcurrentCreateUnique", testConcurrentCreateUnique},
+		{"GetAll", testGetAll},
+		{"GetByID", testGetByID},
+		{"GetByAuthor", testGetByAuthor},
+		{"Created", testCreated},
+		{"AuthorNames", testAuthorNames},
+		{"List", testList},
+		{"ListByAuthorID", testListByAuthorID},
+		{"ListByTags", testListByTags},
+		{"Tags", testTags},
+		{"Source", testSource},
+		{"ListPages", testListPages},
+		{"ListSorted", testListSorted},
+		{"Search", testSearch},
+		{"GetRandom", testGetRandom},
+		{"GetRandomFiltered", testGetRandomFiltered},
+		{"GetRandomWeighted", testGetRandomWeighted},
+		{"Update", testUpdate},
+		{"UpdateValidates", testUpdateValidates},
+		{"UpdateBatch", testUpdateBatch},
+		{"LinkAuthor", testLinkAuthor},
+		{"Versioning", testVersioning},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"Trash", testTrash},
+		{"Undelete", testUndelete},
+		{"Purge", testPurge},
+		{"Merge", testMerge},
+		{"MergeValidates", testMergeValidates},
+		{"IDsAreNotReused", testIDsAreNotReused},
+		{"ConcurrentCreateDelete", testConcurrentCreateDelete},
+		{"Revisions", testRevisions},
+		{"RevisionsOfFailedChanges", testRevisionsOfFailedChanges},
+		{"RevisionsOfBatch", testRevisionsOfBatch},
+		{"RevisionsOfMerge", testRevisionsOfMerge},
+		{"RevisionsOfPurge", testRevisionsOfPurge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func create(t *testing.T, repo services.QuoteRepository, author, text string) models.Quote {
+	t.Helper()
+
+	quote := models.Quote{Author: author, Text: text}
+	if err := repo.Create(&quote); err != nil {
+		t.Fatalf("Create(%q, %q) failed: %v", author, text, err)
+	}
+	return quote
+}
+
+func testCreateAssignsIDs(t *testing.T, repo services.QuoteRepository) {
+	var prev int64
+	for i := 0; i < 5; i++ {
+		quote := create(t, repo, "Author", "Text")
+		if quote.ID <= 0 {
+			t.Fatalf("Create assigned non-positive ID %d", quote.ID)
+		}
+		if quote.ID <= prev {
+			t.Fatalf("Create assigned ID %d after %d, want increasing IDs", quote.ID, prev)
+		}
+		prev = quote.ID
+	}
+}
+
+func testCreateStampsCreatedAt(t *testing.T, repo services.QuoteRepository) {
+	before := time.Now()
+	quote := create(t, repo, "Author", "Text")
+	after := time.Now()
+
+	if quote.CreatedAt.Before(before.Truncate(time.Second)) || quote.CreatedAt.After(after.Add(time.Second)) {
+		t.Fatalf("CreatedAt = %v, want between %v and %v", quote.CreatedAt, before, after)
+	}
+
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != 1 {
+		t.Fatalf("GetAll returned %d quotes, want 1", len(quotes))
+	}
+	if !quotes[0].CreatedAt.Equal(quote.CreatedAt) {
+		t.Errorf("stored CreatedAt = %v, want %v", quotes[0].CreatedAt, quote.CreatedAt)
+	}
+}
+
+// testCreateIgnoresDeletedAt checks that a client cannot put a quote in the
+// trash, or make it look updated, by setting its timestamps.
+func testCreateIgnoresDeletedAt(t *testing.T, repo services.QuoteRepository) {
+	past := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	quote := models.Quote{Author: "Author", Text: "Created", UpdatedAt: past, DeletedAt: past}
+	if err := repo.Create(&quote); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	batch := []models.Quote{{Author: "Author", Text: "Batch", UpdatedAt: past, DeletedAt: past}}
+	if err := repo.CreateBatch(batch); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	for _, q := range []models.Quote{quote, batch[0]} {
+		if !q.UpdatedAt.IsZero() || !q.DeletedAt.IsZero() {
+			t.Errorf("created quote %d has UpdatedAt %v and DeletedAt %v, want zero", q.ID, q.UpdatedAt, q.DeletedAt)
+		}
+	}
+
+	update := models.Quote{ID: quote.ID, Author: "Author", Text: "Updated", DeletedAt: past}
+	if err := repo.Update(&update); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !update.DeletedAt.IsZero() {
+		t.Errorf("updated quote has DeletedAt %v, want zero", update.DeletedAt)
+	}
+
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("GetAll returned %d quotes, want 2", len(quotes))
+	}
+	for _, q := range quotes {
+		if !q.DeletedAt.IsZero() {
+			t.Errorf("stored quote %d has DeletedAt %v, want zero", q.ID, q.DeletedAt)
+		}
+	}
+	trash, err := repo.Trash()
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	if len(trash) != 0 {
+		t.Errorf("Trash returned %d quotes, want none", len(trash))
+	}
+}
+
+func testCreateValidates(t *testing.T, repo services.QuoteRepository) {
+	if err := repo.Create(&models.Quote{Text: "Text"}); !errors.Is(err, storage.ErrEmptyAuthor) {
+		t.Errorf("Create with empty author: got %v, want %v", err, storage.ErrEmptyAuthor)
+	}
+	if err := repo.Create(&models.Quote{Author: "Author"}); !errors.Is(err, storage.ErrEmptyText) {
+		t.Errorf("Create with empty text: got %v, want %v", err, storage.ErrEmptyText)
+	}
+
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != 0 {
+		t.Errorf("invalid quotes were stored: %+v", quotes)
+	}
+}
+
+func testCreateBatch(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Author", "First")
+
+	batch := []models.Quote{
+		{Author: "Confucius", Text: "Second", Tags: []string{"Wisdom"}},
+		{Author: "Seneca", Text: "Third", Rating: 3},
+	}
+	if err := repo.CreateBatch(batch); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	prev := first.ID
+	for _, quote := range batch {
+		if quote.ID <= prev || quote.Version != 1 || quote.CreatedAt.IsZero() {
+			t.Errorf("CreateBatch did not stamp the quote after %d: %+v", prev, quote)
+		}
+		prev = quote.ID
+
+		got, err := repo.GetByID(quote.ID)
+		if err != nil {
+			t.Fatalf("GetByID(%d) failed: %v", quote.ID, err)
+		}
+		if got.Author != quote.Author || got.Text != quote.Text || got.Rating != quote.Rating ||
+			!slices.Equal(got.Tags, quote.Tags) || got.Verification != models.VerificationUnverified {
+			t.Errorf("GetByID(%d): got %+v, want %+v", quote.ID, got, quote)
+		}
+	}
+	if !slices.Equal(batch[0].Tags, []string{"wisdom"}) {
+		t.Errorf("CreateBatch did not normalize tags: %q", batch[0].Tags)
+	}
+
+	invalid := []models.Quote{
+		{Author: "Confucius", Text: "Fourth"},
+		{Author: "Confucius"},
+	}
+	if err := repo.CreateBatch(invalid); !errors.Is(err, storage.ErrEmptyText) {
+		t.Errorf("CreateBatch with empty text: got %v, want %v", err, storage.ErrEmptyText)
+	}
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != 3 {
+		t.Errorf("CreateBatch stored part of an invalid batch: %+v", quotes)
+	}
+}
+
+func testCreateUnique(t *testing.T, repo services.QuoteRepository) {
+	long := "The only true wisdom is in knowing you know nothing"
+	first := create(t, repo, "Socrates", long)
+	deleted := create(t, repo, "Socrates", "Deleted")
+	if err := repo.Delete(deleted.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		quote models.Quote
+		exact bool
+	}{
+		{"exact", models.Quote{Author: " socrates ", Text: "the only true wisdom is in knowing, you know nothing!"}, true},
+		{"near", models.Quote{Author: "Socrates", Text: "The only true wisdom is in knowing that you know nothing"}, false},
+	}
+	for _, tt := range tests {
+		batch := []models.Quote{{Author: "Plato", Text: "Unique"}, tt.quote}
+		err := repo.CreateUnique(batch)
+		var dup *storage.DuplicateError
+		if !errors.As(err, &dup) || dup.ID != first.ID || dup.Exact != tt.exact {
+			t.Errorf("CreateUnique with %s duplicate: got %v, want duplicate of %d", tt.name, err, first.ID)
+		}
+	}
+	if quotes, _ := repo.GetByAuthor("Plato"); len(quotes) != 0 {
+		t.Errorf("CreateUnique stored part of a batch with a duplicate: %+v", quotes)
+	}
+
+	// Quotes of other authors and quotes in the trash are no duplicates.
+	batch := []models.Quote{{Author: "Plato", Text: long}, {Author: "Socrates", Text: "Deleted"}}
+	if err := repo.CreateUnique(batch); err != nil {
+		t.Fatalf("CreateUnique failed: %v", err)
+	}
+	if batch[0].ID <= deleted.ID || batch[1].ID <= batch[0].ID || batch[1].Version != 1 {
+		t.Errorf("CreateUnique did not stamp the quotes: %+v", batch)
+	}
+	if got, err := repo.GetByID(batch[1].ID); err != nil || got.Text != "Deleted" {
+		t.Errorf("GetByID(%d): got %+v, %v", batch[1].ID, got, err)
+	}
+
+	// The quotes are looked up by their current authors.
+	if err := repo.Update(&models.Quote{ID: first.ID, Author: "Xenophon", Text: long}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.CreateUnique([]models.Quote{{Author: "Socrates", Text: long}}); err != nil {
+		t.Errorf("CreateUnique after the author changed: %v", err)
+	}
+	if err := repo.CreateUnique([]models.Quote{{Author: "Xenophon", Text: long}}); !errors.Is(err, storage.ErrDuplicateQuote) {
+		t.Errorf("CreateUnique for the new author: got %v, want %v", err, storage.ErrDuplicateQuote)
+	}
+
+	if err := repo.CreateUnique([]models.Quote{{Author: "Plato"}}); !errors.Is(err, storage.ErrEmptyText) {
+		t.Errorf("CreateUnique with empty text: got %v, want %v", err, storage.ErrEmptyText)
+	}
+}
+
+func testConcurrentCreateUnique(t *testing.T, repo services.QuoteRepository) {
+	const workers = 8
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.CreateUnique([]models.Quote{{Author: "Author", Text: "Text"}})
+			if err != nil && !errors.Is(err, storage.ErrDuplicateQuote) {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent CreateUnique failed: %v", err)
+	}
+
+	quotes, err := repo.GetByAuthor("Author")
+	if err != nil {
+		t.Fatalf("GetByAuthor failed: %v", err)
+	}
+	if len(quotes) != 1 {
+		t.Errorf("concurrent CreateUnique created %d quotes, want 1", len(quotes))
+	}
+}
+
+func testUpdateBatch(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Confucius", "First")
+	second := create(t, repo, "Confucius", "Second")
+
+	stale := []models.Quote{
+		{ID: first.ID, Author: "Kong Qiu", Text: "First", Version: first.Version},
+		{ID: second.ID, Author: "Kong Qiu", Text: "Second", Version: second.Version + 1},
+	}
+	if err := repo.UpdateBatch(stale); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Errorf("UpdateBatch with stale version: got %v, want %v", err, storage.ErrVersionMismatch)
+	}
+	missing := []models.Quote{{ID: first.ID, Author: "Kong Qiu", Text: "First"}, {ID: second.ID + 1, Author: "Kong Qiu", Text: "Third"}}
+	if err := repo.UpdateBatch(missing); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("UpdateBatch with missing quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+	if got, _ := repo.GetByID(first.ID); got == nil || got.Author != "Confucius" || got.Version != first.Version {
+		t.Errorf("UpdateBatch changed part of a failed batch: %+v", got)
+	}
+
+	batch := []models.Quote{
+		{ID: first.ID, Author: "Kong Qiu", Text: "First", Version: first.Version},
+		{ID: second.ID, Author: "Kong Qiu", Text: "Second", Version: second.Version},
+	}
+	if err := repo.By("alice").UpdateBatch(batch); err != nil {
+		t.Fatalf("UpdateBatch failed: %v", err)
+	}
+	for _, quote := range batch {
+		got, err := repo.GetByID(quote.ID)
+		if err != nil || got.Author != "Kong Qiu" || got.Version != 2 || quote.Version != 2 {
+			t.Errorf("GetByID(%d) after UpdateBatch: got %+v, %v", quote.ID, got, err)
+		}
+		revision, err := repo.Revision(quote.ID, 2)
+		if err != nil || revision.Action != models.RevisionUpdate || revision.ChangedBy != "alice" {
+			t.Errorf("Revision of batch update of quote %d: got %+v, %v", quote.ID, revision, err)
+		}
+	}
+	if quotes, _ := repo.GetByAuthor("Kong Qiu"); len(quotes) != 2 {
+		t.Errorf("GetByAuthor after UpdateBatch: got %+v", quotes)
+	}
+}
+
+func testLinkAuthor(t *testing.T, repo services.QuoteRepository) {
+	quote := create(t, repo, "Confucius", "First")
+	if err := repo.LinkAuthor(quote.ID, 7); err != nil {
+		t.Fatalf("LinkAuthor failed: %v", err)
+	}
+
+	got, err := repo.GetByID(quote.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.AuthorID != 7 || got.Version != quote.Version || !got.UpdatedAt.Equal(quote.UpdatedAt) {
+		t.Errorf("LinkAuthor: got %+v, want author 7 and version %d", got, quote.Version)
+	}
+	if actions := revisionActions(t, repo, quote.ID); len(actions) != 1 {
+		t.Errorf("LinkAuthor recorded a revision: %v", actions)
+	}
+	if err := repo.LinkAuthor(quote.ID+1, 7); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("LinkAuthor of missing quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func testGetAll(t *testing.T, repo services.QuoteRepository) {
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if quotes == nil || len(quotes) != 0 {
+		t.Fatalf("GetAll on empty repository = %#v, want empty non-nil slice", quotes)
+	}
+
+	want := map[int64]models.Quote{}
+	for _, text := range []string{"First", "Second", "Third"} {
+		quote := create(t, repo, "Author", text)
+		want[quote.ID] = quote
+	}
+
+	quotes, err = repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != len(want) {
+		t.Fatalf("GetAll returned %d quotes, want %d", len(quotes), len(want))
+	}
+	for _, quote := range quotes {
+		expected, ok := want[quote.ID]
+		if !ok {
+			t.Fatalf("GetAll returned unknown quote %+v", quote)
+		}
+		if quote.Author != expected.Author || quote.Text != expected.Text {
+			t.Errorf("GetAll returned %+v, want %+v", quote, expected)
+		}
+	}
+}
+
+func testGetByID(t *testing.T, repo services.QuoteRepository) {
+	create(t, repo, "Confucius", "First")
+	want := create(t, repo, "Seneca", "Second")
+
+	quote, err := repo.GetByID(want.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if quote.ID != want.ID || quote.Author != want.Author || quote.Text != want.Text {
+		t.Errorf("GetByID = %+v, want %+v", quote, want)
+	}
+
+	if _, err := repo.GetByID(999); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("GetByID of unknown quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+	if _, err := repo.GetByID(0); !errors.Is(err, storage.ErrInvalidID) {
+		t.Errorf("GetByID(0): got %v, want %v", err, storage.ErrInvalidID)
+	}
+
+	if err := repo.Delete(want.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(want.ID); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("GetByID of deleted quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func testCreated(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Confucius", "First")
+	second := create(t, repo, "Confucius", "Second")
+	third := create(t, repo, "Confucius", "Third")
+	// The stored times may be rounded, so the cutoff is taken well after them.
+	time.Sleep(20 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	fourth := create(t, repo, "Confucius", "Fourth")
+
+	// Deleted and merged quotes are still known to have been created.
+	if err := repo.Delete(second.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Merge(&first, []models.Quote{third}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	ids := func(before time.Time) []int64 {
+		t.Helper()
+		created, err := repo.Created(before)
+		if err != nil {
+			t.Fatalf("Created failed: %v", err)
+		}
+		ids := make([]int64, 0, len(created))
+		for _, quote := range created {
+			ids = append(ids, quote.ID)
+		}
+		return ids
+	}
+	if got, want := ids(cutoff), []int64{first.ID, second.ID, third.ID}; !slices.Equal(got, want) {
+		t.Errorf("Created before cutoff: got %v, want %v", got, want)
+	}
+	if got, want := ids(time.Now().Add(time.Second)), []int64{first.ID, second.ID, third.ID, fourth.ID}; !slices.Equal(got, want) {
+		t.Errorf("Created before now: got %v, want %v", got, want)
+	}
+	if got := ids(first.CreatedAt.Add(-time.Second)); len(got) != 0 {
+		t.Errorf("Created before the first quote: got %v, want none", got)
+	}
+
+	// Purged quotes are forgotten.
+	if _, err := repo.Purge(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if got, want := ids(cutoff), []int64{first.ID, third.ID}; !slices.Equal(got, want) {
+		t.Errorf("Created after Purge: got %v, want %v", got, want)
+	}
+}
+
+func testGetByAuthor(t *testing.T, repo services.QuoteRepository) {
+	create(t, repo, "Confucius", "First")
+	create(t, repo, "Seneca", "Second")
+	create(t, repo, "Confucius", "Third")
+
+	quotes, err := repo.GetByAuthor("Confucius")
+	if err != nil {
+		t.Fatalf("GetByAuthor failed: %v", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("GetByAuthor returned %d quotes, want 2", len(quotes))
+	}
+	for _, quote := range quotes {
+		if quote.Author != "Confucius" {
+			t.Errorf("GetByAuthor returned quote of %q", quote.Author)
+		}
+	}
+
+	// Names are compared ignoring case, extra whitespace and the Unicode
+	// normalization form: "e\u0301" is "é" decomposed.
+	create(t, repo, "Honor\u00e9 de Balzac", "Fourth")
+	for _, author := range []string{"confucius", " CONFUCIUS  ", "Honore\u0301  de balzac", "HONORÉ DE BALZAC"} {
+		quotes, err := repo.GetByAuthor(author)
+		if err != nil {
+			t.Fatalf("GetByAuthor(%q) failed: %v", author, err)
+		}
+		if len(quotes) == 0 {
+			t.Errorf("GetByAuthor(%q) found nothing", author)
+		}
+	}
+
+	quotes, err = repo.GetByAuthor("Nobody")
+	if err != nil {
+		t.Fatalf("GetByAuthor for unknown author failed: %v", err)
+	}
+	if len(quotes) != 0 {
+		t.Errorf("GetByAuthor for unknown author returned %+v", quotes)
+	}
+
+	for _, author := range []string{"", "  "} {
+		if _, err := repo.GetByAuthor(author); !errors.Is(err, storage.ErrEmptyAuthor) {
+			t.Errorf("GetByAuthor(%q): got %v, want %v", author, err, storage.ErrEmptyAuthor)
+		}
+	}
+}
+
+func testAuthorNames(t *testing.T, repo services.QuoteRepository) {
+	names, err := repo.AuthorNames()
+	if err != nil {
+		t.Fatalf("AuthorNames failed: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("AuthorNames of empty repository returned %v", names)
+	}
+
+	create(t, repo, "Seneca", "First")
+	create(t, repo, "Confucius", "Second")
+	create(t, repo, "Seneca", "Third")
+
+	names, err = repo.AuthorNames()
+	if err != nil {
+		t.Fatalf("AuthorNames failed: %v", err)
+	}
+	if want := []string{"Confucius", "Seneca"}; !slices.Equal(names, want) {
+		t.Errorf("AuthorNames: got %v, want %v", names, want)
+	}
+}
+
+func testList(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Confucius", "Life is simple")
+	second := create(t, repo, "Seneca", "Life is long if you know how to use it")
+	third := create(t, repo, "Confucius", "Всё ЛЕГКО")
+
+	ids := func(query models.QuoteQuery) []int64 {
+		t.Helper()
+		page, err := repo.List(query, models.Page{})
+		if err != nil {
+			t.Fatalf("List(%+v) failed: %v", query, err)
+		}
+		if page.Next != nil {
+			t.Errorf("List(%+v) without limit returned next cursor %+v", query, page.Next)
+		}
+		result := make([]int64, 0, len(page.Quotes))
+		for _, quote := range page.Quotes {
+			result = append(result, quote.ID)
+		}
+		slices.Sort(result)
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		query models.QuoteQuery
+		want  []int64
+	}{
+		{"no filters", models.QuoteQuery{}, []int64{first.ID, second.ID, third.ID}},
+		{"author", models.QuoteQuery{Author: "Confucius"}, []int64{first.ID, third.ID}},
+		{"unknown author", models.QuoteQuery{Author: "Nobody"}, []int64{}},
+		{"author ignores case and spaces", models.QuoteQuery{Author: "  confucius "}, []int64{first.ID, third.ID}},
+		{"author with typos", models.QuoteQuery{Author: "Confucious"}, []int64{}},
+		{"fuzzy author", models.QuoteQuery{Author: "Confucious", AuthorFuzzy: true}, []int64{first.ID, third.ID}},
+		{"fuzzy author with a typo", models.QuoteQuery{Author: "senca", AuthorFuzzy: true}, []int64{second.ID}},
+		{"fuzzy author too far", models.QuoteQuery{Author: "Socrates", AuthorFuzzy: true}, []int64{}},
+		{"text is case-insensitive", models.QuoteQuery{TextContains: "LIFE"}, []int64{first.ID, second.ID}},
+		{"text folds Cyrillic", models.QuoteQuery{TextContains: "легко"}, []int64{third.ID}},
+		{"author and text", models.QuoteQuery{Author: "Confucius", TextContains: "life"}, []int64{first.ID}},
+		{"created after", models.QuoteQuery{CreatedAfter: second.CreatedAt}, []int64{second.ID, third.ID}},
+		{"created before", models.QuoteQuery{CreatedBefore: second.CreatedAt}, []int64{first.ID}},
+		{"created range", models.QuoteQuery{CreatedAfter: first.CreatedAt, CreatedBefore: third.CreatedAt}, []int64{first.ID, second.ID}},
+		{"created in the future", models.QuoteQuery{CreatedAfter: time.Now().Add(time.Hour)}, []int64{}},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("List %s: got IDs %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testListByAuthorID(t *testing.T, repo services.QuoteRepository) {
+	linked := models.Quote{Author: "Confucius", AuthorID: 7, Text: "Life is simple"}
+	if err := repo.Create(&linked); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	unlinked := create(t, repo, "Confucius", "Real knowledge")
+	relinked := create(t, repo, "Seneca", "Luck is what happens")
+	relinked.AuthorID = 7
+	if err := repo.Update(&relinked); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	got, err := repo.GetByID(linked.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.AuthorID != 7 {
+		t.Errorf("GetByID: got author ID %d, want %d", got.AuthorID, 7)
+	}
+
+	page, err := repo.List(models.QuoteQuery{AuthorID: 7}, models.Page{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var ids []int64
+	for _, quote := range page.Quotes {
+		ids = append(ids, quote.ID)
+	}
+	if want := []int64{linked.ID, relinked.ID}; !slices.Equal(ids, want) {
+		t.Errorf("List by author ID: got IDs %v, want %v (unlinked %d)", ids, want, unlinked.ID)
+	}
+}
+
+func createTagged(t *testing.T, repo services.QuoteRepository, text string, tags ...string) models.Quote {
+	t.Helper()
+
+	quote := models.Quote{Author: "Author", Text: text, Tags: tags}
+	if err := repo.Create(&quote); err != nil {
+		t.Fatalf("Create(%q, %q) failed: %v", text, tags, err)
+	}
+	return quote
+}
+
+func testListByTags(t *testing.T, repo services.QuoteRepository) {
+	funny := createTagged(t, repo, "Funny", "Humor", " humor ", "SHORT")
+	long := createTagged(t, repo, "Long", "humor", "philosophy")
+	wise := createTagged(t, repo, "Wise", "philosophy", "short")
+	createTagged(t, repo, "Untagged")
+
+	if want := []string{"humor", "short"}; !slices.Equal(funny.Tags, want) {
+		t.Errorf("Create: got tags %q, want %q", funny.Tags, want)
+	}
+	got, err := repo.GetByID(funny.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if want := []string{"humor", "short"}; !slices.Equal(got.Tags, want) {
+		t.Errorf("GetByID: got tags %q, want %q", got.Tags, want)
+	}
+
+	tests := []struct {
+		name  string
+		query models.QuoteQuery
+		want  []int64
+	}{
+		{"one tag", models.QuoteQuery{Tags: []string{"humor"}}, []int64{funny.ID, long.ID}},
+		{"tag is normalized", models.QuoteQuery{Tags: []string{"  PHILOSOPHY"}}, []int64{long.ID, wise.ID}},
+		{"all tags", models.QuoteQuery{Tags: []string{"humor", "short"}}, []int64{funny.ID}},
+		{"repeated tag", models.QuoteQuery{Tags: []string{"short", "Short"}}, []int64{funny.ID, wise.ID}},
+		{"any tag", models.QuoteQuery{Tags: []string{"short", "philosophy"}, AnyTag: true}, []int64{funny.ID, long.ID, wise.ID}},
+		{"unknown tag", models.QuoteQuery{Tags: []string{"humor", "poetry"}}, []int64{}},
+		{"any with unknown tag", models.QuoteQuery{Tags: []string{"poetry", "humor"}, AnyTag: true}, []int64{funny.ID, long.ID}},
+		{"tags and text", models.QuoteQuery{Tags: []string{"humor"}, TextContains: "long"}, []int64{long.ID}},
+	}
+	for _, tt := range tests {
+		page, err := repo.List(tt.query, models.Page{})
+		if err != nil {
+			t.Fatalf("List %s failed: %v", tt.name, err)
+		}
+		ids := make([]int64, 0, len(page.Quotes))
+		for _, quote := range page.Quotes {
+			ids = append(ids, quote.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("List %s: got IDs %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+
+	// Pages of a tag filter follow each other like any other listing.
+	page, err := repo.List(models.QuoteQuery{Tags: []string{"short", "humor"}, AnyTag: true}, models.Page{Limit: 1})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(page.Quotes) != 1 || page.Quotes[0].ID != funny.ID || page.Next == nil {
+		t.Fatalf("List first page: got %+v", page)
+	}
+	page, err = repo.List(models.QuoteQuery{Tags: []string{"short", "humor"}, AnyTag: true}, models.Page{Limit: 5, After: page.Next})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(page.Quotes) != 2 || page.Quotes[0].ID != long.ID || page.Quotes[1].ID != wise.ID {
+		t.Errorf("List second page: got %+v", page.Quotes)
+	}
+}
+
+func testTags(t *testing.T, repo services.QuoteRepository) {
+	tags, err := repo.Tags()
+	if err != nil {
+		t.Fatalf("Tags failed: %v", err)
+	}
+	if tags == nil || len(tags) != 0 {
+		t.Errorf("Tags on empty repository: got %#v, want empty non-nil slice", tags)
+	}
+
+	first := createTagged(t, repo, "First", "humor", "short")
+	createTagged(t, repo, "Second", "Philosophy", "short")
+	third := createTagged(t, repo, "Third", "short", "humor")
+
+	want := []models.TagCount{{Tag: "short", Count: 3}, {Tag: "humor", Count: 2}, {Tag: "philosophy", Count: 1}}
+	if tags, err = repo.Tags(); err != nil || !slices.Equal(tags, want) {
+		t.Errorf("Tags: got %v, %v, want %v", tags, err, want)
+	}
+
+	first.Tags = []string{"Poetry"}
+	if err := repo.Update(&first); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if want := []string{"poetry"}; !slices.Equal(first.Tags, want) {
+		t.Errorf("Update: got tags %q, want %q", first.Tags, want)
+	}
+	if err := repo.Delete(third.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	want = []models.TagCount{{Tag: "philosophy", Count: 1}, {Tag: "poetry", Count: 1}, {Tag: "short", Count: 1}}
+	if tags, err = repo.Tags(); err != nil || !slices.Equal(tags, want) {
+		t.Errorf("Tags after Update and Delete: got %v, %v, want %v", tags, err, want)
+	}
+
+	page, err := repo.List(models.QuoteQuery{Tags: []string{"humor"}}, models.Page{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(page.Quotes) != 0 {
+		t.Errorf("List by removed tag: got %+v", page.Quotes)
+	}
+}
+
+func testSource(t *testing.T, repo services.QuoteRepository) {
+	source := &models.Source{Kind: models.SourceBook, Title: "Letters from a Stoic", Page: "xiv", Year: 65, URL: "https://example.com/seneca"}
+	sourced := models.Quote{Author: "Seneca", Text: "Luck is what happens", Source: source, Verification: models.VerificationVerified}
+	if err := repo.Create(&sourced); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	unsourced := create(t, repo, "Seneca", "We suffer more in imagination")
+	if unsourced.Verification != models.VerificationUnverified {
+		t.Errorf("Create: got verification %q, want %q", unsourced.Verification, models.VerificationUnverified)
+	}
+
+	got, err := repo.GetByID(sourced.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Source == nil || *got.Source != *source || got.Verification != models.VerificationVerified {
+		t.Errorf("GetByID: got source %+v and verification %q", got.Source, got.Verification)
+	}
+	if got, err := repo.GetByID(unsourced.ID); err != nil || got.Source != nil {
+		t.Errorf("GetByID of unsourced quote: got %+v, %v", got, err)
+	}
+
+	ids := func(verification models.Verification) []int64 {
+		t.Helper()
+		page, err := repo.List(models.QuoteQuery{Verification: verification}, models.Page{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		result := make([]int64, 0, len(page.Quotes))
+		for _, quote := range page.Quotes {
+			result = append(result, quote.ID)
+		}
+		return result
+	}
+	if got, want := ids(models.VerificationVerified), []int64{sourced.ID}; !slices.Equal(got, want) {
+		t.Errorf("List verified: got IDs %v, want %v", got, want)
+	}
+	if got, want := ids(models.VerificationUnverified), []int64{unsourced.ID}; !slices.Equal(got, want) {
+		t.Errorf("List unverified: got IDs %v, want %v", got, want)
+	}
+
+	sourced.Source = nil
+	sourced.Verification = models.VerificationApocryphal
+	if err := repo.Update(&sourced); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, err := repo.GetByID(sourced.ID); err != nil || got.Source != nil || got.Verification != models.VerificationApocryphal {
+		t.Errorf("GetByID after Update: got %+v, %v", got, err)
+	}
+	if got := ids(models.VerificationVerified); len(got) != 0 {
+		t.Errorf("List verified after Update: got IDs %v, want none", got)
+	}
+}
+
+func testListPages(t *testing.T, repo services.QuoteRepository) {
+	var want []int64
+	for i := range 7 {
+		author := "Confucius"
+		if i%3 == 0 {
+			author = "Seneca"
+		}
+		want = append(want, create(t, repo, author, fmt.Sprintf("Quote %d", i)).ID)
+	}
+	// Deleting from the middle must not disturb the order of the pages.
+	if err := repo.Delete(want[1], 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	want = slices.Delete(want, 1, 2)
+
+	walk := func(query models.QuoteQuery, limit int) []int64 {
+		t.Helper()
+		var got []int64
+		page := models.Page{Limit: limit}
+		for range len(want) + 1 {
+			result, err := repo.List(query, page)
+			if err != nil {
+				t.Fatalf("List(%+v, %+v) failed: %v", query, page, err)
+			}
+			if len(result.Quotes) > limit {
+				t.Fatalf("List returned %d quotes, limit is %d", len(result.Quotes), limit)
+			}
+			for _, quote := range result.Quotes {
+				got = append(got, quote.ID)
+			}
+			if result.Next == nil {
+				return got
+			}
+			page.After = result.Next
+		}
+		t.Fatalf("List did not reach the last page")
+		return nil
+	}
+
+	for _, limit := range []int{1, 2, 5, 6, 10} {
+		if got := walk(models.QuoteQuery{}, limit); !slices.Equal(got, want) {
+			t.Errorf("pages of %d: got IDs %v, want %v", limit, got, want)
+		}
+	}
+
+	var seneca []int64
+	for _, id := range want {
+		quote, err := repo.GetByID(id)
+		if err != nil {
+			t.Fatalf("GetByID(%d) failed: %v", id, err)
+		}
+		if quote.Author == "Seneca" {
+			seneca = append(seneca, id)
+		}
+	}
+	if got := walk(models.QuoteQuery{Author: "Seneca"}, 2); !slices.Equal(got, seneca) {
+		t.Errorf("filtered pages: got IDs %v, want %v", got, seneca)
+	}
+}
+
+func testListSorted(t *testing.T, repo services.QuoteRepository) {
+	// Text lengths are counted in characters, not bytes.
+	q1 := create(t, repo, "Seneca", "ёж")
+	q2 := create(t, repo, "Confucius", "a")
+	q3 := create(t, repo, "Seneca", "b")
+	q4 := create(t, repo, "Aristotle", "ccc")
+
+	asc := func(field models.SortField) models.SortKey { return models.SortKey{Field: field} }
+	desc := func(field models.SortField) models.SortKey { return models.SortKey{Field: field, Desc: true} }
+
+	tests := []struct {
+		name string
+		sort []models.SortKey
+		want []models.Quote
+	}{
+		{"default", nil, []models.Quote{q1, q2, q3, q4}},
+		{"-id", []models.SortKey{desc(models.SortByID)}, []models.Quote{q4, q3, q2, q1}},
+		{"author", []models.SortKey{asc(models.SortByAuthor)}, []models.Quote{q4, q2, q1, q3}},
+		{"-author", []models.SortKey{desc(models.SortByAuthor)}, []models.Quote{q1, q3, q2, q4}},
+		{"-created_at", []models.SortKey{desc(models.SortByCreatedAt)}, []models.Quote{q4, q3, q2, q1}},
+		{"author,-created_at", []models.SortKey{asc(models.SortByAuthor), desc(models.SortByCreatedAt)}, []models.Quote{q4, q2, q3, q1}},
+		{"text_length,-id", []models.SortKey{asc(models.SortByTextLength), desc(models.SortByID)}, []models.Quote{q3, q2, q1, q4}},
+		{"-text_length,author", []models.SortKey{desc(models.SortByTextLength), asc(models.SortByAuthor)}, []models.Quote{q4, q1, q2, q3}},
+	}
+	for _, tt := range tests {
+		var want []int64
+		for _, quote := range tt.want {
+			want = append(want, quote.ID)
+		}
+
+		result, err := repo.List(models.QuoteQuery{}, models.Page{Sort: tt.sort})
+		if err != nil {
+			t.Fatalf("List sorted by %s failed: %v", tt.name, err)
+		}
+		var got []int64
+		for _, quote := range result.Quotes {
+			got = append(got, quote.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("List sorted by %s: got IDs %v, want %v", tt.name, got, want)
+		}
+
+		// Walking page by page must give the same order.
+		got = nil
+		page := models.Page{Limit: 1, Sort: tt.sort}
+		for range len(want) + 1 {
+			result, err := repo.List(models.QuoteQuery{}, page)
+			if err != nil {
+				t.Fatalf("List sorted by %s failed: %v", tt.name, err)
+			}
+			for _, quote := range result.Quotes {
+				got = append(got, quote.ID)
+			}
+			if result.Next == nil {
+				break
+			}
+			page.After = result.Next
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("pages sorted by %s: got IDs %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func testSearch(t *testing.T, repo services.QuoteRepository) {
+	repeated := create(t, repo, "Unknown", "Simple, simple, simple.")
+	long := create(t, repo, "Confucius", "Life is really simple, but we insist on making it complicated.")
+	cyrillic := create(t, repo, "Лев Толстой", "Всё гениальное ПРОСТО.")
+	create(t, repo, "Seneca", "Luck is what happens when preparation meets opportunity.")
+	create(t, repo, "Socrates", "The only true wisdom is in knowing you know nothing.")
+	create(t, repo, "Aristotle", "Quality is not an act, it is a habit.")
+
+	ids := func(query string, limit int) []int64 {
+		t.Helper()
+		quotes, err := repo.Search(query, limit)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", query, err)
+		}
+		result := make([]int64, 0, len(quotes))
+		for i, quote := range quotes {
+			if quote.Score <= 0 {
+				t.Errorf("Search(%q) returned quote %d with score %v", query, quote.ID, quote.Score)
+			}
+			if i > 0 && quote.Score > quotes[i-1].Score {
+				t.Errorf("Search(%q) is not ordered by score: %v after %v", query, quote.Score, quotes[i-1].Score)
+			}
+			result = append(result, quote.ID)
+		}
+		return result
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []int64
+	}{
+		// More occurrences in a shorter text rank higher.
+		{"simple", 0, []int64{repeated.ID, long.ID}},
+		{"SIMPL", 0, []int64{repeated.ID, long.ID}},
+		{"simple", 1, []int64{repeated.ID}},
+		{"просто", 0, []int64{cyrillic.ID}},
+		{"гениал", 0, []int64{cyrillic.ID}},
+		{"complicated genius", 0, []int64{long.ID}},
+		{"mple", 0, []int64{}},
+		{"zebra crossing", 0, []int64{}},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.query, tt.limit); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q, %d): got IDs %v, want %v", tt.query, tt.limit, got, tt.want)
+		}
+	}
+
+	long.Text = "Life is long if you know how to use it."
+	if err := repo.Update(&long); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got := ids("complicated", 0); len(got) != 0 {
+		t.Errorf("Search found the replaced text: %v", got)
+	}
+	if got := ids("know", 0); !slices.Contains(got, long.ID) {
+		t.Errorf("Search did not find the updated text: %v", got)
+	}
+
+	if err := repo.Delete(repeated.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := ids("simple", 0); len(got) != 0 {
+		t.Errorf("Search found deleted quotes: %v", got)
+	}
+
+	for _, query := range []string{"", "  ", "...", `"*`} {
+		if _, err := repo.Search(query, 0); !errors.Is(err, storage.ErrEmptySearchQuery) {
+			t.Errorf("Search(%q): got %v, want %v", query, err, storage.ErrEmptySearchQuery)
+		}
+	}
+}
+
+func testGetRandom(t *testing.T, repo services.QuoteRepository) {
+	if _, err := repo.GetRandom(models.QuoteQuery{}, models.WeightUniform); !errors.Is(err, storage.ErrNoQuotesAvailable) {
+		t.Fatalf("GetRandom on empty repository: got %v, want %v", err, storage.ErrNoQuotesAvailable)
+	}
+
+	ids := map[int64]bool{}
+	for _, text := range []string{"First", "Second", "Third"} {
+		ids[create(t, repo, "Author", text).ID] = true
+	}
+	for i := 0; i < 20; i++ {
+		quote, err := repo.GetRandom(models.QuoteQuery{}, models.WeightUniform)
+		if err != nil {
+			t.Fatalf("GetRandom failed: %v", err)
+		}
+		if !ids[quote.ID] {
+			t.Fatalf("GetRandom returned unknown quote %+v", quote)
+		}
+	}
+
+	for id := range ids {
+		if err := repo.Delete(id, 0); err != nil {
+			t.Fatalf("Delete(%d) failed: %v", id, err)
+		}
+	}
+	if _, err := repo.GetRandom(models.QuoteQuery{}, models.WeightUniform); !errors.Is(err, storage.ErrNoQuotesAvailable) {
+		t.Errorf("GetRandom after deleting everything: got %v, want %v", err, storage.ErrNoQuotesAvailable)
+	}
+}
+
+func testGetRandomFiltered(t *testing.T, repo services.QuoteRepository) {
+	short := createTagged(t, repo, "Short", "humor")
+	long := createTagged(t, repo, "A rather long quote", "humor")
+	other := create(t, repo, "Seneca", "Brief")
+
+	tests := []struct {
+		name  string
+		query models.QuoteQuery
+		want  []int64
+	}{
+		{"author", models.QuoteQuery{Author: "seneca"}, []int64{other.ID}},
+		{"tag", models.QuoteQuery{Tags: []string{"humor"}}, []int64{short.ID, long.ID}},
+		{"max length", models.QuoteQuery{MaxLength: 5}, []int64{short.ID, other.ID}},
+		{"tag and max length", models.QuoteQuery{Tags: []string{"humor"}, MaxLength: 5}, []int64{short.ID}},
+	}
+	for _, tt := range tests {
+		for _, weight := range []models.RandomWeight{models.WeightUniform, models.WeightRating, models.WeightRecency} {
+			seen := make(map[int64]bool)
+			for range 50 {
+				quote, err := repo.GetRandom(tt.query, weight)
+				if err != nil {
+					t.Fatalf("GetRandom %s by %q failed: %v", tt.name, weight, err)
+				}
+				if !slices.Contains(tt.want, quote.ID) {
+					t.Fatalf("GetRandom %s by %q returned quote %d, want one of %v", tt.name, weight, quote.ID, tt.want)
+				}
+				seen[quote.ID] = true
+			}
+			if len(seen) != len(tt.want) {
+				t.Errorf("GetRandom %s by %q picked only %v out of %v", tt.name, weight, seen, tt.want)
+			}
+		}
+	}
+
+	if _, err := repo.GetRandom(models.QuoteQuery{Author: "Nobody"}, models.WeightUniform); !errors.Is(err, storage.ErrNoQuotesAvailable) {
+		t.Errorf("GetRandom with no matches: got %v, want %v", err, storage.ErrNoQuotesAvailable)
+	}
+}
+
+func testGetRandomWeighted(t *testing.T, repo services.QuoteRepository) {
+	unrated := create(t, repo, "Author", "Unrated")
+	rated := models.Quote{Author: "Author", Text: "Rated", Rating: models.MaxRating}
+	if err := repo.Create(&rated); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got, err := repo.GetByID(rated.ID); err != nil || got.Rating != models.MaxRating {
+		t.Fatalf("GetByID: got %+v, %v", got, err)
+	}
+
+	// The rated quote weighs 6 against 1, so it should win about 6 times out
+	// of 7; the bounds are loose enough to never fail by chance.
+	const picks = 700
+	wins := 0
+	for range picks {
+		quote, err := repo.GetRandom(models.QuoteQuery{}, models.WeightRating)
+		if err != nil {
+			t.Fatalf("GetRandom failed: %v", err)
+		}
+		if quote.ID == rated.ID {
+			wins++
+		} else if quote.ID != unrated.ID {
+			t.Fatalf("GetRandom returned unknown quote %+v", quote)
+		}
+	}
+	if wins < picks*3/4 || wins == picks {
+		t.Errorf("GetRandom by rating picked the rated quote %d times out of %d, want about %d", wins, picks, picks*6/7)
+	}
+}
+
+func testUpdate(t *testing.T, repo services.QuoteRepository) {
+	original := create(t, repo, "Confucius", "Lfie is simple")
+
+	before := time.Now()
+	quote := models.Quote{ID: original.ID, Author: "Confucius", Text: "Life is simple"}
+	if err := repo.Update(&quote); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !quote.CreatedAt.Equal(original.CreatedAt) {
+		t.Errorf("Update changed CreatedAt: got %v, want %v", quote.CreatedAt, original.CreatedAt)
+	}
+	if quote.UpdatedAt.Before(before.Truncate(time.Second)) {
+		t.Errorf("UpdatedAt = %v, want not before %v", quote.UpdatedAt, before)
+	}
+
+	stored, err := repo.GetByID(original.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if stored.Text != "Life is simple" || !stored.UpdatedAt.Equal(quote.UpdatedAt) {
+		t.Errorf("stored quote = %+v, want %+v", stored, quote)
+	}
+
+	if err := repo.Update(&models.Quote{ID: 999, Author: "Author", Text: "Text"}); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Update of unknown quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func testUpdateValidates(t *testing.T, repo services.QuoteRepository) {
+	original := create(t, repo, "Author", "Text")
+
+	if err := repo.Update(&models.Quote{ID: original.ID, Text: "Text"}); !errors.Is(err, storage.ErrEmptyAuthor) {
+		t.Errorf("Update with empty author: got %v, want %v", err, storage.ErrEmptyAuthor)
+	}
+	if err := repo.Update(&models.Quote{ID: original.ID, Author: "Author"}); !errors.Is(err, storage.ErrEmptyText) {
+		t.Errorf("Update with empty text: got %v, want %v", err, storage.ErrEmptyText)
+	}
+	if err := repo.Update(&models.Quote{Author: "Author", Text: "Text"}); !errors.Is(err, storage.ErrInvalidID) {
+		t.Errorf("Update without ID: got %v, want %v", err, storage.ErrInvalidID)
+	}
+
+	stored, err := repo.GetByID(original.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if stored.Author != "Author" || stored.Text != "Text" {
+		t.Errorf("invalid update was stored: %+v", stored)
+	}
+}
+
+func testVersioning(t *testing.T, repo services.QuoteRepository) {
+	quote := create(t, repo, "Author", "Text")
+	if quote.Version != 1 {
+		t.Fatalf("Create set Version = %d, want 1", quote.Version)
+	}
+
+	update := models.Quote{ID: quote.ID, Author: "Author", Text: "Updated", Version: 1}
+	if err := repo.Update(&update); err != nil {
+		t.Fatalf("Update with current version failed: %v", err)
+	}
+	if update.Version != 2 {
+		t.Fatalf("Update set Version = %d, want 2", update.Version)
+	}
+
+	stale := models.Quote{ID: quote.ID, Author: "Author", Text: "Stale", Version: 1}
+	if err := repo.Update(&stale); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Errorf("Update with stale version: got %v, want %v", err, storage.ErrVersionMismatch)
+	}
+	if err := repo.Delete(quote.ID, 1); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Errorf("Delete with stale version: got %v, want %v", err, storage.ErrVersionMismatch)
+	}
+
+	stored, err := repo.GetByID(quote.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if stored.Text != "Updated" || stored.Version != 2 {
+		t.Errorf("stored quote = %+v, want text %q at version 2", stored, "Updated")
+	}
+
+	if err := repo.Delete(quote.ID, 2); err != nil {
+		t.Errorf("Delete with current version failed: %v", err)
+	}
+}
+
+func testDelete(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Author", "First")
+	second := create(t, repo, "Author", "Second")
+
+	if err := repo.Delete(first.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].ID != second.ID {
+		t.Errorf("GetAll after Delete = %+v, want only quote %d", quotes, second.ID)
+	}
+}
+
+func testDeleteNotFound(t *testing.T, repo services.QuoteRepository) {
+	if err := repo.Delete(999, 0); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Delete of unknown quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+	if err := repo.Delete(0, 0); !errors.Is(err, storage.ErrInvalidID) {
+		t.Errorf("Delete(0): got %v, want %v", err, storage.ErrInvalidID)
+	}
+	if err := repo.Delete(-1, 0); !errors.Is(err, storage.ErrInvalidID) {
+		t.Errorf("Delete(-1): got %v, want %v", err, storage.ErrInvalidID)
+	}
+
+	quote := create(t, repo, "Author", "Text")
+	if err := repo.Delete(quote.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(quote.ID, 0); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("second Delete: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func testTrash(t *testing.T, repo services.QuoteRepository) {
+	trash, err := repo.Trash()
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	if trash == nil || len(trash) != 0 {
+		t.Errorf("Trash on empty repository: got %#v, want empty non-nil slice", trash)
+	}
+
+	first := createTagged(t, repo, "Simple wisdom", "wisdom")
+	second := create(t, repo, "Seneca", "Simple life")
+	kept := create(t, repo, "Confucius", "Kept")
+
+	before := time.Now()
+	for _, quote := range []models.Quote{first, second} {
+		if err := repo.Delete(quote.ID, 0); err != nil {
+			t.Fatalf("Delete(%d) failed: %v", quote.ID, err)
+		}
+	}
+
+	trash, err = repo.Trash()
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	var ids []int64
+	for _, quote := range trash {
+		ids = append(ids, quote.ID)
+		if quote.DeletedAt.Before(before.Add(-time.Second)) || quote.DeletedAt.After(time.Now().Add(time.Second)) {
+			t.Errorf("Trash: quote %d deleted at %v, want about %v", quote.ID, quote.DeletedAt, before)
+		}
+	}
+	if want := []int64{second.ID, first.ID}; !slices.Equal(ids, want) {
+		t.Errorf("Trash: got IDs %v, want %v", ids, want)
+	}
+	if trash[1].Text != first.Text || !slices.Equal(trash[1].Tags, first.Tags) {
+		t.Errorf("Trash: got %+v, want %+v", trash[1], first)
+	}
+
+	// Deleted quotes are left out of everything else.
+	if _, err := repo.GetByID(first.ID); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("GetByID of deleted quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+	page, err := repo.List(models.QuoteQuery{}, models.Page{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(page.Quotes) != 1 || page.Quotes[0].ID != kept.ID {
+		t.Errorf("List after Delete = %+v, want only quote %d", page.Quotes, kept.ID)
+	}
+	found, err := repo.Search("simple", 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(found) != 0 {
+		t.Errorf("Search found deleted quotes: %+v", found)
+	}
+	for range 10 {
+		quote, err := repo.GetRandom(models.QuoteQuery{}, models.WeightUniform)
+		if err != nil {
+			t.Fatalf("GetRandom failed: %v", err)
+		}
+		if quote.ID != kept.ID {
+			t.Fatalf("GetRandom returned deleted quote %d", quote.ID)
+		}
+	}
+	byAuthor, err := repo.GetByAuthor("Seneca")
+	if err != nil {
+		t.Fatalf("GetByAuthor failed: %v", err)
+	}
+	if len(byAuthor) != 0 {
+		t.Errorf("GetByAuthor returned deleted quotes: %+v", byAuthor)
+	}
+	names, err := repo.AuthorNames()
+	if err != nil {
+		t.Fatalf("AuthorNames failed: %v", err)
+	}
+	if want := []string{"Confucius"}; !slices.Equal(names, want) {
+		t.Errorf("AuthorNames: got %q, want %q", names, want)
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		t.Fatalf("Tags failed: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("Tags counted deleted quotes: %+v", tags)
+	}
+
+	updated := first
+	updated.Text = "Changed"
+	if err := repo.Update(&updated); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Update of deleted quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func testUndelete(t *testing.T, repo services.QuoteRepository) {
+	quote := createTagged(t, repo, "Restored", "wisdom")
+	if err := repo.Delete(quote.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	restored, err := repo.Undelete(quote.ID)
+	if err != nil {
+		t.Fatalf("Undelete failed: %v", err)
+	}
+	if restored.ID != quote.ID || restored.Text != quote.Text || !restored.DeletedAt.IsZero() ||
+		!restored.CreatedAt.Equal(quote.CreatedAt) || !slices.Equal(restored.Tags, quote.Tags) {
+		t.Errorf("Undelete: got %+v, want %+v", restored, quote)
+	}
+
+	got, err := repo.GetByID(quote.ID)
+	if err != nil {
+		t.Fatalf("GetByID after Undelete failed: %v", err)
+	}
+	if got.Text != quote.Text || !got.DeletedAt.IsZero() {
+		t.Errorf("GetByID after Undelete: got %+v", got)
+	}
+	trash, err := repo.Trash()
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	if len(trash) != 0 {
+		t.Errorf("Trash after Undelete: got %+v, want none", trash)
+	}
+
+	if _, err := repo.Undelete(quote.ID); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Undelete of live quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+	if _, err := repo.Undelete(999); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Undelete of unknown quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+	if _, err := repo.Undelete(0); !errors.Is(err, storage.ErrInvalidID) {
+		t.Errorf("Undelete(0): got %v, want %v", err, storage.ErrInvalidID)
+	}
+}
+
+func testPurge(t *testing.T, repo services.QuoteRepository) {
+	old := create(t, repo, "Author", "Old")
+	if err := repo.Delete(old.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	recent := create(t, repo, "Author", "Recent")
+	if err := repo.Delete(recent.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	live := create(t, repo, "Author", "Live")
+
+	purged, err := repo.Purge(cutoff)
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Purge: got %d purged, want %d", purged, 1)
+	}
+	trash, err := repo.Trash()
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != recent.ID {
+		t.Errorf("Trash after Purge = %+v, want only quote %d", trash, recent.ID)
+	}
+	if _, err := repo.Undelete(old.ID); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Undelete of purged quote: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+
+	purged, err = repo.Purge(time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("second Purge: got %d purged, want %d", purged, 1)
+	}
+	if _, err := repo.GetByID(live.ID); err != nil {
+		t.Errorf("GetByID of live quote after Purge failed: %v", err)
+	}
+
+	// Purged IDs are not reused.
+	next := create(t, repo, "Author", "Next")
+	if next.ID <= live.ID {
+		t.Errorf("Create after Purge assigned ID %d, want above %d", next.ID, live.ID)
+	}
+}
+
+func testMerge(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Confucius", "First")
+	second := createTagged(t, repo, "Second", "wisdom")
+	kept := create(t, repo, "Seneca", "Kept")
+	third := create(t, repo, "Confucius", "Third")
+	last := create(t, repo, "Seneca", "Last")
+
+	survivor := first
+	survivor.Tags = []string{"Wisdom", "life"}
+	if err := repo.Merge(&survivor, []models.Quote{second, third}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if survivor.Version != first.Version+1 || !survivor.CreatedAt.Equal(first.CreatedAt) ||
+		!slices.Equal(survivor.Tags, []string{"life", "wisdom"}) {
+		t.Errorf("Merge did not update the survivor: %+v", survivor)
+	}
+
+	got, err := repo.GetByID(first.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if !slices.Equal(got.Tags, survivor.Tags) || got.Version != survivor.Version {
+		t.Errorf("GetByID after Merge: got %+v, want %+v", got, survivor)
+	}
+	for _, id := range []int64{second.ID, third.ID} {
+		if _, err := repo.GetByID(id); !errors.Is(err, storage.ErrQuoteNotFound) {
+			t.Errorf("GetByID(%d) of merged quote: got %v, want %v", id, err, storage.ErrQuoteNotFound)
+		}
+		if to, err := repo.Redirect(id); err != nil || to != first.ID {
+			t.Errorf("Redirect(%d): got %d, %v, want %d", id, to, err, first.ID)
+		}
+	}
+	if _, err := repo.Redirect(first.ID); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Redirect of survivor: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+	// The quotes around the merged ones are still found by their IDs.
+	for _, quote := range []models.Quote{kept, last} {
+		if got, err := repo.GetByID(quote.ID); err != nil || got.Text != quote.Text {
+			t.Errorf("GetByID(%d) after Merge: got %+v, %v", quote.ID, got, err)
+		}
+	}
+	if tags, err := repo.Tags(); err != nil || len(tags) != 2 {
+		t.Errorf("Tags after Merge: got %+v, %v", tags, err)
+	}
+
+	// Merging the survivor again moves the redirects to the new survivor.
+	fourth := create(t, repo, "Confucius", "Fourth")
+	if err := repo.Merge(&fourth, []models.Quote{{ID: first.ID}}); err != nil {
+		t.Fatalf("second Merge failed: %v", err)
+	}
+	for _, id := range []int64{first.ID, second.ID, third.ID} {
+		if to, err := repo.Redirect(id); err != nil || to != fourth.ID {
+			t.Errorf("Redirect(%d) after second Merge: got %d, %v, want %d", id, to, err, fourth.ID)
+		}
+	}
+
+	// Deleting the survivor ends the redirects.
+	if err := repo.Delete(fourth.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.Redirect(second.ID); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Redirect to deleted survivor: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func testMergeValidates(t *testing.T, repo services.QuoteRepository) {
+	first := create(t, repo, "Confucius", "First")
+	second := create(t, repo, "Confucius", "Second")
+
+	tests := []struct {
+		name     string
+		survivor models.Quote
+		merged   []models.Quote
+		want     error
+	}{
+		{"survivor among merged", first, []models.Quote{second, first}, storage.ErrInvalidID},
+		{"repeated IDs", first, []models.Quote{second, second}, storage.ErrInvalidID},
+		{"missing merged quote", first, []models.Quote{second, {ID: 999}}, storage.ErrQuoteNotFound},
+		{"missing survivor", models.Quote{ID: 999, Author: "Confucius", Text: "Text"}, []models.Quote{second}, storage.ErrQuoteNotFound},
+		{"stale survivor", models.Quote{ID: first.ID, Author: "Confucius", Text: "Text", Version: 5}, []models.Quote{second}, storage.ErrVersionMismatch},
+		{"stale merged quote", first, []models.Quote{{ID: second.ID, Version: second.Version + 1}}, storage.ErrVersionMismatch},
+		{"empty text", models.Quote{ID: first.ID, Author: "Confucius"}, []models.Quote{second}, storage.ErrEmptyText},
+	}
+	for _, tt := range tests {
+		survivor := tt.survivor
+		if err := repo.Merge(&survivor, tt.merged); !errors.Is(err, tt.want) {
+			t.Errorf("Merge with %s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A failed merge changes nothing.
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != 2 || quotes[0].Version != first.Version {
+		t.Errorf("failed Merge changed the quotes: %+v", quotes)
+	}
+	if _, err := repo.Redirect(second.ID); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("Redirect after failed Merge: got %v, want %v", err, storage.ErrQuoteNotFound)
+	}
+}
+
+func testIDsAreNotReused(t *testing.T, repo services.QuoteRepository) {
+	create(t, repo, "Author", "First")
+	last := create(t, repo, "Author", "Second")
+	if err := repo.Delete(last.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	quote := create(t, repo, "Author", "Third")
+	if quote.ID <= last.ID {
+		t.Errorf("Create reused ID: got %d after deleted %d", quote.ID, last.ID)
+	}
+}
+
+func testConcurrentCreateDelete(t *testing.T, repo services.QuoteRepository) {
+	const workers = 8
+	const perWorker = 25
+
+	var mu sync.Mutex
+	ids := make(map[int64]bool)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker*2)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				quote := models.Quote{Author: "Author", Text: "Text"}
+				if err := repo.Create(&quote); err != nil {
+					errs <- err
+					return
+				}
+				mu.Lock()
+				if ids[quote.ID] {
+					errs <- errors.New("duplicate ID assigned concurrently")
+				}
+				ids[quote.ID] = true
+				mu.Unlock()
+
+				// Every other quote is deleted right away, racing with the other workers.
+				if i%2 == 0 {
+					if err := repo.Delete(quote.ID, 0); err != nil {
+						errs <- err
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent operation failed: %v", err)
+	}
+
+	if len(ids) != workers*perWorker {
+		t.Fatalf("created %d distinct IDs, want %d", len(ids), workers*perWorker)
+	}
+
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	want := workers * (perWorker / 2)
+	if len(quotes) != want {
+		t.Errorf("GetAll returned %d quotes after concurrent Create/Delete, want %d", len(quotes), want)
+	}
+}
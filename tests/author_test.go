@@ -0,0 +1,320 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+	authormemory "quotes/internal/storage/authors/memory"
+	"quotes/internal/storage/quotes/memory"
+
+	"github.com/gorilla/mux"
+)
+
+// serveJSON выполняет запрос с телом в формате JSON
+func serveJSON(router *mux.Router, method, path string, body any) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newJSONRequest(method, path, body))
+	return rr
+}
+
+// newJSONRequest создает запрос с телом в формате JSON, не проверяющий версию цитаты
+func newJSONRequest(method, path string, body any) *http.Request {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "*")
+	return req
+}
+
+// TestAuthorCRUD проверяет создание, получение, изменение и удаление автора
+func TestAuthorCRUD(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveJSON(router, "POST", "/authors", models.Author{
+		Name:      "Confucius",
+		Aliases:   []string{"Конфуций"},
+		BirthYear: -551,
+		DeathYear: -479,
+	})
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var created models.Author
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if created.ID == 0 || created.BirthYear != -551 {
+		t.Errorf("handler returned unexpected author: %+v", created)
+	}
+
+	rr = serveJSON(router, "POST", "/authors", models.Author{Name: "конфуций"})
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code for taken name: got %v want %v", status, http.StatusConflict)
+	}
+	rr = serveJSON(router, "POST", "/authors", models.Author{Name: " "})
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for empty name: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	path := fmt.Sprintf("/authors/%d", created.ID)
+	rr = serveJSON(router, "PUT", path, models.Author{Name: "Kong Fuzi", Aliases: []string{"Confucius", "Конфуций"}})
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	rr = serveJSON(router, "GET", path, nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var got models.Author
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if got.Name != "Kong Fuzi" || !slices.Equal(got.Aliases, []string{"Confucius", "Конфуций"}) {
+		t.Errorf("handler returned unexpected author: %+v", got)
+	}
+
+	rr = serveJSON(router, "GET", "/authors", nil)
+	var all []models.Author
+	if err := json.Unmarshal(rr.Body.Bytes(), &all); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("handler returned unexpected number of authors: got %v want %v", len(all), 1)
+	}
+
+	if status := serveJSON(router, "DELETE", path, nil).Code; status != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+	if status := serveJSON(router, "GET", path, nil).Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for deleted author: got %v want %v", status, http.StatusNotFound)
+	}
+}
+
+// TestAuthorAliasResolution проверяет привязку цитат к автору по псевдониму
+func TestAuthorAliasResolution(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveJSON(router, "POST", "/authors", models.Author{Name: "Confucius", Aliases: []string{"Конфуций"}})
+	var author models.Author
+	if err := json.Unmarshal(rr.Body.Bytes(), &author); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	rr = serveJSON(router, "POST", "/quotes", models.Quote{Author: "конфуций", Text: "Life is simple"})
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var quote models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if quote.AuthorID != author.ID || quote.Author != "Confucius" {
+		t.Errorf("quote was not linked to the author: got %q (%d) want %q (%d)",
+			quote.Author, quote.AuthorID, "Confucius", author.ID)
+	}
+
+	// Цитата нового автора создает автора.
+	rr = serveJSON(router, "POST", "/quotes", models.Quote{Author: "Seneca", Text: "Luck is what happens"})
+	var seneca models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &seneca); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if seneca.AuthorID == 0 || seneca.AuthorID == author.ID {
+		t.Errorf("quote of a new author got unexpected author ID %d", seneca.AuthorID)
+	}
+
+	// Цитату можно добавить только по ID автора.
+	rr = serveJSON(router, "POST", "/quotes", models.Quote{AuthorID: author.ID, Text: "Real knowledge"})
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	rr = serveJSON(router, "POST", "/quotes", models.Quote{AuthorID: 1000, Text: "Nobody said it"})
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for unknown author ID: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	// Переименование автора переименовывает его цитаты.
+	path := fmt.Sprintf("/authors/%d", author.ID)
+	rr = serveJSON(router, "PUT", path, models.Author{Name: "Kong Fuzi", Aliases: []string{"Confucius"}})
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	rr = serveJSON(router, "GET", fmt.Sprintf("/quotes/%d", quote.ID), nil)
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if quote.Author != "Kong Fuzi" {
+		t.Errorf("quote was not renamed with the author: got %q want %q", quote.Author, "Kong Fuzi")
+	}
+}
+
+// TestAuthorQuotes проверяет получение цитат автора и запрет удаления автора с цитатами
+func TestAuthorQuotes(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Confucius", Text: "First"},
+		{Author: "Seneca", Text: "Second"},
+		{Author: "confucius", Text: "Third"},
+		{Author: "Confucius", Text: "Fourth"},
+	} {
+		if status := serveJSON(router, "POST", "/quotes", quote).Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	}
+
+	rr := serveJSON(router, "GET", "/authors", nil)
+	var authors []models.Author
+	if err := json.Unmarshal(rr.Body.Bytes(), &authors); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(authors) != 2 || authors[0].Name != "Confucius" {
+		t.Fatalf("handler returned unexpected authors: %+v", authors)
+	}
+	path := fmt.Sprintf("/authors/%d", authors[0].ID)
+
+	var texts []string
+	next := path + "/quotes?limit=2"
+	for next != "" {
+		rr = serveJSON(router, "GET", next, nil)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var list quoteList
+		if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		for _, quote := range list.Quotes {
+			texts = append(texts, quote.Text)
+		}
+		next = ""
+		if list.NextCursor != "" {
+			next = path + "/quotes?limit=2&cursor=" + list.NextCursor
+		}
+	}
+	if want := []string{"First", "Third", "Fourth"}; !slices.Equal(texts, want) {
+		t.Errorf("handler returned unexpected quotes: got %v want %v", texts, want)
+	}
+
+	if status := serveJSON(router, "GET", "/authors/1000/quotes", nil).Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for unknown author: got %v want %v", status, http.StatusNotFound)
+	}
+	if status := serveJSON(router, "DELETE", path, nil).Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code for author with quotes: got %v want %v", status, http.StatusConflict)
+	}
+}
+
+// TestAuthorRenameQuotes проверяет, что переименование автора записывается в историю
+// цитат от имени пользователя
+func TestAuthorRenameQuotes(t *testing.T) {
+	router := setupTestServer()
+
+	for _, text := range []string{"First", "Second"} {
+		if status := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Confucius", Text: text}).Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	}
+	if status := serveAs(router, "alice", "PUT", "/authors/1", models.Author{Name: "Kong Qiu"}).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	for _, id := range []int64{1, 2} {
+		rr := serveJSON(router, "GET", fmt.Sprintf("/quotes/%d/history", id), nil)
+		var history []models.Revision
+		if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		last := history[len(history)-1]
+		if len(history) != 2 || last.Action != models.RevisionUpdate || last.ChangedBy != "alice" || last.Quote.Author != "Kong Qiu" {
+			t.Errorf("unexpected history of quote %d after renaming the author: %+v", id, history)
+		}
+	}
+}
+
+// staleQuotes изменяет первую цитату сразу после того, как она прочитана
+type staleQuotes struct {
+	services.QuoteRepository
+}
+
+func (r staleQuotes) List(query models.QuoteQuery, page models.Page) (models.QuotePage, error) {
+	result, err := r.QuoteRepository.List(query, page)
+	if err == nil && len(result.Quotes) > 0 {
+		changed := result.Quotes[0]
+		changed.Text = "Changed meanwhile"
+		err = r.QuoteRepository.Update(&changed)
+	}
+	return result, err
+}
+
+// TestAuthorRenameConflict проверяет, что переименование автора не затирает изменения
+// цитат, сделанные после их чтения, и не оставляет автора переименованным
+func TestAuthorRenameConflict(t *testing.T) {
+	quotes := memory.NewQuoteStorage()
+	authors := services.NewAuthorService(authormemory.NewAuthorStorage(), staleQuotes{quotes})
+
+	for _, text := range []string{"First", "Second"} {
+		quote := models.Quote{Author: "Confucius", Text: text}
+		if err := authors.ResolveAuthor(&quote); err != nil {
+			t.Fatalf("failed to resolve author: %v", err)
+		}
+		if err := quotes.Create(&quote); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+
+	err := authors.UpdateAuthor(&models.Author{ID: 1, Name: "Kong Qiu"}, "alice")
+	if !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Fatalf("unexpected error renaming the author: got %v want %v", err, storage.ErrVersionMismatch)
+	}
+	if author, err := authors.GetAuthorByID(1); err != nil || author.Name != "Confucius" {
+		t.Errorf("author was not restored: %+v, %v", author, err)
+	}
+	all, _ := quotes.GetAll()
+	for _, quote := range all {
+		if quote.Author != "Confucius" {
+			t.Errorf("quote %d was renamed: %+v", quote.ID, quote)
+		}
+	}
+	if all[0].Text != "Changed meanwhile" {
+		t.Errorf("concurrent change was lost: %+v", all[0])
+	}
+}
+
+// TestLinkQuotesKeepsVersions проверяет, что привязка старых цитат к авторам не меняет
+// их версии и историю
+func TestLinkQuotesKeepsVersions(t *testing.T) {
+	quotes := memory.NewQuoteStorage()
+	quote := models.Quote{Author: "Confucius", Text: "First"}
+	if err := quotes.Create(&quote); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+
+	authors := services.NewAuthorService(authormemory.NewAuthorStorage(), quotes)
+	if linked, err := authors.LinkQuotes(); err != nil || linked != 1 {
+		t.Fatalf("unexpected result of linking: %d, %v", linked, err)
+	}
+
+	got, err := quotes.GetByID(quote.ID)
+	if err != nil {
+		t.Fatalf("failed to get quote: %v", err)
+	}
+	if got.AuthorID != 1 || got.Version != quote.Version || !got.UpdatedAt.Equal(quote.UpdatedAt) {
+		t.Errorf("unexpected quote after linking: %+v", got)
+	}
+	if revisions, _ := quotes.Revisions(quote.ID); len(revisions) != 1 {
+		t.Errorf("linking recorded revisions: %+v", revisions)
+	}
+}
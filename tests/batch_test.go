@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type batchReport struct {
+	Created int `json:"created"`
+	Failed  int `json:"failed"`
+	Results []struct {
+		Index      int    `json:"index"`
+		ID         int64  `json:"id"`
+		Error      string `json:"error"`
+		ExistingID int64  `json:"existing_id"`
+	} `json:"results"`
+}
+
+// postBatch отправляет пакет цитат и возвращает отчет
+func postBatch(t *testing.T, handler http.Handler, query, contentType, body string, want int) batchReport {
+	t.Helper()
+
+	req, _ := http.NewRequest("POST", "/quotes/batch"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != want {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, want)
+	}
+	var report batchReport
+	if want != http.StatusBadRequest {
+		if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+	}
+	return report
+}
+
+// TestCreateQuotesBatch проверяет пакетное добавление цитат в обоих режимах
+func TestCreateQuotesBatch(t *testing.T) {
+	router := setupTestServer()
+
+	report := postBatch(t, router, "", "application/json", `[
+		{"author": "Confucius", "quote": "Life is simple"},
+		{"author": "Seneca", "quote": "Luck is what happens", "tags": ["Luck"]}
+	]`, http.StatusCreated)
+	if report.Created != 2 || report.Results[0].ID == 0 || report.Results[1].ID <= report.Results[0].ID {
+		t.Errorf("handler returned unexpected report: %+v", report)
+	}
+
+	invalid := `[
+		{"author": "Confucius", "quote": "Real knowledge"},
+		{"author": "Confucius", "quote": ""},
+		{"author": "Seneca", "quote": "Text", "rating": 7},
+		{"author": "Seneca", "quote": "Text", "source": {"kind": "tweet", "title": "x"}}
+	]`
+	report = postBatch(t, router, "?mode=atomic", "application/json", invalid, http.StatusUnprocessableEntity)
+	wantErrors := []string{
+		"batch rejected because of invalid quotes",
+		"quote text cannot be empty",
+		"invalid quote rating",
+		"invalid quote source",
+	}
+	if report.Created != 0 || report.Failed != 4 || len(report.Results) != 4 {
+		t.Fatalf("handler returned unexpected report: %+v", report)
+	}
+	for i, want := range wantErrors {
+		if got := report.Results[i].Error; got != want {
+			t.Errorf("handler returned unexpected error for row %d: got %q want %q", i, got, want)
+		}
+	}
+
+	report = postBatch(t, router, "?mode=best_effort", "application/json", invalid, http.StatusOK)
+	if report.Created != 1 || report.Failed != 3 || report.Results[0].ID == 0 || report.Results[0].Error != "" {
+		t.Errorf("handler returned unexpected report: %+v", report)
+	}
+
+	ndjson := `{"author": "Mark Twain", "quote": "Get your facts first"}
+
+{"author_id": 1000, "quote": "Nobody said it"}
+`
+	report = postBatch(t, router, "?mode=best_effort", "application/x-ndjson", ndjson, http.StatusOK)
+	if report.Created != 1 || report.Results[1].Error != "author not found" {
+		t.Errorf("handler returned unexpected report: %+v", report)
+	}
+
+	rr := serveJSON(router, "GET", "/quotes", nil)
+	var list quoteList
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(list.Quotes) != 4 {
+		t.Errorf("unexpected number of stored quotes: got %v want %v", len(list.Quotes), 4)
+	}
+
+	postBatch(t, router, "?mode=all", "application/json", "[]", http.StatusBadRequest)
+	postBatch(t, router, "", "application/json", `{"author": "Confucius"}`, http.StatusBadRequest)
+	postBatch(t, router, "", "application/x-ndjson", "{\"author\": \"Confucius\"}\n{", http.StatusBadRequest)
+}
+
+// endlessArray отдает JSON-массив, который никогда не заканчивается
+type endlessArray struct {
+	started bool
+}
+
+func (r *endlessArray) Read(p []byte) (int, error) {
+	n := 0
+	if !r.started {
+		p[0] = '['
+		n, r.started = 1, true
+	}
+	const item = `{"author": "Confucius", "text": "Quote"},`
+	for len(p)-n >= len(item) {
+		n += copy(p[n:], item)
+	}
+	return n, nil
+}
+
+// TestCreateQuotesBatchTooLarge проверяет, что слишком большой массив
+// отклоняется без чтения до конца
+func TestCreateQuotesBatchTooLarge(t *testing.T) {
+	router := setupTestServer()
+
+	req, _ := http.NewRequest("POST", "/quotes/batch", &endlessArray{})
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if !strings.Contains(rr.Body.String(), "more than") {
+		t.Errorf("handler returned unexpected body: %v", rr.Body.String())
+	}
+}
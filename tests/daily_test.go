@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage/quotes/memory"
+)
+
+// TestGetDailyQuote проверяет, что цитата дня одинакова для всех часовых поясов
+// в пределах одной календарной даты
+func TestGetDailyQuote(t *testing.T) {
+	router := setupTestServer()
+
+	if status := serveJSON(router, "GET", "/quotes/daily", nil).Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for empty storage: got %v want %v", status, http.StatusNotFound)
+	}
+
+	for i := range 10 {
+		quote := models.Quote{Author: "Confucius", Text: fmt.Sprintf("Quote %d", i)}
+		if status := serveJSON(router, "POST", "/quotes", quote).Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	}
+
+	// Цитаты, добавленные сегодня, участвуют в выборе только с послезавтрашнего дня.
+	date := time.Now().AddDate(0, 0, 2).Format(time.DateOnly)
+	var ids []int64
+	for _, tz := range []string{"", "Europe/Moscow", "America/New_York", "Pacific/Kiritimati"} {
+		rr := serveJSON(router, "GET", "/quotes/daily?date="+date+"&tz="+tz, nil)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code for tz %q: got %v want %v", tz, status, http.StatusOK)
+		}
+		var quote models.Quote
+		if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		ids = append(ids, quote.ID)
+	}
+	for _, id := range ids[1:] {
+		if id != ids[0] {
+			t.Errorf("handler returned different quotes for the same date: %v", ids)
+			break
+		}
+	}
+
+	errorTests := []string{
+		"date=2026-13-01",
+		"date=17.10.2026",
+		"tz=Mars/Olympus",
+	}
+	for _, query := range errorTests {
+		if status := serveJSON(router, "GET", "/quotes/daily?"+query, nil).Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestDailyQuotesWindow проверяет, что цитата дня не повторяется в пределах окна,
+// не зависит от экземпляра сервиса и пропускает удаленные цитаты
+func TestDailyQuotesWindow(t *testing.T) {
+	const window = 5
+
+	repo := memory.NewQuoteStorage()
+	for i := range 30 {
+		if err := repo.Create(&models.Quote{Author: "Confucius", Text: fmt.Sprintf("Quote %d", i)}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	daily := services.NewDailyQuotes(repo, "seed", window)
+	replica := services.NewDailyQuotes(repo, "seed", window)
+
+	start := time.Now().AddDate(0, 0, 2)
+	var ids []int64
+	for i := range 60 {
+		date := start.AddDate(0, 0, i)
+		quote, err := daily.Get(date)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", date.Format(time.DateOnly), err)
+		}
+		other, err := replica.Get(date)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", date.Format(time.DateOnly), err)
+		}
+		if other.ID != quote.ID {
+			t.Errorf("Get(%s) differs between instances: %d and %d", date.Format(time.DateOnly), quote.ID, other.ID)
+		}
+		ids = append(ids, quote.ID)
+	}
+	for i, id := range ids {
+		for j := max(0, i-window+1); j < i; j++ {
+			if ids[j] == id {
+				t.Errorf("quote %d repeated on days %d and %d within window %d", id, j, i, window)
+			}
+		}
+	}
+
+	if err := repo.Delete(ids[0], 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	survivor, err := repo.GetByID(ids[2])
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if err := repo.Merge(survivor, []models.Quote{{ID: ids[1]}}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	quote, err := daily.Get(start)
+	if err != nil {
+		t.Fatalf("Get after Delete failed: %v", err)
+	}
+	if quote.ID == ids[0] {
+		t.Errorf("Get returned deleted quote %d", quote.ID)
+	}
+
+	// Removing quotes shown earlier in the block does not change the quotes of
+	// the following days.
+	fresh := services.NewDailyQuotes(repo, "seed", window)
+	for i := 2; i < 60; i++ {
+		if ids[i] == ids[0] || ids[i] == ids[1] {
+			continue
+		}
+		date := start.AddDate(0, 0, i)
+		quote, err := fresh.Get(date)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", date.Format(time.DateOnly), err)
+		}
+		if quote.ID != ids[i] {
+			t.Errorf("Get(%s) after Delete and Merge: got %d want %d", date.Format(time.DateOnly), quote.ID, ids[i])
+		}
+	}
+}
+
+// TestDailyQuotesCached проверяет, что цитата начавшегося дня, удаленная после
+// показа, заменяется одинаково с кэшем и без него
+func TestDailyQuotesCached(t *testing.T) {
+	repo := memory.NewQuoteStorage()
+	createdAt := time.Now().AddDate(0, 0, -10)
+	for i := range 10 {
+		quote := models.Quote{ID: int64(i + 1), Author: "Confucius", Text: fmt.Sprintf("Quote %d", i), CreatedAt: createdAt}
+		if err := repo.Insert(quote); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+	daily := services.NewDailyQuotes(repo, "seed", 5)
+
+	date := time.Now().AddDate(0, 0, -2)
+	quote, err := daily.Get(date)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if err := repo.Delete(quote.ID, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	cached, err := daily.Get(date)
+	if err != nil {
+		t.Fatalf("Get after Delete failed: %v", err)
+	}
+	fresh, err := services.NewDailyQuotes(repo, "seed", 5).Get(date)
+	if err != nil {
+		t.Fatalf("Get after Delete failed: %v", err)
+	}
+	if cached.ID == quote.ID || cached.ID != fresh.ID {
+		t.Errorf("Get after Delete: got %d and %d without cache, deleted %d", cached.ID, fresh.ID, quote.ID)
+	}
+}
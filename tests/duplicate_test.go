@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+	authormemory "quotes/internal/storage/authors/memory"
+	"quotes/internal/storage/quotes/memory"
+)
+
+type duplicateResponse struct {
+	ExistingID int64 `json:"existing_id"`
+	Exact      bool  `json:"exact"`
+}
+
+// TestNearDuplicate проверяет нормализацию текста и поиск почти одинаковых цитат
+func TestNearDuplicate(t *testing.T) {
+	if got, want := models.NormalizeText("  Life is SIMPLE — but we insist...\n"), "life is simple but we insist"; got != want {
+		t.Errorf("NormalizeText: got %q want %q", got, want)
+	}
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"life is really simple but we insist on making it complicated", "life is really simple but we insist on making it complicated", true},
+		{"life is really simple but we insist on making it complicated", "life is realy simple but we insist on making it complicted", true},
+		{"life is really simple but we insist on making it complicated", "life is really hard and we insist on making it easier", false},
+		{"quote 1", "quote 2", false},
+	}
+	for _, tt := range tests {
+		if got := models.NearDuplicate(tt.a, tt.b); got != tt.want {
+			t.Errorf("NearDuplicate(%q, %q): got %v want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestCreateQuoteDuplicate проверяет отказ в добавлении повторяющихся цитат
+func TestCreateQuoteDuplicate(t *testing.T) {
+	router := setupTestServer()
+
+	original := models.Quote{Author: "Confucius", Text: "Life is really simple, but we insist on making it complicated."}
+	rr := serveJSON(router, "POST", "/quotes", original)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &original); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	conflictTests := []struct {
+		quote models.Quote
+		exact bool
+	}{
+		{models.Quote{Author: "confucius", Text: "LIFE is really simple — but we insist on making it complicated!"}, true},
+		{models.Quote{Author: "Confucius", Text: "Life is realy simple, but we insist on making it complicated"}, false},
+	}
+	for _, tt := range conflictTests {
+		rr := serveJSON(router, "POST", "/quotes", tt.quote)
+		if status := rr.Code; status != http.StatusConflict {
+			t.Errorf("handler returned wrong status code for %q: got %v want %v", tt.quote.Text, status, http.StatusConflict)
+			continue
+		}
+		var dup duplicateResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &dup); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if dup.ExistingID != original.ID || dup.Exact != tt.exact {
+			t.Errorf("handler returned unexpected duplicate for %q: %+v", tt.quote.Text, dup)
+		}
+		if location := rr.Header().Get("Location"); location != "/quotes/1" {
+			t.Errorf("handler returned unexpected Location: %q", location)
+		}
+	}
+
+	// Тот же текст другого автора не считается повтором.
+	other := models.Quote{Author: "Seneca", Text: original.Text}
+	if status := serveJSON(router, "POST", "/quotes", other).Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code for another author: got %v want %v", status, http.StatusCreated)
+	}
+	if status := serveJSON(router, "POST", "/quotes?allow_duplicate=true", original).Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code with allow_duplicate: got %v want %v", status, http.StatusCreated)
+	}
+	if status := serveJSON(router, "POST", "/quotes?allow_duplicate=maybe", original).Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for invalid allow_duplicate: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+// TestCreateQuotesBatchDuplicate проверяет повторы среди цитат пакета и уже добавленных цитат
+func TestCreateQuotesBatchDuplicate(t *testing.T) {
+	router := setupTestServer()
+
+	if status := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Confucius", Text: "Real knowledge is to know the extent of one's ignorance."}).Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	body := `[
+		{"author": "Confucius", "quote": "Real knowledge is to know the extent of ones ignorance"},
+		{"author": "Seneca", "quote": "Luck is what happens when preparation meets opportunity."},
+		{"author": "Seneca", "quote": "luck is what happens when preparation meets opportunity"}
+	]`
+	report := postBatch(t, router, "?mode=best_effort", "application/json", body, http.StatusOK)
+	if report.Created != 1 || report.Failed != 2 {
+		t.Fatalf("handler returned unexpected report: %+v", report)
+	}
+	created := report.Results[1].ID
+	if got := report.Results[0]; got.Error != "quote already exists" || got.ExistingID != 1 {
+		t.Errorf("handler returned unexpected result for existing duplicate: %+v", got)
+	}
+	if got := report.Results[2]; got.Error != "quote already exists" || got.ExistingID != created {
+		t.Errorf("handler returned unexpected result for duplicate within batch: %+v", got)
+	}
+
+	report = postBatch(t, router, "?allow_duplicate=true", "application/json", body, http.StatusCreated)
+	if report.Created != 3 {
+		t.Errorf("handler returned unexpected report with allow_duplicate: %+v", report)
+	}
+}
+
+// TestDuplicateCreatesNoAuthor проверяет, что отклоненные повторы не добавляют авторов
+func TestDuplicateCreatesNoAuthor(t *testing.T) {
+	router := setupTestServer()
+
+	text := "Real knowledge is to know the extent of one's ignorance."
+	if status := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Confucius", Text: text}).Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if status := serveJSON(router, "PUT", "/authors/1", models.Author{Name: "Confucius", Aliases: []string{"Kong Fuzi"}}).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code for author update: got %v want %v", status, http.StatusOK)
+	}
+
+	// Повтор ищется среди цитат автора, найденного по псевдониму.
+	if status := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Kong Fuzi", Text: text}).Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code for alias: got %v want %v", status, http.StatusConflict)
+	}
+
+	body := `[
+		{"author": "Plato", "quote": "Wise men speak because they have something to say."},
+		{"author": "Confucius", "quote": "Real knowledge is to know the extent of ones ignorance"}
+	]`
+	postBatch(t, router, "?mode=atomic", "application/json", body, http.StatusUnprocessableEntity)
+
+	rr := serveJSON(router, "GET", "/authors", nil)
+	var authors []models.Author
+	if err := json.Unmarshal(rr.Body.Bytes(), &authors); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(authors) != 1 || authors[0].Name != "Confucius" {
+		t.Errorf("rejected quotes created authors: %+v", authors)
+	}
+}
+
+// racingQuotes перед каждой проверкой повтора в хранилище добавляет такие же цитаты,
+// как если бы их успел добавить другой запрос
+type racingQuotes struct {
+	services.QuoteRepository
+}
+
+func (r racingQuotes) By(changedBy string) services.QuoteRepository {
+	return racingQuotes{r.QuoteRepository.By(changedBy)}
+}
+
+func (r racingQuotes) CreateUnique(quotes []models.Quote) error {
+	for _, quote := range quotes {
+		other := models.Quote{Author: quote.Author, Text: quote.Text}
+		if err := r.QuoteRepository.Create(&other); err != nil {
+			return err
+		}
+	}
+	return r.QuoteRepository.CreateUnique(quotes)
+}
+
+// TestDuplicateRaceCreatesNoAuthor проверяет, что автор, созданный для цитаты, которая
+// проиграла гонку с таким же повтором, удаляется
+func TestDuplicateRaceCreatesNoAuthor(t *testing.T) {
+	quotes := memory.NewQuoteStorage()
+	authors := services.NewAuthorService(authormemory.NewAuthorStorage(), quotes)
+	service := services.NewQuoteService(racingQuotes{quotes}, authors, services.QuoteOptions{})
+
+	err := service.CreateQuote(&models.Quote{Author: "Plato", Text: "Wise men speak because they have something to say."}, false, "")
+	if !errors.Is(err, storage.ErrDuplicateQuote) {
+		t.Fatalf("unexpected error: got %v want %v", err, storage.ErrDuplicateQuote)
+	}
+
+	batch := []models.Quote{
+		{Author: "Seneca", Text: "Luck is what happens when preparation meets opportunity."},
+		{Author: "Seneca", Text: "Difficulties strengthen the mind, as labor does the body."},
+	}
+	results, err := service.CreateQuotes(batch, models.BatchBestEffort, false, "")
+	if err != nil {
+		t.Fatalf("failed to create quotes: %v", err)
+	}
+	for i, result := range results {
+		if !errors.Is(result.Err, storage.ErrDuplicateQuote) {
+			t.Errorf("unexpected result of quote %d: %+v", i, result)
+		}
+	}
+
+	if all, err := authors.GetAllAuthors(); err != nil || len(all) != 0 {
+		t.Errorf("authors of rejected quotes were kept: %+v, %v", all, err)
+	}
+}
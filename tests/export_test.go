@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"quotes/internal/domain/models"
+)
+
+// TestExportQuotes проверяет выгрузку цитат в форматах NDJSON, CSV и JSON
+func TestExportQuotes(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveJSON(router, "GET", "/quotes/export?format=json", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var empty []models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &empty); err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("handler returned unexpected empty export: %q", rr.Body.String())
+	}
+
+	tricky := models.Quote{
+		Author: "Mark Twain",
+		Text:   "Get your facts first, then you can \"distort\" them\nas you please.",
+		Tags:   []string{"facts", "humor"},
+		Source: &models.Source{Kind: models.SourceBook, Title: "Autobiography, vol. 1"},
+	}
+	if status := serveJSON(router, "POST", "/quotes", tricky).Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var batch []models.Quote
+	for i := range 1200 {
+		batch = append(batch, models.Quote{Author: "Confucius", Text: fmt.Sprintf("Quote %d", i)})
+	}
+	if status := serveJSON(router, "POST", "/quotes/batch", batch).Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	rr = serveJSON(router, "GET", "/quotes/export", nil)
+	if got := rr.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("handler returned wrong content type: got %q want %q", got, "application/x-ndjson")
+	}
+	var ids []int64
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var quote models.Quote
+		if err := json.Unmarshal(scanner.Bytes(), &quote); err != nil {
+			t.Fatalf("failed to unmarshal line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, quote.ID)
+	}
+	if len(ids) != 1201 || ids[0] != 1 || ids[1200] != 1201 {
+		t.Errorf("handler exported unexpected quotes: %d quotes from %d", len(ids), ids[0])
+	}
+
+	rr = serveJSON(router, "GET", "/quotes/export?format=csv&author=Mark+Twain", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	disposition := rr.Header().Get("Content-Disposition")
+	if !strings.HasPrefix(disposition, "attachment; filename=quotes-") || !strings.HasSuffix(disposition, ".csv") {
+		t.Errorf("handler returned unexpected Content-Disposition: %q", disposition)
+	}
+	records, err := csv.NewReader(bytes.NewReader(rr.Body.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	if len(records) != 2 || records[0][3] != "quote" {
+		t.Fatalf("handler returned unexpected CSV: %q", records)
+	}
+	row := records[1]
+	if row[1] != tricky.Author || row[3] != tricky.Text || row[4] != "facts;humor" || row[8] != tricky.Source.Title {
+		t.Errorf("CSV row does not match the quote: %q", row)
+	}
+
+	rr = serveJSON(router, "GET", "/quotes/export?format=json&author=Confucius", nil)
+	var quotes []models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quotes); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(quotes) != 1200 {
+		t.Errorf("handler exported unexpected number of quotes: got %v want %v", len(quotes), 1200)
+	}
+
+	if status := serveJSON(router, "GET", "/quotes/export?format=xml", nil).Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for unknown format: got %v want %v", status, http.StatusBadRequest)
+	}
+}
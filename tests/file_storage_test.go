@@ -0,0 +1,587 @@
+package tests
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"quotes/internal/domain/models"
+	authorfile "quotes/internal/storage/authors/file"
+	"quotes/internal/storage/filelock"
+	"quotes/internal/storage/quotes/file"
+)
+
+// TestFileStorageReplay проверяет восстановление цитат и счетчика ID после перезапуска
+func TestFileStorageReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	for _, text := range []string{"First", "Second", "Third"} {
+		if err := s.Create(&models.Quote{Author: "Test Author", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	if err := s.CreateBatch([]models.Quote{
+		{Author: "Test Author", Text: "Batch first"},
+		{Author: "Test Author", Text: "Batch second"},
+	}); err != nil {
+		t.Fatalf("failed to create quotes: %v", err)
+	}
+	if err := s.Delete(3, 0); err != nil {
+		t.Fatalf("failed to delete quote: %v", err)
+	}
+	if err := s.Update(&models.Quote{ID: 1, Author: "Test Author", Text: "Updated"}); err != nil {
+		t.Fatalf("failed to update quote: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	s, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	defer s.Close()
+
+	updated, err := s.GetByID(1)
+	if err != nil {
+		t.Fatalf("failed to get quote: %v", err)
+	}
+	if updated.Text != "Updated" || updated.UpdatedAt.IsZero() {
+		t.Errorf("update was not replayed: %+v", updated)
+	}
+
+	quotes, err := s.GetAll()
+	if err != nil {
+		t.Fatalf("failed to get quotes: %v", err)
+	}
+	if len(quotes) != 4 || quotes[3].Text != "Batch second" {
+		t.Fatalf("unexpected quotes after replay: got %+v", quotes)
+	}
+
+	quote := models.Quote{Author: "Test Author", Text: "Sixth"}
+	if err := s.Create(&quote); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+	if quote.ID != 6 {
+		t.Errorf("unexpected ID after replay: got %v want %v", quote.ID, 6)
+	}
+}
+
+// TestFileStorageMerge проверяет, что слияние цитат восстанавливается из журнала и из снимка
+func TestFileStorageMerge(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	for _, text := range []string{"First", "Second", "Third"} {
+		if err := s.Create(&models.Quote{Author: "Test Author", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	if err := s.Merge(&models.Quote{ID: 1, Author: "Test Author", Text: "First", Tags: []string{"merged"}}, []models.Quote{{ID: 2}, {ID: 3}}); err != nil {
+		t.Fatalf("failed to merge quotes: %v", err)
+	}
+
+	for _, snapshot := range []bool{false, true} {
+		if snapshot {
+			if err := s.Snapshot(); err != nil {
+				t.Fatalf("failed to take snapshot: %v", err)
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("failed to close storage: %v", err)
+		}
+		s, err = file.NewQuoteStorage(dir, file.Options{})
+		if err != nil {
+			t.Fatalf("failed to reopen storage: %v", err)
+		}
+
+		quotes, err := s.GetAll()
+		if err != nil {
+			t.Fatalf("failed to get quotes: %v", err)
+		}
+		if len(quotes) != 1 || len(quotes[0].Tags) != 1 || quotes[0].Version != 2 {
+			t.Errorf("unexpected quotes after reopening (snapshot %v): got %+v", snapshot, quotes)
+		}
+		for _, id := range []int64{2, 3} {
+			if to, err := s.Redirect(id); err != nil || to != 1 {
+				t.Errorf("unexpected redirect of quote %d after reopening (snapshot %v): got %d, %v", id, snapshot, to, err)
+			}
+		}
+		if created, err := s.Created(time.Now()); err != nil || len(created) != 3 {
+			t.Errorf("unexpected created quotes after reopening (snapshot %v): got %+v, %v", snapshot, created, err)
+		}
+	}
+	s.Close()
+}
+
+// TestFileStorageTrash проверяет, что корзина, восстановление и очистка восстанавливаются
+// из журнала и из снимка
+func TestFileStorageTrash(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	for _, text := range []string{"First", "Second", "Third", "Fourth"} {
+		if err := s.Create(&models.Quote{Author: "Test Author", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	for _, id := range []int64{1, 2, 3} {
+		if err := s.Delete(id, 0); err != nil {
+			t.Fatalf("failed to delete quote: %v", err)
+		}
+	}
+	if _, err := s.Undelete(2); err != nil {
+		t.Fatalf("failed to restore quote: %v", err)
+	}
+	trash, err := s.Trash()
+	if err != nil {
+		t.Fatalf("failed to get trash: %v", err)
+	}
+	if _, err := s.Purge(trash[1].DeletedAt.Add(time.Nanosecond)); err != nil {
+		t.Fatalf("failed to purge trash: %v", err)
+	}
+
+	for _, snapshot := range []bool{false, true} {
+		if snapshot {
+			if err := s.Snapshot(); err != nil {
+				t.Fatalf("failed to take snapshot: %v", err)
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("failed to close storage: %v", err)
+		}
+		s, err = file.NewQuoteStorage(dir, file.Options{})
+		if err != nil {
+			t.Fatalf("failed to reopen storage: %v", err)
+		}
+
+		quotes, err := s.GetAll()
+		if err != nil {
+			t.Fatalf("failed to get quotes: %v", err)
+		}
+		if len(quotes) != 2 || quotes[0].ID != 2 || quotes[1].ID != 4 {
+			t.Errorf("unexpected quotes after reopening (snapshot %v): got %+v", snapshot, quotes)
+		}
+		trash, err := s.Trash()
+		if err != nil {
+			t.Fatalf("failed to get trash: %v", err)
+		}
+		if len(trash) != 1 || trash[0].ID != 3 || trash[0].DeletedAt.IsZero() {
+			t.Errorf("unexpected trash after reopening (snapshot %v): got %+v", snapshot, trash)
+		}
+	}
+
+	quote := models.Quote{Author: "Test Author", Text: "Fifth"}
+	if err := s.Create(&quote); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+	if quote.ID != 5 {
+		t.Errorf("unexpected ID after reopening: got %v want %v", quote.ID, 5)
+	}
+	s.Close()
+}
+
+// TestFileStorageIgnoresDeletedAt проверяет, что переданное клиентом время удаления не
+// отправляет живую цитату в корзину после перезапуска
+func TestFileStorageIgnoresDeletedAt(t *testing.T) {
+	dir := t.TempDir()
+	past := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	if err := s.Create(&models.Quote{Author: "Test Author", Text: "First", DeletedAt: past}); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+	if err := s.Create(&models.Quote{Author: "Test Author", Text: "Second"}); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+	if err := s.Update(&models.Quote{ID: 2, Author: "Test Author", Text: "Updated", DeletedAt: past}); err != nil {
+		t.Fatalf("failed to update quote: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	s, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	defer s.Close()
+
+	if n, err := s.Purge(time.Now()); err != nil || n != 0 {
+		t.Errorf("unexpected purge after reopening: got %d, %v want %d", n, err, 0)
+	}
+	quotes, err := s.GetAll()
+	if err != nil {
+		t.Fatalf("failed to get quotes: %v", err)
+	}
+	if len(quotes) != 2 || !quotes[0].DeletedAt.IsZero() || !quotes[1].DeletedAt.IsZero() {
+		t.Errorf("unexpected quotes after reopening: got %+v", quotes)
+	}
+}
+
+// TestFileStorageTornRecord проверяет, что оборванная последняя запись не мешает запуску
+func TestFileStorageTornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	for _, text := range []string{"First", "Second"} {
+		if err := s.Create(&models.Quote{Author: "Test Author", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	logPath := findLogFile(t, dir)
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("failed to stat log: %v", err)
+	}
+	if err := os.Truncate(logPath, info.Size()-3); err != nil {
+		t.Fatalf("failed to truncate log: %v", err)
+	}
+
+	s, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to reopen storage with torn record: %v", err)
+	}
+	defer s.Close()
+
+	quotes, err := s.GetAll()
+	if err != nil {
+		t.Fatalf("failed to get quotes: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Text != "First" {
+		t.Fatalf("unexpected quotes after torn record: %+v", quotes)
+	}
+
+	quote := models.Quote{Author: "Test Author", Text: "Third"}
+	if err := s.Create(&quote); err != nil {
+		t.Fatalf("failed to create quote after torn record: %v", err)
+	}
+	if quote.ID != 2 {
+		t.Errorf("unexpected ID after torn record: got %v want %v", quote.ID, 2)
+	}
+}
+
+// TestFileStorageCorruptRecord проверяет, что поврежденная запись в середине журнала
+// не отбрасывается вместе со следующими, а останавливает запуск
+func TestFileStorageCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	for _, text := range []string{"First", "Second", "Third"} {
+		if err := s.Create(&models.Quote{Author: "Test Author", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	logPath := findLogFile(t, dir)
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	// Портится последний байт второй записи: после нее идет третья.
+	first := 8 + int(binary.LittleEndian.Uint32(data[0:4]))
+	second := first + 8 + int(binary.LittleEndian.Uint32(data[first:first+4]))
+	data[second-1] ^= 0xff
+	if err := os.WriteFile(logPath, data, 0o644); err != nil {
+		t.Fatalf("failed to corrupt log: %v", err)
+	}
+
+	_, err = file.NewQuoteStorage(dir, file.Options{})
+	if err == nil {
+		t.Fatal("storage with a corrupt record in the middle of the log opened")
+	}
+	if want := fmt.Sprintf("offset %d", first); !strings.Contains(err.Error(), want) {
+		t.Errorf("unexpected error: %v, want it to mention %q", err, want)
+	}
+	if info, err := os.Stat(logPath); err != nil || info.Size() != int64(len(data)) {
+		t.Errorf("log was changed after a failed open: %v, %v", info, err)
+	}
+}
+
+func findLogFile(t *testing.T, dir string) string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil || len(matches) == 0 {
+		t.Fatalf("log file not found in %s", dir)
+	}
+	return matches[len(matches)-1]
+}
+
+// TestFileStorageSnapshot проверяет восстановление из снимка и хвоста журнала
+func TestFileStorageSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{SnapshotThreshold: 2})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	for _, text := range []string{"First", "Second", "Third"} {
+		if err := s.Create(&models.Quote{Author: "Test Author", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	if err := s.Delete(3, 0); err != nil {
+		t.Fatalf("failed to delete quote: %v", err)
+	}
+	if err := s.Snapshot(); err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if err := s.Create(&models.Quote{Author: "Test Author", Text: "Fourth"}); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	snapshots, _ := filepath.Glob(filepath.Join(dir, "*.snap"))
+	logs, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(snapshots) > 2 || len(logs) > 2 {
+		t.Errorf("old generations were not removed: %v %v", snapshots, logs)
+	}
+
+	s, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	quotes, _ := s.GetAll()
+	if len(quotes) != 3 {
+		t.Fatalf("unexpected number of quotes after restore: got %v want %v", len(quotes), 3)
+	}
+	quote := models.Quote{Author: "Test Author", Text: "Fifth"}
+	if err := s.Create(&quote); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+	if quote.ID != 5 {
+		t.Errorf("unexpected ID after restore: got %v want %v", quote.ID, 5)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	// Порча последнего снимка должна приводить к откату на предыдущий
+	snapshots, _ = filepath.Glob(filepath.Join(dir, "*.snap"))
+	if err := os.WriteFile(snapshots[len(snapshots)-1], []byte("garbage"), 0o644); err != nil {
+		t.Fatalf("failed to corrupt snapshot: %v", err)
+	}
+
+	s, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to reopen storage with corrupted snapshot: %v", err)
+	}
+	defer s.Close()
+
+	quotes, _ = s.GetAll()
+	if len(quotes) != 4 {
+		t.Errorf("unexpected number of quotes after fallback: got %v want %v", len(quotes), 4)
+	}
+}
+
+// TestFileStorageLocked проверяет, что каталог данных нельзя открыть второй раз,
+// пока хранилища не закрыты
+func TestFileStorageLocked(t *testing.T) {
+	dir := t.TempDir()
+
+	quotes, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	authors, err := authorfile.NewAuthorStorage(dir)
+	if err != nil {
+		t.Fatalf("failed to open author storage: %v", err)
+	}
+
+	if _, err := file.NewQuoteStorage(dir, file.Options{}); !errors.Is(err, filelock.ErrLocked) {
+		t.Errorf("unexpected error opening locked storage: got %v want %v", err, filelock.ErrLocked)
+	}
+	if _, err := authorfile.NewAuthorStorage(dir); !errors.Is(err, filelock.ErrLocked) {
+		t.Errorf("unexpected error opening locked author storage: got %v want %v", err, filelock.ErrLocked)
+	}
+
+	for _, c := range []io.Closer{quotes, authors} {
+		if err := c.Close(); err != nil {
+			t.Fatalf("failed to close storage: %v", err)
+		}
+	}
+	quotes, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	quotes.Close()
+	authors, err = authorfile.NewAuthorStorage(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen author storage: %v", err)
+	}
+	authors.Close()
+}
+
+// TestFileStorageRevisions проверяет восстановление правок из журнала и снимка
+// после перезапуска
+func TestFileStorageRevisions(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	quote := models.Quote{Author: "Test Author", Text: "First"}
+	if err := s.By("alice").Create(&quote); err != nil {
+		t.Fatalf("failed to create quote: %v", err)
+	}
+	if err := s.By("bob").Update(&models.Quote{ID: quote.ID, Author: "Test Author", Text: "Updated"}); err != nil {
+		t.Fatalf("failed to update quote: %v", err)
+	}
+	if err := s.Snapshot(); err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if err := s.By("carol").Delete(quote.ID, 0); err != nil {
+		t.Fatalf("failed to delete quote: %v", err)
+	}
+	s.Close()
+
+	s, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	defer s.Close()
+
+	revisions, err := s.Revisions(quote.ID)
+	if err != nil {
+		t.Fatalf("failed to list revisions: %v", err)
+	}
+	var users []string
+	for _, revision := range revisions {
+		users = append(users, revision.ChangedBy)
+	}
+	if want := []string{"alice", "bob", "carol"}; !slices.Equal(users, want) {
+		t.Errorf("unexpected revisions after reopening: got %v want %v", users, want)
+	}
+}
+
+// TestFileAuthorStorageLog проверяет восстановление авторов из журнала после
+// перезапуска и его свертку в файл авторов
+func TestFileAuthorStorageLog(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := authorfile.NewAuthorStorage(dir)
+	if err != nil {
+		t.Fatalf("failed to open author storage: %v", err)
+	}
+	for _, name := range []string{"Confucius", "Seneca", "Plato"} {
+		if err := s.Create(&models.Author{Name: name}); err != nil {
+			t.Fatalf("failed to create author: %v", err)
+		}
+	}
+	if err := s.Update(&models.Author{ID: 2, Name: "Lucius Annaeus Seneca", Aliases: []string{"Seneca"}}); err != nil {
+		t.Fatalf("failed to update author: %v", err)
+	}
+	if err := s.Delete(3); err != nil {
+		t.Fatalf("failed to delete author: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "authors.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("authors file is written before compaction: %v", err)
+	}
+	s.Close()
+
+	s, err = authorfile.NewAuthorStorage(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen author storage: %v", err)
+	}
+	defer s.Close()
+
+	authors, _ := s.GetAll()
+	if len(authors) != 2 {
+		t.Fatalf("unexpected number of authors after reopen: got %v want %v", len(authors), 2)
+	}
+	if author, err := s.FindByName("seneca"); err != nil || author.ID != 2 || author.Name != "Lucius Annaeus Seneca" {
+		t.Errorf("unexpected author found by alias: %+v, %v", author, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "authors.log")); err != nil || info.Size() != 0 {
+		t.Errorf("authors log is not compacted on open: %v", err)
+	}
+
+	author := models.Author{Name: "Aristotle"}
+	if err := s.Create(&author); err != nil {
+		t.Fatalf("failed to create author: %v", err)
+	}
+	if author.ID != 4 {
+		t.Errorf("deleted author ID is reused: got %v want %v", author.ID, 4)
+	}
+}
+
+// TestFileStorageLegacyLog проверяет перенос журнала quotes.log, записанного до
+// появления снимков, и отказ открываться, если рядом есть новые журналы
+func TestFileStorageLegacyLog(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	for _, text := range []string{"First", "Second"} {
+		if err := s.Create(&models.Quote{Author: "Test Author", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	s.Close()
+
+	// Журнал нулевого поколения совпадает по формату со старым quotes.log
+	wal := filepath.Join(dir, "wal-00000000000000000000.log")
+	legacy := filepath.Join(dir, "quotes.log")
+	if err := os.Rename(wal, legacy); err != nil {
+		t.Fatalf("failed to rename log: %v", err)
+	}
+
+	s, err = file.NewQuoteStorage(dir, file.Options{})
+	if err != nil {
+		t.Fatalf("failed to open storage with legacy log: %v", err)
+	}
+	quotes, _ := s.GetAll()
+	if len(quotes) != 2 {
+		t.Errorf("unexpected number of quotes after migration: got %v want %v", len(quotes), 2)
+	}
+	s.Close()
+	if _, err := os.Stat(legacy); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("legacy log was not migrated: %v", err)
+	}
+
+	if err := os.WriteFile(legacy, nil, 0o644); err != nil {
+		t.Fatalf("failed to write legacy log: %v", err)
+	}
+	if _, err := file.NewQuoteStorage(dir, file.Options{}); err == nil {
+		t.Errorf("storage opened with both legacy and numbered logs")
+	}
+}
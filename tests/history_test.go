@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"quotes/internal/domain/models"
+
+	"github.com/gorilla/mux"
+)
+
+// serveAs выполняет запрос с телом в формате JSON от имени пользователя
+func serveAs(router *mux.Router, user, method, path string, body any) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := newJSONRequest(method, path, body)
+	req.Header.Set("X-User", user)
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestQuoteHistory проверяет запись правок цитаты, их просмотр, сравнение и откат
+func TestQuoteHistory(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveAs(router, "alice", "POST", "/quotes", models.Quote{Author: "Confucius", Text: "Life is simple", Tags: []string{"life"}})
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var quote models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	path := fmt.Sprintf("/quotes/%d", quote.ID)
+
+	update := models.Quote{Author: "Confucius", Text: "Life is really simple, but we make it hard", Tags: []string{"wisdom"}}
+	if status := serveAs(router, "bob", "PUT", path, update).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if status := serveAs(router, "carol", "DELETE", path, nil).Code; status != http.StatusNoContent {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+	if status := serveAs(router, "alice", "POST", path+"/restore", nil).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	rr = serveJSON(router, "GET", path+"/history", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var history []models.Revision
+	if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	var actions []models.RevisionAction
+	var users []string
+	for _, revision := range history {
+		actions = append(actions, revision.Action)
+		users = append(users, revision.ChangedBy)
+	}
+	wantActions := []models.RevisionAction{models.RevisionCreate, models.RevisionUpdate, models.RevisionDelete, models.RevisionRestore}
+	if !slices.Equal(actions, wantActions) {
+		t.Errorf("handler returned unexpected actions: got %v want %v", actions, wantActions)
+	}
+	if want := []string{"alice", "bob", "carol", "alice"}; !slices.Equal(users, want) {
+		t.Errorf("handler returned unexpected users: got %v want %v", users, want)
+	}
+	if history[2].Quote.DeletedAt.IsZero() || history[2].Quote.Text != update.Text {
+		t.Errorf("handler returned unexpected deleted revision: %+v", history[2])
+	}
+
+	rr = serveJSON(router, "GET", path+"/history/1", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var first models.Revision
+	if err := json.Unmarshal(rr.Body.Bytes(), &first); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if first.Number != 1 || first.Quote.Text != "Life is simple" || first.Quote.Version != 1 {
+		t.Errorf("handler returned unexpected revision: %+v", first)
+	}
+
+	rr = serveJSON(router, "GET", path+"/history/diff?from=1&to=2", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var diff models.RevisionDiff
+	if err := json.Unmarshal(rr.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	wantText := []models.DiffChunk{
+		{Op: models.DiffEqual, Text: "Life is"},
+		{Op: models.DiffDelete, Text: "simple"},
+		{Op: models.DiffInsert, Text: "really simple, but we make it hard"},
+	}
+	if !slices.Equal(diff.Text, wantText) {
+		t.Errorf("handler returned unexpected text diff: got %+v want %+v", diff.Text, wantText)
+	}
+	if !slices.Equal(diff.TagsAdded, []string{"wisdom"}) || !slices.Equal(diff.TagsRemoved, []string{"life"}) {
+		t.Errorf("handler returned unexpected tag diff: added %v, removed %v", diff.TagsAdded, diff.TagsRemoved)
+	}
+
+	// Без номеров сравниваются две последние правки.
+	rr = serveJSON(router, "GET", path+"/history/diff", nil)
+	if err := json.Unmarshal(rr.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if diff.From != 3 || diff.To != 4 {
+		t.Errorf("handler compared unexpected revisions: got %d and %d want %d and %d", diff.From, diff.To, 3, 4)
+	}
+
+	rr = serveJSON(router, "GET", path, nil)
+	etag := rr.Header().Get("ETag")
+	req := newJSONRequest("POST", path+"/history/1/revert", nil)
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusPreconditionFailed {
+		t.Errorf("handler returned wrong status code for stale version: got %v want %v", status, http.StatusPreconditionFailed)
+	}
+
+	req = newJSONRequest("POST", path+"/history/1/revert", nil)
+	req.Header.Set("If-Match", etag)
+	req.Header.Set("X-User", "dave")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var reverted models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &reverted); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if reverted.Text != "Life is simple" || !slices.Equal(reverted.Tags, []string{"life"}) || reverted.Version != 3 {
+		t.Errorf("handler returned unexpected quote: %+v", reverted)
+	}
+
+	rr = serveJSON(router, "GET", path+"/history/5", nil)
+	var last models.Revision
+	if err := json.Unmarshal(rr.Body.Bytes(), &last); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if last.Action != models.RevisionRevert || last.RevertedTo != 1 || last.ChangedBy != "dave" {
+		t.Errorf("handler returned unexpected revert revision: %+v", last)
+	}
+
+	errorTests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/quotes/1000/history", http.StatusNotFound},
+		{"GET", path + "/history/6", http.StatusNotFound},
+		{"GET", path + "/history/0", http.StatusBadRequest},
+		{"GET", path + "/history/diff?from=x", http.StatusBadRequest},
+		{"GET", path + "/history/diff?from=1&to=9", http.StatusNotFound},
+		{"POST", path + "/history/9/revert", http.StatusNotFound},
+	}
+	for _, tt := range errorTests {
+		if status := serveJSON(router, tt.method, tt.path, nil).Code; status != tt.status {
+			t.Errorf("handler returned wrong status code for %s %s: got %v want %v", tt.method, tt.path, status, tt.status)
+		}
+	}
+}
+
+// TestRevertAfterAuthorRename проверяет, что откат после переименования
+// автора оставляет цитату за ним и не создает автора со старым именем
+func TestRevertAfterAuthorRename(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Kong Qiu", Text: "Life is simple"})
+	var quote models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	path := fmt.Sprintf("/quotes/%d", quote.ID)
+	if status := serveJSON(router, "PUT", path, models.Quote{Author: "Kong Qiu", Text: "Life is hard"}).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	authorPath := fmt.Sprintf("/authors/%d", quote.AuthorID)
+	if status := serveJSON(router, "PUT", authorPath, models.Author{Name: "Confucius"}).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	rr = serveJSON(router, "POST", path+"/history/1/revert", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var reverted models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &reverted); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if reverted.Text != "Life is simple" || reverted.AuthorID != quote.AuthorID || reverted.Author != "Confucius" {
+		t.Errorf("handler returned unexpected quote: %+v", reverted)
+	}
+
+	rr = serveJSON(router, "GET", "/authors", nil)
+	var authors []models.Author
+	if err := json.Unmarshal(rr.Body.Bytes(), &authors); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(authors) != 1 {
+		t.Errorf("revert created an author: %+v", authors)
+	}
+}
+
+// TestDiffWords проверяет пословное сравнение текстов
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want []models.DiffChunk
+	}{
+		{"", "", []models.DiffChunk{}},
+		{"same  words", "same words", []models.DiffChunk{{Op: models.DiffEqual, Text: "same words"}}},
+		{"", "new text", []models.DiffChunk{{Op: models.DiffInsert, Text: "new text"}}},
+		{"old text", "", []models.DiffChunk{{Op: models.DiffDelete, Text: "old text"}}},
+		{"a b c d", "a x c d", []models.DiffChunk{
+			{Op: models.DiffEqual, Text: "a"},
+			{Op: models.DiffDelete, Text: "b"},
+			{Op: models.DiffInsert, Text: "x"},
+			{Op: models.DiffEqual, Text: "c d"},
+		}},
+		{"the quick brown fox", "the brown quick fox", []models.DiffChunk{
+			{Op: models.DiffEqual, Text: "the"},
+			{Op: models.DiffDelete, Text: "quick"},
+			{Op: models.DiffEqual, Text: "brown"},
+			{Op: models.DiffInsert, Text: "quick"},
+			{Op: models.DiffEqual, Text: "fox"},
+		}},
+	}
+	for _, tt := range tests {
+		if got, ok := models.DiffWords(tt.from, tt.to); !ok || !slices.Equal(got, tt.want) {
+			t.Errorf("DiffWords(%q, %q) = %+v, %v, want %+v", tt.from, tt.to, got, ok, tt.want)
+		}
+	}
+
+	// Общие начало и конец текстов не учитываются в ограничении размера.
+	long := strings.Repeat("word ", 1000)
+	if _, ok := models.DiffWords(long+"old", long+"new"); !ok {
+		t.Error("DiffWords failed for long texts with a small change")
+	}
+	if _, ok := models.DiffWords(strings.Repeat("old ", 1000), strings.Repeat("new ", 1000)); ok {
+		t.Error("DiffWords compared texts differing in too many words")
+	}
+}
+
+// TestDiffTooLarge проверяет ответ на сравнение правок со слишком большими различиями
+func TestDiffTooLarge(t *testing.T) {
+	router := setupTestServer()
+
+	if status := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Confucius", Text: strings.Repeat("old ", 500)}).Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if status := serveJSON(router, "PUT", "/quotes/1", models.Quote{Author: "Confucius", Text: strings.Repeat("new ", 500)}).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if status := serveJSON(router, "GET", "/quotes/1/history/diff", nil).Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/importer"
+	"quotes/internal/services"
+	authormemory "quotes/internal/storage/authors/memory"
+	"quotes/internal/storage/quotes/memory"
+)
+
+const fortuneFile = `Life is simple, but we insist on making it complicated.
+		-- Confucius
+%
+	The secret of getting ahead
+	is getting started.
+		-- Mark Twain,
+		   "Notebook"
+%
+Anonymous wisdom.
+%
+
+%
+"Real knowledge is to know the extent of one's ignorance."
+		— Confucius
+%
+`
+
+const textFile = `# Seneca
+
+> Luck is what happens
+> when preparation meets opportunity.
+
+* "We suffer more often in imagination than in reality." -- Seneca, Letters
+* Difficulties strengthen the mind.
+— Lucius Annaeus Seneca
+
+Life is simple, but we insist on making it complicated. — Confucius
+`
+
+// TestParseFortune проверяет разбор файлов fortune с подписями авторов
+func TestParseFortune(t *testing.T) {
+	entries, err := importer.ParseFortune(strings.NewReader(fortuneFile))
+	if err != nil {
+		t.Fatalf("ParseFortune failed: %v", err)
+	}
+
+	want := []struct {
+		line   int
+		author string
+		text   string
+		source string
+	}{
+		{1, "Confucius", "Life is simple, but we insist on making it complicated.", ""},
+		{4, "Mark Twain", "The secret of getting ahead\nis getting started.", "Notebook"},
+		{9, "", "Anonymous wisdom.", ""},
+		{13, "Confucius", "Real knowledge is to know the extent of one's ignorance.", ""},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("ParseFortune returned %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		got := entries[i]
+		var source string
+		if got.Quote.Source != nil {
+			source = got.Quote.Source.Title
+		}
+		if got.Line != w.line || got.Quote.Author != w.author || got.Quote.Text != w.text || source != w.source {
+			t.Errorf("entry %d: got line %d, %q, %q, source %q; want line %d, %q, %q, source %q",
+				i, got.Line, got.Quote.Author, got.Quote.Text, source, w.line, w.author, w.text, w.source)
+		}
+	}
+}
+
+// TestParseText проверяет разбор текстового формата с цитатами в Markdown
+func TestParseText(t *testing.T) {
+	entries, err := importer.ParseText(strings.NewReader(textFile))
+	if err != nil {
+		t.Fatalf("ParseText failed: %v", err)
+	}
+
+	want := []struct {
+		author string
+		text   string
+	}{
+		{"Seneca", "Luck is what happens when preparation meets opportunity."},
+		{"Seneca", "We suffer more often in imagination than in reality."},
+		{"Lucius Annaeus Seneca", "Difficulties strengthen the mind."},
+		{"Confucius", "Life is simple, but we insist on making it complicated."},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("ParseText returned %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		if got := entries[i].Quote; got.Author != w.author || got.Text != w.text {
+			t.Errorf("entry %d: got %q, %q; want %q, %q", i, got.Author, got.Text, w.author, w.text)
+		}
+	}
+	if source := entries[1].Quote.Source; source == nil || source.Title != "Letters" {
+		t.Errorf("entry 1: got source %+v, want title %q", source, "Letters")
+	}
+}
+
+// TestImporter проверяет пропуск дубликатов, автора по умолчанию и пробный запуск
+func TestImporter(t *testing.T) {
+	repo := memory.NewQuoteStorage()
+	authorService := services.NewAuthorService(authormemory.NewAuthorStorage(), repo)
+	service := services.NewQuoteService(repo, authorService, services.QuoteOptions{})
+	if err := service.CreateQuote(&models.Quote{Author: "confucius", Text: "Life is  simple, but we insist on making it complicated."}, false, ""); err != nil {
+		t.Fatalf("CreateQuote failed: %v", err)
+	}
+
+	fortunes, err := importer.ParseFortune(strings.NewReader(fortuneFile))
+	if err != nil {
+		t.Fatalf("ParseFortune failed: %v", err)
+	}
+	texts, err := importer.ParseText(strings.NewReader(textFile))
+	if err != nil {
+		t.Fatalf("ParseText failed: %v", err)
+	}
+
+	dry := importer.New(service, importer.Options{DryRun: true})
+	if err := dry.Import("fortunes", fortunes); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	summary := dry.Summary()
+	if summary.Parsed != 4 || summary.Created != 2 || summary.Duplicates != 1 || summary.Unattributed != 1 {
+		t.Errorf("dry run returned unexpected summary: %+v", summary)
+	}
+	if quotes, _ := repo.GetAll(); len(quotes) != 1 {
+		t.Errorf("dry run created quotes: %+v", quotes)
+	}
+
+	imp := importer.New(service, importer.Options{DefaultAuthor: "Unknown"})
+	for name, entries := range map[string][]importer.Entry{"fortunes": fortunes, "quotes.md": texts} {
+		if err := imp.Import(name, entries); err != nil {
+			t.Fatalf("Import(%s) failed: %v", name, err)
+		}
+	}
+	summary = imp.Summary()
+	if summary.Parsed != 8 || summary.Created != 6 || summary.Duplicates != 2 || summary.Unattributed != 0 || len(summary.Failures) != 0 {
+		t.Errorf("import returned unexpected summary: %+v", summary)
+	}
+	if quotes, _ := repo.GetAll(); len(quotes) != 7 {
+		t.Errorf("unexpected number of quotes after import: got %v want %v", len(quotes), 7)
+	}
+
+	// Повторный импорт не создает цитат.
+	again := importer.New(service, importer.Options{DefaultAuthor: "Unknown"})
+	if err := again.Import("fortunes", fortunes); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if summary := again.Summary(); summary.Created != 0 || summary.Duplicates != 4 {
+		t.Errorf("repeated import returned unexpected summary: %+v", summary)
+	}
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	"quotes/internal/storage"
+	authormemory "quotes/internal/storage/authors/memory"
+	"quotes/internal/storage/quotes/memory"
+)
+
+type duplicateReport struct {
+	Duplicates int                       `json:"duplicates"`
+	Clusters   []models.DuplicateCluster `json:"clusters"`
+}
+
+// TestMergeDuplicates проверяет поиск и слияние повторяющихся цитат
+func TestMergeDuplicates(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Confucius", Text: "Life is really simple, but we insist on making it complicated.", Tags: []string{"life"}},
+		{Author: "Seneca", Text: "Luck is what happens when preparation meets opportunity."},
+		{Author: "confucius", Text: "Life is really simple but we insist on making it complicated", Tags: []string{"simplicity"}},
+		{Author: "Confucius", Text: "Life is realy simple, but we insist on making it complicated!", Tags: []string{"life"}},
+		{Author: "Seneca", Text: "Life is really simple, but we insist on making it complicated."},
+	} {
+		if status := serveJSON(router, "POST", "/quotes?allow_duplicate=true", quote).Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	}
+
+	rr := serveJSON(router, "GET", "/admin/duplicates", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var report duplicateReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if report.Duplicates != 2 || len(report.Clusters) != 1 {
+		t.Fatalf("handler returned unexpected report: %+v", report)
+	}
+	cluster := report.Clusters[0]
+	if cluster.Canonical.ID != 1 || cluster.Duplicates[0].Quote.ID != 3 || cluster.Duplicates[1].Quote.ID != 4 {
+		t.Errorf("handler returned unexpected cluster: %+v", cluster)
+	}
+	if similarity := cluster.Duplicates[0].Similarity; similarity != 1 {
+		t.Errorf("handler returned wrong similarity for exact duplicate: got %v want %v", similarity, 1)
+	}
+	if similarity := cluster.Duplicates[1].Similarity; similarity < models.NearDuplicateSimilarity || similarity >= 1 {
+		t.Errorf("handler returned wrong similarity for near duplicate: %v", similarity)
+	}
+
+	rr = serveJSON(router, "POST", "/admin/duplicates/merge", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	rr = serveJSON(router, "GET", "/quotes/1", nil)
+	var survivor models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &survivor); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if want := []string{"life", "simplicity"}; !slices.Equal(survivor.Tags, want) {
+		t.Errorf("merged quote has unexpected tags: got %v want %v", survivor.Tags, want)
+	}
+
+	for _, id := range []int{3, 4} {
+		rr = serveJSON(router, "GET", fmt.Sprintf("/quotes/%d", id), nil)
+		if status := rr.Code; status != http.StatusMovedPermanently {
+			t.Errorf("handler returned wrong status code for merged quote: got %v want %v", status, http.StatusMovedPermanently)
+		}
+		if location := rr.Header().Get("Location"); location != "/quotes/1" {
+			t.Errorf("handler returned unexpected Location for merged quote: %q", location)
+		}
+	}
+	if status := serveJSON(router, "GET", "/quotes/1000", nil).Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for unknown quote: got %v want %v", status, http.StatusNotFound)
+	}
+
+	// История слитой цитаты сохраняется и указывает, куда она слита.
+	rr = serveJSON(router, "GET", "/quotes/3/history", nil)
+	var history []models.Revision
+	if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if n := len(history); n != 2 || history[1].Action != models.RevisionMerge || history[1].MergedInto != 1 {
+		t.Errorf("handler returned unexpected history of merged quote: %+v", history)
+	}
+
+	rr = serveJSON(router, "GET", "/admin/duplicates", nil)
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if report.Duplicates != 0 || len(report.Clusters) != 0 {
+		t.Errorf("handler returned duplicates after merge: %+v", report)
+	}
+}
+
+// editedQuotes изменяет последнюю цитату сразу после того, как все цитаты прочитаны
+type editedQuotes struct {
+	services.QuoteRepository
+}
+
+func (r editedQuotes) GetAll() ([]models.Quote, error) {
+	quotes, err := r.QuoteRepository.GetAll()
+	if err == nil && len(quotes) > 0 {
+		edited := quotes[len(quotes)-1]
+		edited.Tags = []string{"edited"}
+		err = r.QuoteRepository.Update(&edited)
+	}
+	return quotes, err
+}
+
+// TestMergeDuplicatesConflict проверяет, что группа, цитату которой изменили после
+// поиска повторов, не сливается и изменение не теряется
+func TestMergeDuplicatesConflict(t *testing.T) {
+	quotes := memory.NewQuoteStorage()
+	authors := services.NewAuthorService(authormemory.NewAuthorStorage(), quotes)
+	service := services.NewQuoteService(editedQuotes{quotes}, authors, services.QuoteOptions{})
+
+	for _, text := range []string{"Life is really simple.", "Life is really simple!"} {
+		if err := quotes.Create(&models.Quote{Author: "Confucius", Text: text}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+
+	clusters, err := service.MergeDuplicates("alice")
+	if !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Fatalf("unexpected error merging duplicates: got %v want %v", err, storage.ErrVersionMismatch)
+	}
+	if len(clusters) != 0 {
+		t.Errorf("unexpected merged clusters: %+v", clusters)
+	}
+	edited, err := quotes.GetByID(2)
+	if err != nil {
+		t.Fatalf("edited quote was merged: %v", err)
+	}
+	if !slices.Equal(edited.Tags, []string{"edited"}) {
+		t.Errorf("edit of the quote was lost: %+v", edited)
+	}
+}
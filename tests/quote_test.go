@@ -3,30 +3,41 @@ package tests
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"quotes/internal/domain/models"
 	"quotes/internal/handlers"
 	"quotes/internal/services"
+	authormemory "quotes/internal/storage/authors/memory"
 	"quotes/internal/storage/quotes/memory"
 
 	"github.com/gorilla/mux"
 )
 
+// quoteList - ответ со списком цитат
+type quoteList struct {
+	Quotes     []models.Quote `json:"quotes"`
+	NextCursor string         `json:"next_cursor"`
+}
+
 // setupTestServer создает тестовый сервер с настроенными маршрутами
 func setupTestServer() *mux.Router {
 	storage := memory.NewQuoteStorage()
-	quoteService := services.NewQuoteService(storage)
+	authorService := services.NewAuthorService(authormemory.NewAuthorStorage(), storage)
+	quoteService := services.NewQuoteService(storage, authorService, services.QuoteOptions{})
 	quoteHandler := handlers.NewQuoteHandler(quoteService)
+	authorHandler := handlers.NewAuthorHandler(authorService)
 
 	r := mux.NewRouter()
-	r.HandleFunc("/quotes", quoteHandler.CreateQuote).Methods("POST")
-	r.HandleFunc("/quotes", quoteHandler.GetAllQuotes).Methods("GET")
-	r.HandleFunc("/quotes/random", quoteHandler.GetRandomQuote).Methods("GET")
-	r.HandleFunc("/quotes", quoteHandler.GetQuotesByAuthor).Methods("GET").Queries("author", "{author}")
-	r.HandleFunc("/quotes/{id:[0-9]+}", quoteHandler.DeleteQuote).Methods("DELETE")
+	quoteHandler.RegisterRoutes(r)
+	authorHandler.RegisterRoutes(r)
 
 	return r
 }
@@ -126,11 +137,12 @@ func TestGetAllQuotes(t *testing.T) {
 			status, http.StatusOK)
 	}
 
-	var quotes []models.Quote
-	err := json.Unmarshal(rr.Body.Bytes(), &quotes)
+	var list quoteList
+	err := json.Unmarshal(rr.Body.Bytes(), &list)
 	if err != nil {
 		t.Errorf("failed to unmarshal: %v", err)
 	}
+	quotes := list.Quotes
 
 	if len(quotes) == 0 {
 		t.Error("handler returned empty quotes list")
@@ -205,11 +217,12 @@ func TestGetQuotesByAuthor(t *testing.T) {
 			status, http.StatusOK)
 	}
 
-	var quotes []models.Quote
-	err := json.Unmarshal(rr.Body.Bytes(), &quotes)
+	var list quoteList
+	err := json.Unmarshal(rr.Body.Bytes(), &list)
 	if err != nil {
 		t.Errorf("failed to unmarshal: %v", err)
 	}
+	quotes := list.Quotes
 
 	if len(quotes) == 0 {
 		t.Error("handler returned empty quotes list")
@@ -251,6 +264,7 @@ func TestDeleteQuote(t *testing.T) {
 	router.ServeHTTP(httptest.NewRecorder(), req)
 
 	req, _ = http.NewRequest("DELETE", "/quotes/1", nil)
+	req.Header.Set("If-Match", `"1"`)
 	rr := httptest.NewRecorder()
 
 	router.ServeHTTP(rr, req)
@@ -264,11 +278,12 @@ func TestDeleteQuote(t *testing.T) {
 	rr = httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 
-	var quotes []models.Quote
-	err := json.Unmarshal(rr.Body.Bytes(), &quotes)
+	var list quoteList
+	err := json.Unmarshal(rr.Body.Bytes(), &list)
 	if err != nil {
 		t.Errorf("failed to unmarshal: %v", err)
 	}
+	quotes := list.Quotes
 
 	if len(quotes) != 0 {
 		t.Error("quote was not deleted")
@@ -280,6 +295,7 @@ func TestDeleteNonExistentQuote(t *testing.T) {
 	router := setupTestServer()
 
 	req, _ := http.NewRequest("DELETE", "/quotes/999", nil)
+	req.Header.Set("If-Match", "*")
 	rr := httptest.NewRecorder()
 
 	router.ServeHTTP(rr, req)
@@ -335,17 +351,376 @@ func TestGetQuotesByAuthorNotFound(t *testing.T) {
 			status, http.StatusOK)
 	}
 
-	var quotes []models.Quote
-	err := json.Unmarshal(rr.Body.Bytes(), &quotes)
+	var list quoteList
+	err := json.Unmarshal(rr.Body.Bytes(), &list)
 	if err != nil {
 		t.Errorf("failed to unmarshal: %v", err)
 	}
+	quotes := list.Quotes
 
 	if len(quotes) != 0 {
 		t.Error("handler returned non-empty quotes list for nonexistent author")
 	}
 }
 
+// TestGetQuotesByAuthorFilters проверяет, что фильтр по автору отбрасывает цитаты других авторов
+func TestGetQuotesByAuthorFilters(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Confucius", Text: "First"},
+		{Author: "Seneca", Text: "Second"},
+		{Author: "Confucius", Text: "Third"},
+	} {
+		body, _ := json.Marshal(quote)
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/quotes?author=Seneca", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	var list quoteList
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	quotes := list.Quotes
+	if len(quotes) != 1 || quotes[0].Author != "Seneca" {
+		t.Errorf("handler returned unexpected quotes: got %+v want only Seneca", quotes)
+	}
+}
+
+// TestListQuotesFilters проверяет совместное применение фильтров списка цитат
+func TestListQuotesFilters(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Confucius", Text: "Life is simple"},
+		{Author: "Seneca", Text: "Life is long"},
+		{Author: "Confucius", Text: "Silence is a true friend"},
+	} {
+		body, _ := json.Marshal(quote)
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"author and text", "author=Confucius&text=LIFE", 1},
+		{"text only", "text=life", 2},
+		{"created range", "created_after=" + today + "&created_before=" + tomorrow, 3},
+		{"created after tomorrow", "created_after=" + tomorrow, 0},
+		{"created before RFC 3339", "created_before=2000-01-01T00:00:00Z", 0},
+		{"unknown tag", "tag=wisdom", 0},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/quotes?"+tt.query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v",
+				tt.name, status, http.StatusOK)
+			continue
+		}
+
+		var list quoteList
+		if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+			t.Fatalf("%s: failed to unmarshal: %v", tt.name, err)
+		}
+		quotes := list.Quotes
+		if len(quotes) != tt.want {
+			t.Errorf("%s: handler returned %d quotes, want %d", tt.name, len(quotes), tt.want)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/quotes?created_after=yesterday", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for invalid date: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+// TestListQuotesPagination проверяет постраничную выдачу списка цитат
+func TestListQuotesPagination(t *testing.T) {
+	router := setupTestServer()
+
+	for i := range 5 {
+		quote := models.Quote{Author: "Test Author", Text: fmt.Sprintf("Test Quote %d", i)}
+		body, _ := json.Marshal(quote)
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var ids []int64
+	next := "/quotes?author=Test+Author&limit=2"
+	for pages := 0; next != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("handler returned too many pages")
+		}
+
+		req, _ := http.NewRequest("GET", next, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v",
+				status, http.StatusOK)
+		}
+
+		var list quoteList
+		if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		for _, quote := range list.Quotes {
+			ids = append(ids, quote.ID)
+		}
+
+		link := rr.Header().Get("Link")
+		if list.NextCursor == "" {
+			if link != "" {
+				t.Errorf("handler returned Link header on the last page: %v", link)
+			}
+			next = ""
+			continue
+		}
+
+		start, end := strings.Index(link, "<"), strings.Index(link, ">")
+		if start != 0 || end < 0 || !strings.HasSuffix(link, `rel="next"`) {
+			t.Fatalf("handler returned unexpected Link header: %v", link)
+		}
+		next = link[start+1 : end]
+		if !strings.Contains(next, "cursor="+list.NextCursor) || !strings.Contains(next, "author=Test+Author") {
+			t.Errorf("Link header does not continue the listing: %v", next)
+		}
+	}
+
+	if want := []int64{1, 2, 3, 4, 5}; !slices.Equal(ids, want) {
+		t.Errorf("handler returned unexpected IDs: got %v want %v", ids, want)
+	}
+
+	for _, query := range []string{"limit=0", "limit=abc", "limit=100000", "cursor=not-a-cursor"} {
+		req, _ := http.NewRequest("GET", "/quotes?"+query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v",
+				query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestListQuotesSort проверяет сортировку списка цитат
+func TestListQuotesSort(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Seneca", Text: "Second"},
+		{Author: "Confucius", Text: "First"},
+		{Author: "Seneca", Text: "Third"},
+	} {
+		body, _ := json.Marshal(quote)
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/quotes?sort=author,-created_at&limit=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	var list quoteList
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	ids := []int64{}
+	for _, quote := range list.Quotes {
+		ids = append(ids, quote.ID)
+	}
+	if want := []int64{2, 3}; !slices.Equal(ids, want) {
+		t.Errorf("handler returned unexpected IDs: got %v want %v", ids, want)
+	}
+
+	cursor := list.NextCursor
+	req, _ = http.NewRequest("GET", "/quotes?sort=author,-created_at&limit=2&cursor="+cursor, nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	list = quoteList{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(list.Quotes) != 1 || list.Quotes[0].ID != 1 {
+		t.Errorf("handler returned unexpected second page: %+v", list.Quotes)
+	}
+
+	// Курсор действителен только для того же порядка сортировки
+	for _, query := range []string{"sort=rating", "sort=author,-author", "sort=-author&cursor=" + cursor} {
+		req, _ := http.NewRequest("GET", "/quotes?"+query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v",
+				query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestSearchQuotes проверяет полнотекстовый поиск цитат
+func TestSearchQuotes(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Leonardo da Vinci", Text: "Simplicity is the ultimate sophistication."},
+		{Author: "Seneca", Text: "Luck is what happens when preparation meets opportunity."},
+		{Author: "Лев Толстой", Text: "Всё гениальное просто."},
+	} {
+		body, _ := json.Marshal(quote)
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"simplicity", []int64{1}},
+		{"Simpl", []int64{1}},
+		{"гениальн", []int64{3}},
+		{"zebra", []int64{}},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/quotes/search?q="+url.QueryEscape(tt.query), nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code for %q: got %v want %v",
+				tt.query, status, http.StatusOK)
+			continue
+		}
+
+		var results []models.ScoredQuote
+		if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		ids := []int64{}
+		for _, result := range results {
+			if result.Score <= 0 {
+				t.Errorf("handler returned non-positive score for %q: %v", tt.query, result.Score)
+			}
+			ids = append(ids, result.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("handler returned unexpected IDs for %q: got %v want %v", tt.query, ids, tt.want)
+		}
+	}
+
+	for _, query := range []string{"", "q=", "q=...", "q=life&limit=0"} {
+		req, _ := http.NewRequest("GET", "/quotes/search?"+query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for %q: got %v want %v",
+				query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestListQuotesAuthorMatching проверяет нечеткий поиск по автору и подсказки
+func TestListQuotesAuthorMatching(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Confucius", Text: "First"},
+		{Author: "Leonardo da Vinci", Text: "Second"},
+	} {
+		body, _ := json.Marshal(quote)
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// По псевдониму находятся цитаты, сохраненные под основным именем автора.
+	if status := serveJSON(router, "PUT", "/authors/1", models.Author{Name: "Confucius", Aliases: []string{"Конфуций"}}).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code for author update: got %v want %v", status, http.StatusOK)
+	}
+
+	tests := []struct {
+		query       string
+		quotes      int
+		suggestions []string
+	}{
+		{"author=" + url.QueryEscape(" confucius "), 1, nil},
+		{"author=" + url.QueryEscape("конфуций"), 1, nil},
+		{"author=Confucious", 0, []string{"Confucius"}},
+		{"author=Confucious&match=fuzzy", 1, nil},
+		{"author=vinci", 0, []string{"Leonardo da Vinci"}},
+		{"author=Socrates", 0, nil},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/quotes?"+tt.query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v",
+				tt.query, status, http.StatusOK)
+			continue
+		}
+
+		var list struct {
+			Quotes      []models.Quote `json:"quotes"`
+			Suggestions []string       `json:"suggestions"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if len(list.Quotes) != tt.quotes {
+			t.Errorf("handler returned %d quotes for %s, want %d", len(list.Quotes), tt.query, tt.quotes)
+		}
+		if !slices.Equal(list.Suggestions, tt.suggestions) {
+			t.Errorf("handler returned unexpected suggestions for %s: got %v want %v",
+				tt.query, list.Suggestions, tt.suggestions)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/quotes?author=Confucius&match=soundex", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for unknown match mode: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
 // TestGetAllQuotesEmpty проверяет получение всех цитат при пустом хранилище
 func TestGetAllQuotesEmpty(t *testing.T) {
 	router := setupTestServer()
@@ -360,13 +735,274 @@ func TestGetAllQuotesEmpty(t *testing.T) {
 			status, http.StatusOK)
 	}
 
-	var quotes []models.Quote
-	err := json.Unmarshal(rr.Body.Bytes(), &quotes)
+	var list quoteList
+	err := json.Unmarshal(rr.Body.Bytes(), &list)
 	if err != nil {
 		t.Errorf("failed to unmarshal: %v", err)
 	}
+	quotes := list.Quotes
 
 	if len(quotes) != 0 {
 		t.Error("handler returned non-empty quotes list for empty storage")
 	}
 }
+
+// TestGetQuoteByID проверяет получение цитаты по ID
+func TestGetQuoteByID(t *testing.T) {
+	router := setupTestServer()
+
+	quote := models.Quote{
+		Author: "Test Author",
+		Text:   "Test Quote",
+	}
+	body, _ := json.Marshal(quote)
+
+	req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/quotes/1", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	var response models.Quote
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("failed to unmarshal: %v", err)
+	}
+
+	if response.ID != 1 || response.Author != quote.Author {
+		t.Errorf("handler returned unexpected quote: got %+v", response)
+	}
+}
+
+// TestGetQuoteByIDNotFound проверяет получение несуществующей цитаты
+func TestGetQuoteByIDNotFound(t *testing.T) {
+	router := setupTestServer()
+
+	req, _ := http.NewRequest("GET", "/quotes/999", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for non-existent quote: got %v want %v",
+			status, http.StatusNotFound)
+	}
+}
+
+// TestUpdateQuote проверяет полную замену цитаты через PUT
+func TestUpdateQuote(t *testing.T) {
+	router := setupTestServer()
+
+	quote := models.Quote{
+		Author: "Test Author",
+		Text:   "Tset Quote",
+	}
+	body, _ := json.Marshal(quote)
+
+	req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	quote.Text = "Test Quote"
+	body, _ = json.Marshal(quote)
+
+	req, _ = http.NewRequest("PUT", "/quotes/1", bytes.NewBuffer(body))
+	req.Header.Set("If-Match", `"1"`)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	var response models.Quote
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("failed to unmarshal: %v", err)
+	}
+
+	if response.ID != 1 || response.Text != "Test Quote" || response.UpdatedAt.IsZero() {
+		t.Errorf("handler returned unexpected quote: got %+v", response)
+	}
+
+	req, _ = http.NewRequest("PUT", "/quotes/1", bytes.NewBufferString(`{"author":"Test Author"}`))
+	req.Header.Set("If-Match", "*")
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for empty text: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+// TestPatchQuote проверяет частичное изменение цитаты через JSON Merge Patch
+func TestPatchQuote(t *testing.T) {
+	router := setupTestServer()
+
+	quote := models.Quote{
+		Author: "Tset Author",
+		Text:   "Test Quote",
+	}
+	body, _ := json.Marshal(quote)
+
+	req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	testCases := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		body        string
+		wantCode    int
+	}{
+		{
+			name:        "Wrong Content-Type",
+			contentType: "application/json",
+			ifMatch:     "*",
+			body:        `{"author":"Test Author"}`,
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Remove Text",
+			contentType: "application/merge-patch+json",
+			ifMatch:     "*",
+			body:        `{"quote":null}`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "Fix Author",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			body:        `{"author":"Test Author"}`,
+			wantCode:    http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PATCH", "/quotes/1", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req.Header.Set("If-Match", tc.ifMatch)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tc.wantCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, tc.wantCode)
+			}
+		})
+	}
+
+	req, _ = http.NewRequest("GET", "/quotes/1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var response models.Quote
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("failed to unmarshal: %v", err)
+	}
+
+	if response.Author != "Test Author" || response.Text != quote.Text {
+		t.Errorf("patch produced unexpected quote: got %+v", response)
+	}
+}
+
+// TestQuoteETags проверяет условные запросы с ETag, If-Match и If-None-Match
+func TestQuoteETags(t *testing.T) {
+	router := setupTestServer()
+
+	quote := models.Quote{
+		Author: "Test Author",
+		Text:   "Test Quote",
+	}
+	body, _ := json.Marshal(quote)
+
+	req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/quotes/1", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	etag := rr.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("handler returned unexpected ETag: got %v want %v", etag, `"1"`)
+	}
+
+	req, _ = http.NewRequest("GET", "/quotes/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotModified {
+		t.Errorf("handler returned wrong status code for matching If-None-Match: got %v want %v",
+			status, http.StatusNotModified)
+	}
+
+	req, _ = http.NewRequest("GET", "/quotes", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	listETag := rr.Header().Get("ETag")
+
+	req, _ = http.NewRequest("PUT", "/quotes/1", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusPreconditionRequired {
+		t.Errorf("handler returned wrong status code without If-Match: got %v want %v",
+			status, http.StatusPreconditionRequired)
+	}
+
+	req, _ = http.NewRequest("PUT", "/quotes/1", bytes.NewBuffer(body))
+	req.Header.Set("If-Match", etag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code for current If-Match: got %v want %v",
+			status, http.StatusOK)
+	}
+	if newETag := rr.Header().Get("ETag"); newETag != `"2"` {
+		t.Errorf("handler returned unexpected ETag after update: got %v want %v", newETag, `"2"`)
+	}
+
+	for _, method := range []string{"PUT", "DELETE"} {
+		req, _ = http.NewRequest(method, "/quotes/1", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", etag)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusPreconditionFailed {
+			t.Errorf("%s returned wrong status code for stale If-Match: got %v want %v",
+				method, status, http.StatusPreconditionFailed)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "/quotes", nil)
+	req.Header.Set("If-None-Match", listETag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code for changed list: got %v want %v",
+			status, http.StatusOK)
+	}
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"quotes/internal/domain/models"
+)
+
+// TestGetRandomQuoteFiltered проверяет выбор случайной цитаты с фильтрами и весами
+func TestGetRandomQuoteFiltered(t *testing.T) {
+	router := setupTestServer()
+
+	for _, quote := range []models.Quote{
+		{Author: "Confucius", Text: "Life is simple", Tags: []string{"philosophy"}, Rating: 5},
+		{Author: "Confucius", Text: "Real knowledge is to know the extent of one's ignorance"},
+		{Author: "Mark Twain", Text: "Get your facts first", Tags: []string{"humor"}},
+	} {
+		if status := serveJSON(router, "POST", "/quotes", quote).Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+	}
+
+	tests := []struct {
+		query string
+		text  string
+	}{
+		{"author=confucius&max_length=20", "Life is simple"},
+		{"tag=humor", "Get your facts first"},
+		{"author=Confucius&tag=philosophy&weight=rating", "Life is simple"},
+		{"max_length=14&weight=recency", "Life is simple"},
+	}
+	for _, tt := range tests {
+		for range 10 {
+			rr := serveJSON(router, "GET", "/quotes/random?"+tt.query, nil)
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code for %s: got %v want %v", tt.query, status, http.StatusOK)
+			}
+			var quote models.Quote
+			if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if quote.Text != tt.text {
+				t.Errorf("handler returned unexpected quote for %s: got %q want %q", tt.query, quote.Text, tt.text)
+			}
+		}
+	}
+
+	errorTests := []struct {
+		query  string
+		status int
+	}{
+		{"author=Seneca", http.StatusNotFound},
+		{"max_length=3", http.StatusNotFound},
+		{"max_length=0", http.StatusBadRequest},
+		{"weight=popularity", http.StatusBadRequest},
+	}
+	for _, tt := range errorTests {
+		if status := serveJSON(router, "GET", "/quotes/random?"+tt.query, nil).Code; status != tt.status {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v", tt.query, status, tt.status)
+		}
+	}
+
+	rr := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Seneca", Text: "Text", Rating: 6})
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for invalid rating: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+// shuffledQuote запрашивает случайную цитату с токеном клиента в заголовке
+func shuffledQuote(t *testing.T, handler http.Handler, client string) models.Quote {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", "/quotes/random", nil)
+	req.Header.Set("X-Client-Token", client)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var quote models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	return quote
+}
+
+// TestGetRandomQuoteShuffle проверяет, что клиент с токеном получает все цитаты
+// без повторов, включая добавленные во время цикла, и не получает удаленные
+func TestGetRandomQuoteShuffle(t *testing.T) {
+	router := setupTestServer()
+
+	create := func(text string) models.Quote {
+		rr := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Confucius", Text: text})
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+		var quote models.Quote
+		if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		return quote
+	}
+	unseen := make(map[int64]bool)
+	for i := range 4 {
+		unseen[create(fmt.Sprintf("Quote %d", i)).ID] = true
+	}
+
+	for range 2 {
+		quote := shuffledQuote(t, router, "screen")
+		if !unseen[quote.ID] {
+			t.Fatalf("handler repeated quote %d within a cycle", quote.ID)
+		}
+		delete(unseen, quote.ID)
+	}
+
+	// Новая цитата попадает в текущий цикл, удаленная пропускается.
+	var deleted int64
+	for id := range unseen {
+		deleted = id
+		break
+	}
+	delete(unseen, deleted)
+	unseen[create("Added").ID] = true
+	if status := serveJSON(router, "DELETE", fmt.Sprintf("/quotes/%d", deleted), nil).Code; status != http.StatusNoContent {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+
+	var last models.Quote
+	for range len(unseen) {
+		last = shuffledQuote(t, router, "screen")
+		if !unseen[last.ID] {
+			t.Fatalf("handler returned quote %d out of the cycle", last.ID)
+		}
+		delete(unseen, last.ID)
+	}
+
+	// Новый цикл не начинается с последней показанной цитаты.
+	if quote := shuffledQuote(t, router, "screen"); quote.ID == last.ID {
+		t.Errorf("handler repeated quote %d across cycles", quote.ID)
+	}
+
+	// Токен в параметре запроса работает вместе с фильтрами.
+	for range 3 {
+		rr := serveJSON(router, "GET", "/quotes/random?client=other&author=Confucius&max_length=5", nil)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var quote models.Quote
+		if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if quote.Text != "Added" {
+			t.Errorf("handler returned unexpected quote: got %q want %q", quote.Text, "Added")
+		}
+	}
+
+	if status := serveJSON(router, "GET", "/quotes/random?client=other&weight=rating", nil).Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for weight with client token: got %v want %v", status, http.StatusBadRequest)
+	}
+	if status := serveJSON(router, "GET", "/quotes/random?client=other&author=Seneca", nil).Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for no matching quotes: got %v want %v", status, http.StatusNotFound)
+	}
+}
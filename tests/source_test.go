@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"quotes/internal/domain/models"
+)
+
+// TestQuoteSource проверяет проверку источника цитаты и фильтрацию по статусу проверки
+func TestQuoteSource(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveJSON(router, "POST", "/quotes", models.Quote{
+		Author: "Seneca",
+		Text:   "Luck is what happens when preparation meets opportunity",
+		Source: &models.Source{
+			Kind:  models.SourceBook,
+			Title: " Letters from a Stoic ",
+			Page:  "12",
+			Year:  65,
+			URL:   "https://example.com/letters",
+		},
+		Verification: models.VerificationVerified,
+	})
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var quote models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if quote.Source == nil || quote.Source.Title != "Letters from a Stoic" {
+		t.Errorf("handler returned unexpected source: %+v", quote.Source)
+	}
+
+	rr = serveJSON(router, "POST", "/quotes", models.Quote{Author: "Einstein", Text: "Insanity is doing the same thing"})
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if quote.Verification != models.VerificationUnverified {
+		t.Errorf("handler returned unexpected verification: got %v want %v", quote.Verification, models.VerificationUnverified)
+	}
+
+	invalid := []struct {
+		name  string
+		quote models.Quote
+	}{
+		{"verified without source", models.Quote{Verification: models.VerificationVerified}},
+		{"unknown status", models.Quote{Verification: "maybe"}},
+		{"empty source", models.Quote{Source: &models.Source{Kind: models.SourceBook}}},
+		{"unknown kind", models.Quote{Source: &models.Source{Kind: "tweet", Title: "Tweet"}}},
+		{"relative url", models.Quote{Source: &models.Source{URL: "/letters"}}},
+		{"future year", models.Quote{Source: &models.Source{Title: "Memoirs", Year: 3000}}},
+	}
+	for _, tt := range invalid {
+		tt.quote.Author = "Seneca"
+		tt.quote.Text = "Text"
+		if status := serveJSON(router, "POST", "/quotes", tt.quote).Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v", tt.name, status, http.StatusBadRequest)
+		}
+	}
+
+	rr = serveJSON(router, "GET", "/quotes?verification=verified", nil)
+	var list quoteList
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(list.Quotes) != 1 || list.Quotes[0].Author != "Seneca" {
+		t.Errorf("handler returned unexpected verified quotes: %+v", list.Quotes)
+	}
+
+	if status := serveJSON(router, "GET", "/quotes?verification=true", nil).Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for unknown verification: got %v want %v", status, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/storage"
+	authorsqlite "quotes/internal/storage/authors/sqlite"
+	"quotes/internal/storage/migrate"
+	"quotes/internal/storage/quotes/sqlite"
+)
+
+func newSQLiteStorage(t *testing.T) *sqlite.QuoteStorage {
+	t.Helper()
+
+	return sqlite.NewQuoteStorage(newSQLiteDB(t))
+}
+
+// newSQLiteDB открывает временную базу данных с примененными миграциями
+func newSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sqlite.Open("file:" + filepath.Join(t.TempDir(), "quotes.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, sqlite.Migrations())
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+	return db
+}
+
+// TestSQLiteMigrations проверяет применение и откат миграций
+func TestSQLiteMigrations(t *testing.T) {
+	db, err := sqlite.Open("file:" + filepath.Join(t.TempDir(), "quotes.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, sqlite.Migrations())
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if pending == 0 {
+		t.Fatal("expected pending migrations on empty database")
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+	if pending, _ := migrator.Pending(); pending != 0 {
+		t.Errorf("unexpected pending migrations after up: got %v want %v", pending, 0)
+	}
+
+	for {
+		err := migrator.Down()
+		if errors.Is(err, migrate.ErrNoMigrationApplied) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to revert migration: %v", err)
+		}
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("failed to reapply migrations: %v", err)
+	}
+}
+
+// TestSQLiteStorage проверяет основные операции SQL-хранилища
+func TestSQLiteStorage(t *testing.T) {
+	s := newSQLiteStorage(t)
+
+	if _, err := s.GetRandom(models.QuoteQuery{}, models.WeightUniform); !errors.Is(err, storage.ErrNoQuotesAvailable) {
+		t.Errorf("unexpected error for empty storage: got %v want %v", err, storage.ErrNoQuotesAvailable)
+	}
+
+	for _, quote := range []models.Quote{
+		{Author: "Confucius", Text: "First"},
+		{Author: "Seneca", Text: "Second"},
+		{Author: "Confucius", Text: "Third"},
+	} {
+		if err := s.Create(&quote); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+		if quote.ID == 0 || quote.CreatedAt.IsZero() {
+			t.Errorf("quote was not stamped: %+v", quote)
+		}
+	}
+
+	quotes, err := s.GetByAuthor("Confucius")
+	if err != nil {
+		t.Fatalf("failed to get quotes by author: %v", err)
+	}
+	if len(quotes) != 2 {
+		t.Errorf("unexpected number of quotes by author: got %v want %v", len(quotes), 2)
+	}
+
+	if err := s.Delete(2, 0); err != nil {
+		t.Fatalf("failed to delete quote: %v", err)
+	}
+	if err := s.Delete(2, 0); !errors.Is(err, storage.ErrQuoteNotFound) {
+		t.Errorf("unexpected error for deleted quote: got %v want %v", err, storage.ErrQuoteNotFound)
+	}
+
+	for i := 0; i < 20; i++ {
+		quote, err := s.GetRandom(models.QuoteQuery{}, models.WeightUniform)
+		if err != nil {
+			t.Fatalf("failed to get random quote: %v", err)
+		}
+		if quote.ID == 2 {
+			t.Fatal("random quote returned a deleted quote")
+		}
+	}
+}
+
+// TestSQLiteRandomAfterGap проверяет, что цитата после пропуска в ID не
+// выбирается чаще остальных
+func TestSQLiteRandomAfterGap(t *testing.T) {
+	s := newSQLiteStorage(t)
+
+	for i := 1; i <= 10; i++ {
+		if err := s.Create(&models.Quote{Author: "Confucius", Text: fmt.Sprintf("Quote %d", i)}); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	for id := int64(2); id <= 9; id++ {
+		if err := s.Delete(id, 0); err != nil {
+			t.Fatalf("failed to delete quote: %v", err)
+		}
+	}
+
+	const picks = 400
+	first := 0
+	for i := 0; i < picks; i++ {
+		quote, err := s.GetRandom(models.QuoteQuery{}, models.WeightUniform)
+		if err != nil {
+			t.Fatalf("failed to get random quote: %v", err)
+		}
+		if quote.ID == 1 {
+			first++
+		}
+	}
+	if first < picks/4 || first > picks*3/4 {
+		t.Errorf("random pick is biased: quote 1 chosen %v times out of %v", first, picks)
+	}
+}
+
+// TestSQLiteAuthorsMigration проверяет создание авторов из существующих цитат при миграции
+func TestSQLiteAuthorsMigration(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	migrator, err := migrate.New(db, sqlite.Migrations())
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	// Откатываем миграции до создания таблицы авторов.
+	for {
+		statuses, err := migrator.Status()
+		if err != nil {
+			t.Fatalf("failed to get status: %v", err)
+		}
+		if i := slices.IndexFunc(statuses, func(s migrate.Status) bool { return s.Name == "create_authors" }); statuses[i].AppliedAt == nil {
+			break
+		}
+		if err := migrator.Down(); err != nil {
+			t.Fatalf("failed to revert migration: %v", err)
+		}
+	}
+	for _, author := range []string{"Confucius", "Seneca", " CONFUCIUS"} {
+		_, err := db.Exec(
+			"INSERT INTO quotes (author, author_key, text, created_at) VALUES (?, ?, ?, ?)",
+			author, models.NormalizeAuthor(author), "Text", time.Now().UTC(),
+		)
+		if err != nil {
+			t.Fatalf("failed to insert quote: %v", err)
+		}
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	authors, err := authorsqlite.NewAuthorStorage(db).GetAll()
+	if err != nil {
+		t.Fatalf("failed to get authors: %v", err)
+	}
+	var names []string
+	for _, author := range authors {
+		names = append(names, author.Name)
+	}
+	if want := []string{"Confucius", "Seneca"}; !slices.Equal(names, want) {
+		t.Errorf("unexpected authors after migration: got %v want %v", names, want)
+	}
+
+	quotes, err := sqlite.NewQuoteStorage(db).GetAll()
+	if err != nil {
+		t.Fatalf("failed to get quotes: %v", err)
+	}
+	for i, want := range []int64{authors[0].ID, authors[1].ID, authors[0].ID} {
+		if quotes[i].AuthorID != want {
+			t.Errorf("quote %d linked to wrong author: got %v want %v", quotes[i].ID, quotes[i].AuthorID, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+
+	"quotes/internal/services"
+	authorfile "quotes/internal/storage/authors/file"
+	authormemory "quotes/internal/storage/authors/memory"
+	authorsqlite "quotes/internal/storage/authors/sqlite"
+	"quotes/internal/storage/quotes/file"
+	"quotes/internal/storage/quotes/memory"
+	"quotes/internal/storage/storagetest"
+)
+
+// TestMemoryStorageConformance проверяет хранилище в памяти общим набором тестов
+func TestMemoryStorageConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) services.QuoteRepository {
+		return memory.NewQuoteStorage()
+	})
+}
+
+// TestFileStorageConformance проверяет файловое хранилище общим набором тестов
+func TestFileStorageConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) services.QuoteRepository {
+		s, err := file.NewQuoteStorage(t.TempDir(), file.Options{SnapshotThreshold: 10})
+		if err != nil {
+			t.Fatalf("failed to open storage: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+// TestSQLiteStorageConformance проверяет SQL-хранилище общим набором тестов
+func TestSQLiteStorageConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) services.QuoteRepository {
+		return newSQLiteStorage(t)
+	})
+}
+
+// TestMemoryAuthorStorageConformance проверяет хранилище авторов в памяти общим набором тестов
+func TestMemoryAuthorStorageConformance(t *testing.T) {
+	storagetest.RunAuthors(t, func(t *testing.T) services.AuthorRepository {
+		return authormemory.NewAuthorStorage()
+	})
+}
+
+// TestFileAuthorStorageConformance проверяет файловое хранилище авторов общим набором тестов
+func TestFileAuthorStorageConformance(t *testing.T) {
+	storagetest.RunAuthors(t, func(t *testing.T) services.AuthorRepository {
+		s, err := authorfile.NewAuthorStorage(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to open storage: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+// TestSQLiteAuthorStorageConformance проверяет SQL-хранилище авторов общим набором тестов
+func TestSQLiteAuthorStorageConformance(t *testing.T) {
+	storagetest.RunAuthors(t, func(t *testing.T) services.AuthorRepository {
+		return authorsqlite.NewAuthorStorage(newSQLiteDB(t))
+	})
+}
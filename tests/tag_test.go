@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"quotes/internal/domain/models"
+)
+
+// TestQuoteTags проверяет теги цитат, их подсчет и фильтрацию по тегам
+func TestQuoteTags(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveJSON(router, "POST", "/quotes", models.Quote{
+		Author: "Mark Twain",
+		Text:   "Get your facts first",
+		Tags:   []string{"Humor", "humor ", "Short"},
+	})
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var quote models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if want := []string{"humor", "short"}; !slices.Equal(quote.Tags, want) {
+		t.Errorf("handler returned unexpected tags: got %v want %v", quote.Tags, want)
+	}
+
+	for _, q := range []models.Quote{
+		{Author: "Seneca", Text: "Luck is what happens", Tags: []string{"philosophy", "short"}},
+		{Author: "Confucius", Text: "Real knowledge", Tags: []string{"philosophy"}},
+	} {
+		serveJSON(router, "POST", "/quotes", q)
+	}
+
+	rr = serveJSON(router, "GET", "/tags", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var tags []models.TagCount
+	if err := json.Unmarshal(rr.Body.Bytes(), &tags); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	want := []models.TagCount{{Tag: "philosophy", Count: 2}, {Tag: "short", Count: 2}, {Tag: "humor", Count: 1}}
+	if !slices.Equal(tags, want) {
+		t.Errorf("handler returned unexpected tags: got %v want %v", tags, want)
+	}
+
+	tests := []struct {
+		query  string
+		quotes int
+	}{
+		{"tag=short", 2},
+		{"tag=SHORT&tag=philosophy", 1},
+		{"tag=humor&tag=philosophy&tag_match=any", 3},
+		{"tag=humor&tag=philosophy&tag_match=all", 0},
+		{"tag=poetry", 0},
+	}
+	for _, tt := range tests {
+		rr := serveJSON(router, "GET", "/quotes?"+tt.query, nil)
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v", tt.query, status, http.StatusOK)
+			continue
+		}
+		var list quoteList
+		if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if len(list.Quotes) != tt.quotes {
+			t.Errorf("handler returned %d quotes for %s, want %d", len(list.Quotes), tt.query, tt.quotes)
+		}
+	}
+
+	if status := serveJSON(router, "GET", "/quotes?tag=short&tag_match=some", nil).Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for unknown tag_match: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	// Теги заменяются целиком через PATCH.
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/quotes/%d", quote.ID), strings.NewReader(`{"tags":["Wit"]}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", "*")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if want := []string{"wit"}; !slices.Equal(quote.Tags, want) {
+		t.Errorf("handler returned unexpected tags after patch: got %v want %v", quote.Tags, want)
+	}
+}
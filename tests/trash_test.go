@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"quotes/internal/domain/models"
+	"quotes/internal/services"
+	authormemory "quotes/internal/storage/authors/memory"
+	"quotes/internal/storage/quotes/memory"
+)
+
+// TestTrash проверяет удаление цитаты в корзину и ее восстановление
+func TestTrash(t *testing.T) {
+	router := setupTestServer()
+
+	rr := serveJSON(router, "POST", "/quotes", models.Quote{Author: "Confucius", Text: "Life is simple"})
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var quote models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &quote); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	path := fmt.Sprintf("/quotes/%d", quote.ID)
+
+	if status := serveJSON(router, "DELETE", path, nil).Code; status != http.StatusNoContent {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+	if status := serveJSON(router, "GET", path, nil).Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for deleted quote: got %v want %v", status, http.StatusNotFound)
+	}
+
+	rr = serveJSON(router, "GET", "/quotes/trash", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var trash []models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &trash); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != quote.ID || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("handler returned unexpected trash: %+v", trash)
+	}
+
+	// Автора цитаты в корзине нельзя удалить, а его новое имя достается
+	// восстановленной цитате.
+	authorPath := fmt.Sprintf("/authors/%d", quote.AuthorID)
+	if status := serveJSON(router, "DELETE", authorPath, nil).Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code for author with deleted quotes: got %v want %v", status, http.StatusConflict)
+	}
+	if status := serveJSON(router, "PUT", authorPath, models.Author{Name: "Kong Fuzi"}).Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	rr = serveJSON(router, "POST", path+"/restore", nil)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if rr.Header().Get("ETag") == "" {
+		t.Errorf("handler did not return an ETag")
+	}
+	var restored models.Quote
+	if err := json.Unmarshal(rr.Body.Bytes(), &restored); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if restored.ID != quote.ID || !restored.DeletedAt.IsZero() || restored.Author != "Kong Fuzi" {
+		t.Errorf("handler returned unexpected quote: %+v", restored)
+	}
+	if status := serveJSON(router, "GET", path, nil).Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code for restored quote: got %v want %v", status, http.StatusOK)
+	}
+
+	if status := serveJSON(router, "POST", path+"/restore", nil).Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code for quote not in trash: got %v want %v", status, http.StatusNotFound)
+	}
+	if status := serveJSON(router, "POST", "/quotes/0/restore", nil).Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for invalid ID: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+// TestPurgeTrash проверяет, что из корзины удаляются только цитаты старше срока хранения
+func TestPurgeTrash(t *testing.T) {
+	repo := memory.NewQuoteStorage()
+	authorService := services.NewAuthorService(authormemory.NewAuthorStorage(), repo)
+	service := services.NewQuoteService(repo, authorService, services.QuoteOptions{})
+
+	for i := range 3 {
+		quote := models.Quote{Author: "Confucius", Text: fmt.Sprintf("Quote number %d", i)}
+		if err := service.CreateQuote(&quote, false, ""); err != nil {
+			t.Fatalf("CreateQuote failed: %v", err)
+		}
+		if i < 2 {
+			if err := service.DeleteQuote(quote.ID, 0, ""); err != nil {
+				t.Fatalf("DeleteQuote failed: %v", err)
+			}
+		}
+	}
+
+	purged, err := service.PurgeTrash(services.DefaultTrashRetention)
+	if err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("PurgeTrash purged %d fresh quotes", purged)
+	}
+
+	purged, err = service.PurgeTrash(0)
+	if err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("PurgeTrash: got %d purged, want %d", purged, 2)
+	}
+	trash, err := service.ListTrash()
+	if err != nil {
+		t.Fatalf("ListTrash failed: %v", err)
+	}
+	if len(trash) != 0 {
+		t.Errorf("trash is not empty after PurgeTrash: %+v", trash)
+	}
+	quotes, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(quotes) != 1 {
+		t.Errorf("PurgeTrash removed live quotes: %+v", quotes)
+	}
+}